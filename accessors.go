@@ -0,0 +1,992 @@
+// Code generated by genaccessors; DO NOT EDIT.
+
+package nakama
+
+// The Get methods of the high-level types return the value of a pointer field, or its zero value if
+// the field or the receiver is nil, so that optional fields can be read without nil checks:
+//
+//	name := friend.GetUser().GetDisplayName()
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (l *LeaderboardRecord) GetCreateTime() string {
+	if l == nil || l.CreateTime == nil {
+		return ""
+	}
+	return *l.CreateTime
+}
+
+// GetExpiryTime returns ExpiryTime, or "" if it is nil.
+func (l *LeaderboardRecord) GetExpiryTime() string {
+	if l == nil || l.ExpiryTime == nil {
+		return ""
+	}
+	return *l.ExpiryTime
+}
+
+// GetLeaderboardID returns LeaderboardID, or "" if it is nil.
+func (l *LeaderboardRecord) GetLeaderboardID() string {
+	if l == nil || l.LeaderboardID == nil {
+		return ""
+	}
+	return *l.LeaderboardID
+}
+
+// GetNumScore returns NumScore, or 0 if it is nil.
+func (l *LeaderboardRecord) GetNumScore() int {
+	if l == nil || l.NumScore == nil {
+		return 0
+	}
+	return *l.NumScore
+}
+
+// GetOwnerID returns OwnerID, or "" if it is nil.
+func (l *LeaderboardRecord) GetOwnerID() string {
+	if l == nil || l.OwnerID == nil {
+		return ""
+	}
+	return *l.OwnerID
+}
+
+// GetRank returns Rank, or 0 if it is nil.
+func (l *LeaderboardRecord) GetRank() int64 {
+	if l == nil || l.Rank == nil {
+		return 0
+	}
+	return *l.Rank
+}
+
+// GetScore returns Score, or 0 if it is nil.
+func (l *LeaderboardRecord) GetScore() int64 {
+	if l == nil || l.Score == nil {
+		return 0
+	}
+	return *l.Score
+}
+
+// GetSubScore returns SubScore, or 0 if it is nil.
+func (l *LeaderboardRecord) GetSubScore() int64 {
+	if l == nil || l.SubScore == nil {
+		return 0
+	}
+	return *l.SubScore
+}
+
+// GetUpdateTime returns UpdateTime, or "" if it is nil.
+func (l *LeaderboardRecord) GetUpdateTime() string {
+	if l == nil || l.UpdateTime == nil {
+		return ""
+	}
+	return *l.UpdateTime
+}
+
+// GetUsername returns Username, or "" if it is nil.
+func (l *LeaderboardRecord) GetUsername() string {
+	if l == nil || l.Username == nil {
+		return ""
+	}
+	return *l.Username
+}
+
+// GetMaxNumScore returns MaxNumScore, or 0 if it is nil.
+func (l *LeaderboardRecord) GetMaxNumScore() int {
+	if l == nil || l.MaxNumScore == nil {
+		return 0
+	}
+	return *l.MaxNumScore
+}
+
+// GetNextCursor returns NextCursor, or "" if it is nil.
+func (l *LeaderboardRecordList) GetNextCursor() string {
+	if l == nil || l.NextCursor == nil {
+		return ""
+	}
+	return *l.NextCursor
+}
+
+// GetPrevCursor returns PrevCursor, or "" if it is nil.
+func (l *LeaderboardRecordList) GetPrevCursor() string {
+	if l == nil || l.PrevCursor == nil {
+		return ""
+	}
+	return *l.PrevCursor
+}
+
+// GetRankCount returns RankCount, or 0 if it is nil.
+func (l *LeaderboardRecordList) GetRankCount() int {
+	if l == nil || l.RankCount == nil {
+		return 0
+	}
+	return *l.RankCount
+}
+
+// GetAuthoritative returns Authoritative, or false if it is nil.
+func (t *Tournament) GetAuthoritative() bool {
+	if t == nil || t.Authoritative == nil {
+		return false
+	}
+	return *t.Authoritative
+}
+
+// GetID returns ID, or "" if it is nil.
+func (t *Tournament) GetID() string {
+	if t == nil || t.ID == nil {
+		return ""
+	}
+	return *t.ID
+}
+
+// GetTitle returns Title, or "" if it is nil.
+func (t *Tournament) GetTitle() string {
+	if t == nil || t.Title == nil {
+		return ""
+	}
+	return *t.Title
+}
+
+// GetDescription returns Description, or "" if it is nil.
+func (t *Tournament) GetDescription() string {
+	if t == nil || t.Description == nil {
+		return ""
+	}
+	return *t.Description
+}
+
+// GetDuration returns Duration, or 0 if it is nil.
+func (t *Tournament) GetDuration() int {
+	if t == nil || t.Duration == nil {
+		return 0
+	}
+	return *t.Duration
+}
+
+// GetCategory returns Category, or 0 if it is nil.
+func (t *Tournament) GetCategory() int {
+	if t == nil || t.Category == nil {
+		return 0
+	}
+	return *t.Category
+}
+
+// GetSortOrder returns SortOrder, or 0 if it is nil.
+func (t *Tournament) GetSortOrder() int {
+	if t == nil || t.SortOrder == nil {
+		return 0
+	}
+	return *t.SortOrder
+}
+
+// GetSize returns Size, or 0 if it is nil.
+func (t *Tournament) GetSize() int {
+	if t == nil || t.Size == nil {
+		return 0
+	}
+	return *t.Size
+}
+
+// GetMaxSize returns MaxSize, or 0 if it is nil.
+func (t *Tournament) GetMaxSize() int {
+	if t == nil || t.MaxSize == nil {
+		return 0
+	}
+	return *t.MaxSize
+}
+
+// GetMaxNumScore returns MaxNumScore, or 0 if it is nil.
+func (t *Tournament) GetMaxNumScore() int {
+	if t == nil || t.MaxNumScore == nil {
+		return 0
+	}
+	return *t.MaxNumScore
+}
+
+// GetCanEnter returns CanEnter, or false if it is nil.
+func (t *Tournament) GetCanEnter() bool {
+	if t == nil || t.CanEnter == nil {
+		return false
+	}
+	return *t.CanEnter
+}
+
+// GetEndActive returns EndActive, or 0 if it is nil.
+func (t *Tournament) GetEndActive() int {
+	if t == nil || t.EndActive == nil {
+		return 0
+	}
+	return *t.EndActive
+}
+
+// GetNextReset returns NextReset, or 0 if it is nil.
+func (t *Tournament) GetNextReset() int {
+	if t == nil || t.NextReset == nil {
+		return 0
+	}
+	return *t.NextReset
+}
+
+// GetPrevReset returns PrevReset, or 0 if it is nil.
+func (t *Tournament) GetPrevReset() int {
+	if t == nil || t.PrevReset == nil {
+		return 0
+	}
+	return *t.PrevReset
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (t *Tournament) GetCreateTime() string {
+	if t == nil || t.CreateTime == nil {
+		return ""
+	}
+	return *t.CreateTime
+}
+
+// GetStartTime returns StartTime, or "" if it is nil.
+func (t *Tournament) GetStartTime() string {
+	if t == nil || t.StartTime == nil {
+		return ""
+	}
+	return *t.StartTime
+}
+
+// GetEndTime returns EndTime, or "" if it is nil.
+func (t *Tournament) GetEndTime() string {
+	if t == nil || t.EndTime == nil {
+		return ""
+	}
+	return *t.EndTime
+}
+
+// GetStartActive returns StartActive, or 0 if it is nil.
+func (t *Tournament) GetStartActive() int {
+	if t == nil || t.StartActive == nil {
+		return 0
+	}
+	return *t.StartActive
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (t *TournamentList) GetCursor() string {
+	if t == nil || t.Cursor == nil {
+		return ""
+	}
+	return *t.Cursor
+}
+
+// GetNextCursor returns NextCursor, or "" if it is nil.
+func (t *TournamentRecordList) GetNextCursor() string {
+	if t == nil || t.NextCursor == nil {
+		return ""
+	}
+	return *t.NextCursor
+}
+
+// GetPrevCursor returns PrevCursor, or "" if it is nil.
+func (t *TournamentRecordList) GetPrevCursor() string {
+	if t == nil || t.PrevCursor == nil {
+		return ""
+	}
+	return *t.PrevCursor
+}
+
+// GetRankCount returns RankCount, or 0 if it is nil.
+func (t *TournamentRecordList) GetRankCount() int {
+	if t == nil || t.RankCount == nil {
+		return 0
+	}
+	return *t.RankCount
+}
+
+// GetCollection returns Collection, or "" if it is nil.
+func (s *StorageObject) GetCollection() string {
+	if s == nil || s.Collection == nil {
+		return ""
+	}
+	return *s.Collection
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (s *StorageObject) GetCreateTime() string {
+	if s == nil || s.CreateTime == nil {
+		return ""
+	}
+	return *s.CreateTime
+}
+
+// GetKey returns Key, or "" if it is nil.
+func (s *StorageObject) GetKey() string {
+	if s == nil || s.Key == nil {
+		return ""
+	}
+	return *s.Key
+}
+
+// GetPermissionRead returns PermissionRead, or 0 if it is nil.
+func (s *StorageObject) GetPermissionRead() int {
+	if s == nil || s.PermissionRead == nil {
+		return 0
+	}
+	return *s.PermissionRead
+}
+
+// GetPermissionWrite returns PermissionWrite, or 0 if it is nil.
+func (s *StorageObject) GetPermissionWrite() int {
+	if s == nil || s.PermissionWrite == nil {
+		return 0
+	}
+	return *s.PermissionWrite
+}
+
+// GetUpdateTime returns UpdateTime, or "" if it is nil.
+func (s *StorageObject) GetUpdateTime() string {
+	if s == nil || s.UpdateTime == nil {
+		return ""
+	}
+	return *s.UpdateTime
+}
+
+// GetUserID returns UserID, or "" if it is nil.
+func (s *StorageObject) GetUserID() string {
+	if s == nil || s.UserID == nil {
+		return ""
+	}
+	return *s.UserID
+}
+
+// GetVersion returns Version, or "" if it is nil.
+func (s *StorageObject) GetVersion() string {
+	if s == nil || s.Version == nil {
+		return ""
+	}
+	return *s.Version
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (s *StorageObjectList) GetCursor() string {
+	if s == nil || s.Cursor == nil {
+		return ""
+	}
+	return *s.Cursor
+}
+
+// GetChannelID returns ChannelID, or "" if it is nil.
+func (c *ChannelMessage) GetChannelID() string {
+	if c == nil || c.ChannelID == nil {
+		return ""
+	}
+	return *c.ChannelID
+}
+
+// GetCode returns Code, or 0 if it is nil.
+func (c *ChannelMessage) GetCode() int {
+	if c == nil || c.Code == nil {
+		return 0
+	}
+	return *c.Code
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (c *ChannelMessage) GetCreateTime() string {
+	if c == nil || c.CreateTime == nil {
+		return ""
+	}
+	return *c.CreateTime
+}
+
+// GetGroupID returns GroupID, or "" if it is nil.
+func (c *ChannelMessage) GetGroupID() string {
+	if c == nil || c.GroupID == nil {
+		return ""
+	}
+	return *c.GroupID
+}
+
+// GetMessageID returns MessageID, or "" if it is nil.
+func (c *ChannelMessage) GetMessageID() string {
+	if c == nil || c.MessageID == nil {
+		return ""
+	}
+	return *c.MessageID
+}
+
+// GetPersistent returns Persistent, or false if it is nil.
+func (c *ChannelMessage) GetPersistent() bool {
+	if c == nil || c.Persistent == nil {
+		return false
+	}
+	return *c.Persistent
+}
+
+// GetRoomName returns RoomName, or "" if it is nil.
+func (c *ChannelMessage) GetRoomName() string {
+	if c == nil || c.RoomName == nil {
+		return ""
+	}
+	return *c.RoomName
+}
+
+// GetReferenceID returns ReferenceID, or "" if it is nil.
+func (c *ChannelMessage) GetReferenceID() string {
+	if c == nil || c.ReferenceID == nil {
+		return ""
+	}
+	return *c.ReferenceID
+}
+
+// GetSenderID returns SenderID, or "" if it is nil.
+func (c *ChannelMessage) GetSenderID() string {
+	if c == nil || c.SenderID == nil {
+		return ""
+	}
+	return *c.SenderID
+}
+
+// GetUpdateTime returns UpdateTime, or "" if it is nil.
+func (c *ChannelMessage) GetUpdateTime() string {
+	if c == nil || c.UpdateTime == nil {
+		return ""
+	}
+	return *c.UpdateTime
+}
+
+// GetUserIDOne returns UserIDOne, or "" if it is nil.
+func (c *ChannelMessage) GetUserIDOne() string {
+	if c == nil || c.UserIDOne == nil {
+		return ""
+	}
+	return *c.UserIDOne
+}
+
+// GetUserIDTwo returns UserIDTwo, or "" if it is nil.
+func (c *ChannelMessage) GetUserIDTwo() string {
+	if c == nil || c.UserIDTwo == nil {
+		return ""
+	}
+	return *c.UserIDTwo
+}
+
+// GetUsername returns Username, or "" if it is nil.
+func (c *ChannelMessage) GetUsername() string {
+	if c == nil || c.Username == nil {
+		return ""
+	}
+	return *c.Username
+}
+
+// GetCacheableCursor returns CacheableCursor, or "" if it is nil.
+func (c *ChannelMessageList) GetCacheableCursor() string {
+	if c == nil || c.CacheableCursor == nil {
+		return ""
+	}
+	return *c.CacheableCursor
+}
+
+// GetNextCursor returns NextCursor, or "" if it is nil.
+func (c *ChannelMessageList) GetNextCursor() string {
+	if c == nil || c.NextCursor == nil {
+		return ""
+	}
+	return *c.NextCursor
+}
+
+// GetPrevCursor returns PrevCursor, or "" if it is nil.
+func (c *ChannelMessageList) GetPrevCursor() string {
+	if c == nil || c.PrevCursor == nil {
+		return ""
+	}
+	return *c.PrevCursor
+}
+
+// GetAvatarURL returns AvatarURL, or "" if it is nil.
+func (u *User) GetAvatarURL() string {
+	if u == nil || u.AvatarURL == nil {
+		return ""
+	}
+	return *u.AvatarURL
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (u *User) GetCreateTime() string {
+	if u == nil || u.CreateTime == nil {
+		return ""
+	}
+	return *u.CreateTime
+}
+
+// GetDisplayName returns DisplayName, or "" if it is nil.
+func (u *User) GetDisplayName() string {
+	if u == nil || u.DisplayName == nil {
+		return ""
+	}
+	return *u.DisplayName
+}
+
+// GetEdgeCount returns EdgeCount, or 0 if it is nil.
+func (u *User) GetEdgeCount() int {
+	if u == nil || u.EdgeCount == nil {
+		return 0
+	}
+	return *u.EdgeCount
+}
+
+// GetFacebookID returns FacebookID, or "" if it is nil.
+func (u *User) GetFacebookID() string {
+	if u == nil || u.FacebookID == nil {
+		return ""
+	}
+	return *u.FacebookID
+}
+
+// GetFacebookInstantGameID returns FacebookInstantGameID, or "" if it is nil.
+func (u *User) GetFacebookInstantGameID() string {
+	if u == nil || u.FacebookInstantGameID == nil {
+		return ""
+	}
+	return *u.FacebookInstantGameID
+}
+
+// GetGameCenterID returns GameCenterID, or "" if it is nil.
+func (u *User) GetGameCenterID() string {
+	if u == nil || u.GameCenterID == nil {
+		return ""
+	}
+	return *u.GameCenterID
+}
+
+// GetGoogleID returns GoogleID, or "" if it is nil.
+func (u *User) GetGoogleID() string {
+	if u == nil || u.GoogleID == nil {
+		return ""
+	}
+	return *u.GoogleID
+}
+
+// GetID returns ID, or "" if it is nil.
+func (u *User) GetID() string {
+	if u == nil || u.ID == nil {
+		return ""
+	}
+	return *u.ID
+}
+
+// GetLangTag returns LangTag, or "" if it is nil.
+func (u *User) GetLangTag() string {
+	if u == nil || u.LangTag == nil {
+		return ""
+	}
+	return *u.LangTag
+}
+
+// GetLocation returns Location, or "" if it is nil.
+func (u *User) GetLocation() string {
+	if u == nil || u.Location == nil {
+		return ""
+	}
+	return *u.Location
+}
+
+// GetOnline returns Online, or false if it is nil.
+func (u *User) GetOnline() bool {
+	if u == nil || u.Online == nil {
+		return false
+	}
+	return *u.Online
+}
+
+// GetSteamID returns SteamID, or "" if it is nil.
+func (u *User) GetSteamID() string {
+	if u == nil || u.SteamID == nil {
+		return ""
+	}
+	return *u.SteamID
+}
+
+// GetTimezone returns Timezone, or "" if it is nil.
+func (u *User) GetTimezone() string {
+	if u == nil || u.Timezone == nil {
+		return ""
+	}
+	return *u.Timezone
+}
+
+// GetUpdateTime returns UpdateTime, or "" if it is nil.
+func (u *User) GetUpdateTime() string {
+	if u == nil || u.UpdateTime == nil {
+		return ""
+	}
+	return *u.UpdateTime
+}
+
+// GetUsername returns Username, or "" if it is nil.
+func (u *User) GetUsername() string {
+	if u == nil || u.Username == nil {
+		return ""
+	}
+	return *u.Username
+}
+
+// GetState returns State, or 0 if it is nil.
+func (f *Friend) GetState() int {
+	if f == nil || f.State == nil {
+		return 0
+	}
+	return *f.State
+}
+
+// GetUser returns User, or nil if the Friend is nil.
+func (f *Friend) GetUser() *User {
+	if f == nil {
+		return nil
+	}
+	return f.User
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (f *Friends) GetCursor() string {
+	if f == nil || f.Cursor == nil {
+		return ""
+	}
+	return *f.Cursor
+}
+
+// GetReferrer returns Referrer, or "" if it is nil.
+func (f *FriendOfFriend) GetReferrer() string {
+	if f == nil || f.Referrer == nil {
+		return ""
+	}
+	return *f.Referrer
+}
+
+// GetUser returns User, or nil if the FriendOfFriend is nil.
+func (f *FriendOfFriend) GetUser() *User {
+	if f == nil {
+		return nil
+	}
+	return f.User
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (f *FriendsOfFriends) GetCursor() string {
+	if f == nil || f.Cursor == nil {
+		return ""
+	}
+	return *f.Cursor
+}
+
+// GetUser returns User, or nil if the GroupUser is nil.
+func (g *GroupUser) GetUser() *User {
+	if g == nil {
+		return nil
+	}
+	return g.User
+}
+
+// GetState returns State, or 0 if it is nil.
+func (g *GroupUser) GetState() int {
+	if g == nil || g.State == nil {
+		return 0
+	}
+	return *g.State
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (g *GroupUserList) GetCursor() string {
+	if g == nil || g.Cursor == nil {
+		return ""
+	}
+	return *g.Cursor
+}
+
+// GetAvatarURL returns AvatarURL, or "" if it is nil.
+func (g *Group) GetAvatarURL() string {
+	if g == nil || g.AvatarURL == nil {
+		return ""
+	}
+	return *g.AvatarURL
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (g *Group) GetCreateTime() string {
+	if g == nil || g.CreateTime == nil {
+		return ""
+	}
+	return *g.CreateTime
+}
+
+// GetCreatorID returns CreatorID, or "" if it is nil.
+func (g *Group) GetCreatorID() string {
+	if g == nil || g.CreatorID == nil {
+		return ""
+	}
+	return *g.CreatorID
+}
+
+// GetDescription returns Description, or "" if it is nil.
+func (g *Group) GetDescription() string {
+	if g == nil || g.Description == nil {
+		return ""
+	}
+	return *g.Description
+}
+
+// GetEdgeCount returns EdgeCount, or 0 if it is nil.
+func (g *Group) GetEdgeCount() int {
+	if g == nil || g.EdgeCount == nil {
+		return 0
+	}
+	return *g.EdgeCount
+}
+
+// GetID returns ID, or "" if it is nil.
+func (g *Group) GetID() string {
+	if g == nil || g.ID == nil {
+		return ""
+	}
+	return *g.ID
+}
+
+// GetLangTag returns LangTag, or "" if it is nil.
+func (g *Group) GetLangTag() string {
+	if g == nil || g.LangTag == nil {
+		return ""
+	}
+	return *g.LangTag
+}
+
+// GetMaxCount returns MaxCount, or 0 if it is nil.
+func (g *Group) GetMaxCount() int {
+	if g == nil || g.MaxCount == nil {
+		return 0
+	}
+	return *g.MaxCount
+}
+
+// GetName returns Name, or "" if it is nil.
+func (g *Group) GetName() string {
+	if g == nil || g.Name == nil {
+		return ""
+	}
+	return *g.Name
+}
+
+// GetOpen returns Open, or false if it is nil.
+func (g *Group) GetOpen() bool {
+	if g == nil || g.Open == nil {
+		return false
+	}
+	return *g.Open
+}
+
+// GetUpdateTime returns UpdateTime, or "" if it is nil.
+func (g *Group) GetUpdateTime() string {
+	if g == nil || g.UpdateTime == nil {
+		return ""
+	}
+	return *g.UpdateTime
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (g *GroupList) GetCursor() string {
+	if g == nil || g.Cursor == nil {
+		return ""
+	}
+	return *g.Cursor
+}
+
+// GetGroup returns Group, or nil if the UserGroup is nil.
+func (u *UserGroup) GetGroup() *Group {
+	if u == nil {
+		return nil
+	}
+	return u.Group
+}
+
+// GetState returns State, or 0 if it is nil.
+func (u *UserGroup) GetState() int {
+	if u == nil || u.State == nil {
+		return 0
+	}
+	return *u.State
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (u *UserGroupList) GetCursor() string {
+	if u == nil || u.Cursor == nil {
+		return ""
+	}
+	return *u.Cursor
+}
+
+// GetCode returns Code, or 0 if it is nil.
+func (n *Notification) GetCode() int {
+	if n == nil || n.Code == nil {
+		return 0
+	}
+	return *n.Code
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (n *Notification) GetCreateTime() string {
+	if n == nil || n.CreateTime == nil {
+		return ""
+	}
+	return *n.CreateTime
+}
+
+// GetID returns ID, or "" if it is nil.
+func (n *Notification) GetID() string {
+	if n == nil || n.ID == nil {
+		return ""
+	}
+	return *n.ID
+}
+
+// GetPersistent returns Persistent, or false if it is nil.
+func (n *Notification) GetPersistent() bool {
+	if n == nil || n.Persistent == nil {
+		return false
+	}
+	return *n.Persistent
+}
+
+// GetSenderID returns SenderID, or "" if it is nil.
+func (n *Notification) GetSenderID() string {
+	if n == nil || n.SenderID == nil {
+		return ""
+	}
+	return *n.SenderID
+}
+
+// GetSubject returns Subject, or "" if it is nil.
+func (n *Notification) GetSubject() string {
+	if n == nil || n.Subject == nil {
+		return ""
+	}
+	return *n.Subject
+}
+
+// GetCacheableCursor returns CacheableCursor, or "" if it is nil.
+func (n *NotificationList) GetCacheableCursor() string {
+	if n == nil || n.CacheableCursor == nil {
+		return ""
+	}
+	return *n.CacheableCursor
+}
+
+// GetActive returns Active, or false if it is nil.
+func (v *ValidatedSubscription) GetActive() bool {
+	if v == nil || v.Active == nil {
+		return false
+	}
+	return *v.Active
+}
+
+// GetCreateTime returns CreateTime, or "" if it is nil.
+func (v *ValidatedSubscription) GetCreateTime() string {
+	if v == nil || v.CreateTime == nil {
+		return ""
+	}
+	return *v.CreateTime
+}
+
+// GetEnvironment returns Environment, or "" if it is nil.
+func (v *ValidatedSubscription) GetEnvironment() string {
+	if v == nil || v.Environment == nil {
+		return ""
+	}
+	return *v.Environment
+}
+
+// GetExpiryTime returns ExpiryTime, or "" if it is nil.
+func (v *ValidatedSubscription) GetExpiryTime() string {
+	if v == nil || v.ExpiryTime == nil {
+		return ""
+	}
+	return *v.ExpiryTime
+}
+
+// GetOriginalTransactionID returns OriginalTransactionID, or "" if it is nil.
+func (v *ValidatedSubscription) GetOriginalTransactionID() string {
+	if v == nil || v.OriginalTransactionID == nil {
+		return ""
+	}
+	return *v.OriginalTransactionID
+}
+
+// GetProductID returns ProductID, or "" if it is nil.
+func (v *ValidatedSubscription) GetProductID() string {
+	if v == nil || v.ProductID == nil {
+		return ""
+	}
+	return *v.ProductID
+}
+
+// GetProviderNotification returns ProviderNotification, or "" if it is nil.
+func (v *ValidatedSubscription) GetProviderNotification() string {
+	if v == nil || v.ProviderNotification == nil {
+		return ""
+	}
+	return *v.ProviderNotification
+}
+
+// GetProviderResponse returns ProviderResponse, or "" if it is nil.
+func (v *ValidatedSubscription) GetProviderResponse() string {
+	if v == nil || v.ProviderResponse == nil {
+		return ""
+	}
+	return *v.ProviderResponse
+}
+
+// GetPurchaseTime returns PurchaseTime, or "" if it is nil.
+func (v *ValidatedSubscription) GetPurchaseTime() string {
+	if v == nil || v.PurchaseTime == nil {
+		return ""
+	}
+	return *v.PurchaseTime
+}
+
+// GetRefundTime returns RefundTime, or "" if it is nil.
+func (v *ValidatedSubscription) GetRefundTime() string {
+	if v == nil || v.RefundTime == nil {
+		return ""
+	}
+	return *v.RefundTime
+}
+
+// GetStore returns Store, or "" if it is nil.
+func (v *ValidatedSubscription) GetStore() string {
+	if v == nil || v.Store == nil {
+		return ""
+	}
+	return *v.Store
+}
+
+// GetUpdateTime returns UpdateTime, or "" if it is nil.
+func (v *ValidatedSubscription) GetUpdateTime() string {
+	if v == nil || v.UpdateTime == nil {
+		return ""
+	}
+	return *v.UpdateTime
+}
+
+// GetUserID returns UserID, or "" if it is nil.
+func (v *ValidatedSubscription) GetUserID() string {
+	if v == nil || v.UserID == nil {
+		return ""
+	}
+	return *v.UserID
+}
+
+// GetCursor returns Cursor, or "" if it is nil.
+func (s *SubscriptionList) GetCursor() string {
+	if s == nil || s.Cursor == nil {
+		return ""
+	}
+	return *s.Cursor
+}
+
+// GetPrevCursor returns PrevCursor, or "" if it is nil.
+func (s *SubscriptionList) GetPrevCursor() string {
+	if s == nil || s.PrevCursor == nil {
+		return ""
+	}
+	return *s.PrevCursor
+}
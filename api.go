@@ -1,6 +1,7 @@
 package nakama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/base64"
@@ -8,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -270,6 +272,11 @@ type ApiMatch struct {
 	TickRate      *int    `json:"tick_rate,omitempty"`
 }
 
+// ParseLabel decodes a JSON match label into out.
+func (m ApiMatch) ParseLabel(out interface{}) error {
+	return parseMatchLabel(m.Label, out)
+}
+
 type ApiMatchList struct {
 	Matches []ApiMatch `json:"matches,omitempty"`
 }
@@ -479,6 +486,21 @@ type ApiValidatePurchaseResponse struct {
 	ValidatedPurchases *[]ApiValidatedPurchase `json:"validated_purchases,omitempty"`
 }
 
+// NewPurchases returns the validated purchases that had not been validated before. Grant rewards
+// only for these, so that a receipt validated again does not grant them twice.
+func (r *ApiValidatePurchaseResponse) NewPurchases() []ApiValidatedPurchase {
+	if r == nil || r.ValidatedPurchases == nil {
+		return nil
+	}
+	var purchases []ApiValidatedPurchase
+	for _, purchase := range *r.ValidatedPurchases {
+		if !purchase.IsSeenBefore() {
+			purchases = append(purchases, purchase)
+		}
+	}
+	return purchases
+}
+
 // ApiValidateSubscriptionAppleRequest Request to validate an Apple subscription.
 type ApiValidateSubscriptionAppleRequest struct {
 	Persist *bool   `json:"persist,omitempty"` // Persist the subscription.
@@ -511,6 +533,12 @@ type ApiValidatedPurchase struct {
 	UserID           *string              `json:"user_id,omitempty"`        // Purchase User ID.
 }
 
+// IsSeenBefore reports whether the purchase had already been validated, in which case any rewards
+// for it have already been granted.
+func (p ApiValidatedPurchase) IsSeenBefore() bool {
+	return p.SeenBefore != nil && *p.SeenBefore
+}
+
 // ApiValidatedSubscription Validated Subscription stored by the backend system.
 type ApiValidatedSubscription struct {
 	Active                *bool                `json:"active,omitempty"`                  // Whether the subscription is currently active or not.
@@ -543,10 +571,79 @@ type ApiWriteStorageObjectsRequest struct {
 	Objects *[]ApiWriteStorageObject `json:"objects,omitempty"` // The objects to store on the server.
 }
 
+// DefaultMaxResponseBytes is the default cap on the size of a response body.
+const DefaultMaxResponseBytes = 32 << 20
+
 type NakamaApi struct {
 	ServerKey string
 	BasePath  string
 	TimeoutMs int
+	Logger    Logger          // The logger used for request diagnostics. Defaults to a no-op logger.
+	Context   context.Context // The parent context of every request. Defaults to context.Background().
+
+	// Debug logs every request and response, including bodies, through Logger at debug level.
+	// Authorization headers and password and token fields are redacted. Defaults to off.
+	Debug bool
+
+	// HTTPClient, if set, is used for every request, for example to customise TLS. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// MaxResponseBytes caps the size of a response body. Reading past it fails with ErrResponseTooLarge.
+	// Defaults to DefaultMaxResponseBytes when zero.
+	MaxResponseBytes int64
+
+	// DisallowUnknownFields makes decoding a response fail when it has a field the client's types do
+	// not know, to detect drift between the client and a newer server. Enable it in integration tests
+	// and CI against the server version you deploy, not in production, where new server fields should
+	// be ignored. Defaults to off.
+	DisallowUnknownFields bool
+
+	// Codec, if set, encodes request bodies and decodes responses instead of encoding/json.
+	// DisallowUnknownFields only applies to the default codec.
+	Codec Codec
+
+	// Breaker, if set, makes requests fail fast with ErrUnavailable while the server is unreachable.
+	// See CircuitBreaker. Defaults to off.
+	Breaker *CircuitBreaker
+
+	// callContext, if set, also bounds every request. It is set on the per-call copies made by
+	// Client.WithContext.
+	callContext context.Context
+
+	// OnRequestStart, if set, is called before every HTTP request with its method and URL path.
+	// Hooks run inline on the request goroutine and should return quickly.
+	OnRequestStart func(method string, path string)
+	// OnRequestEnd, if set, is called after every HTTP request with its method, URL path, response
+	// status (0 if no response was received), duration and transport error.
+	// Hooks run inline on the request goroutine and should return quickly.
+	OnRequestEnd func(method string, path string, status int, dur time.Duration, err error)
+
+	// OnUnauthorized, if set, is called with the bearer token of a request rejected with 401. The
+	// request is retried once with the token it returns; an error or the same token gives up. Client
+	// sets it to refresh the session the token belongs to.
+	OnUnauthorized func(token string) (string, error)
+
+	// AdminToken, if set, is sent as the bearer token of requests made without one, for
+	// server-to-server tools that call admin RPCs with a console or service token rather than a user
+	// session. A request's own bearer token or basic auth always takes precedence. The token grants
+	// whatever the server allows it on every request that has no session, including requests made by
+	// mistake without one, so only set it in trusted processes and never ship it in a game client.
+	AdminToken string
+
+	// MaxRetries is how many times a request that failed with a transport error or a 502, 503 or 504
+	// response is retried, after Backoff delays. Writes are sent with an Idempotency-Key header that is
+	// generated when not set and kept across retries, so that the server can process a write retried
+	// after a lost response only once. Stock Nakama ignores the header: retry non-idempotent calls,
+	// such as score submissions and purchase validations, only against a server or proxy that honours
+	// it. Defaults to 0, no retries.
+	MaxRetries int
+	// Backoff is the delays between retries. NewClient points it at the Client's Backoff, so that the
+	// client has a single backoff setting. Nil uses DefaultBackoffConfig.
+	Backoff *BackoffConfig
+
+	// idempotencyKey, if set, is sent as the Idempotency-Key of every write. It is set on the per-call
+	// copies made by Client.WithIdempotencyKey.
+	idempotencyKey string
 }
 
 // Healthcheck is a healthcheck function that load balancers can use to check the service.
@@ -572,17 +669,17 @@ func (api *NakamaApi) Healthcheck(bearerToken string, options map[string]string)
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -593,7 +690,7 @@ func (api *NakamaApi) Healthcheck(bearerToken string, options map[string]string)
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -604,18 +701,71 @@ func (api *NakamaApi) Healthcheck(bearerToken string, options map[string]string)
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
+		}
+	}
+}
+
+// ServerTime returns the server's current time as reported by the Date header of a healthcheck response.
+func (api *NakamaApi) ServerTime(bearerToken string, options map[string]string) (time.Time, error) {
+	// Construct the full URL
+	fullUrl := api.buildFullUrl(api.BasePath, "/healthcheck", url.Values{})
+
+	// Prepare the HTTP request
+	req, err := http.NewRequest("GET", fullUrl, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+	// Apply additional custom headers or options if needed
+	for key, value := range options {
+		req.Header.Set(key, value)
+	}
+
+	// Create a context with a timeout
+	ctx, cancel := api.requestContext()
+	defer cancel()
+
+	// Make the HTTP request
+	client := api.httpClient()
+	responseChan := make(chan *http.Response, 1)
+	errorChan := make(chan error, 1)
+
+	// Run the HTTP request in a goroutine
+	go func() {
+		resp, err := api.do(client, req.WithContext(ctx))
+		if err != nil {
+			errorChan <- err
+			return
+		}
+		responseChan <- resp
+	}()
+
+	// Wait for the response or the timeout
+	select {
+	case <-ctx.Done():
+		return time.Time{}, api.contextError()
+	case err := <-errorChan:
+		return time.Time{}, err
+	case resp := <-responseChan:
+		defer resp.Body.Close()
+
+		// Handle HTTP response
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return time.Time{}, api.responseError(resp)
+		}
+		date := resp.Header.Get("Date")
+		if date == "" {
+			return time.Time{}, errors.New("response has no Date header")
 		}
+		return http.ParseTime(date)
 	}
 }
 
@@ -642,17 +792,17 @@ func (api *NakamaApi) DeleteAccount(bearerToken string, options map[string]strin
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -663,7 +813,7 @@ func (api *NakamaApi) DeleteAccount(bearerToken string, options map[string]strin
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -674,17 +824,12 @@ func (api *NakamaApi) DeleteAccount(bearerToken string, options map[string]strin
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -712,17 +857,17 @@ func (api *NakamaApi) GetAccount(bearerToken string, options map[string]string)
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -733,7 +878,7 @@ func (api *NakamaApi) GetAccount(bearerToken string, options map[string]string)
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -744,17 +889,12 @@ func (api *NakamaApi) GetAccount(bearerToken string, options map[string]string)
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiAccount
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -771,7 +911,7 @@ func (api *NakamaApi) UpdateAccount(bearerToken string, body ApiUpdateAccountReq
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -795,17 +935,17 @@ func (api *NakamaApi) UpdateAccount(bearerToken string, body ApiUpdateAccountReq
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -816,7 +956,7 @@ func (api *NakamaApi) UpdateAccount(bearerToken string, body ApiUpdateAccountReq
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -827,17 +967,12 @@ func (api *NakamaApi) UpdateAccount(bearerToken string, body ApiUpdateAccountReq
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -855,7 +990,7 @@ func (api *NakamaApi) AuthenticateApple(basicAuthUsername string, basicAuthPassw
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -882,17 +1017,17 @@ func (api *NakamaApi) AuthenticateApple(basicAuthUsername string, basicAuthPassw
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -903,7 +1038,7 @@ func (api *NakamaApi) AuthenticateApple(basicAuthUsername string, basicAuthPassw
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -914,17 +1049,12 @@ func (api *NakamaApi) AuthenticateApple(basicAuthUsername string, basicAuthPassw
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -949,7 +1079,7 @@ func (api *NakamaApi) AuthenticateCustom(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -976,17 +1106,17 @@ func (api *NakamaApi) AuthenticateCustom(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -997,7 +1127,7 @@ func (api *NakamaApi) AuthenticateCustom(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1008,17 +1138,12 @@ func (api *NakamaApi) AuthenticateCustom(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1043,7 +1168,7 @@ func (api *NakamaApi) AuthenticateDevice(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1070,17 +1195,17 @@ func (api *NakamaApi) AuthenticateDevice(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1091,7 +1216,7 @@ func (api *NakamaApi) AuthenticateDevice(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1102,17 +1227,12 @@ func (api *NakamaApi) AuthenticateDevice(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1137,7 +1257,7 @@ func (api *NakamaApi) AuthenticateEmail(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1164,17 +1284,17 @@ func (api *NakamaApi) AuthenticateEmail(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1185,7 +1305,7 @@ func (api *NakamaApi) AuthenticateEmail(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1196,17 +1316,12 @@ func (api *NakamaApi) AuthenticateEmail(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1235,7 +1350,7 @@ func (api *NakamaApi) AuthenticateFacebook(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1262,17 +1377,17 @@ func (api *NakamaApi) AuthenticateFacebook(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1283,7 +1398,7 @@ func (api *NakamaApi) AuthenticateFacebook(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1294,17 +1409,12 @@ func (api *NakamaApi) AuthenticateFacebook(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1329,7 +1439,7 @@ func (api *NakamaApi) AuthenticateFacebookInstantGame(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1356,17 +1466,17 @@ func (api *NakamaApi) AuthenticateFacebookInstantGame(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1377,7 +1487,7 @@ func (api *NakamaApi) AuthenticateFacebookInstantGame(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1388,17 +1498,12 @@ func (api *NakamaApi) AuthenticateFacebookInstantGame(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1423,7 +1528,7 @@ func (api *NakamaApi) AuthenticateGameCenter(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1450,17 +1555,17 @@ func (api *NakamaApi) AuthenticateGameCenter(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1471,7 +1576,7 @@ func (api *NakamaApi) AuthenticateGameCenter(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1482,17 +1587,12 @@ func (api *NakamaApi) AuthenticateGameCenter(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1517,7 +1617,7 @@ func (api *NakamaApi) AuthenticateGoogle(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1544,17 +1644,17 @@ func (api *NakamaApi) AuthenticateGoogle(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1565,7 +1665,7 @@ func (api *NakamaApi) AuthenticateGoogle(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1576,17 +1676,12 @@ func (api *NakamaApi) AuthenticateGoogle(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1615,7 +1710,7 @@ func (api *NakamaApi) AuthenticateSteam(
 	}
 
 	// Convert the account to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -1642,17 +1737,17 @@ func (api *NakamaApi) AuthenticateSteam(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1663,7 +1758,7 @@ func (api *NakamaApi) AuthenticateSteam(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1674,17 +1769,12 @@ func (api *NakamaApi) AuthenticateSteam(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1700,7 +1790,7 @@ func (api *NakamaApi) LinkApple(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -1726,17 +1816,17 @@ func (api *NakamaApi) LinkApple(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1747,7 +1837,7 @@ func (api *NakamaApi) LinkApple(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1758,17 +1848,12 @@ func (api *NakamaApi) LinkApple(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1784,7 +1869,7 @@ func (api *NakamaApi) LinkCustom(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -1810,17 +1895,17 @@ func (api *NakamaApi) LinkCustom(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1831,7 +1916,7 @@ func (api *NakamaApi) LinkCustom(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1842,17 +1927,12 @@ func (api *NakamaApi) LinkCustom(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1868,7 +1948,7 @@ func (api *NakamaApi) LinkDevice(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -1894,17 +1974,17 @@ func (api *NakamaApi) LinkDevice(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1915,7 +1995,7 @@ func (api *NakamaApi) LinkDevice(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -1926,17 +2006,12 @@ func (api *NakamaApi) LinkDevice(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -1952,7 +2027,7 @@ func (api *NakamaApi) LinkEmail(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -1978,17 +2053,17 @@ func (api *NakamaApi) LinkEmail(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -1999,7 +2074,7 @@ func (api *NakamaApi) LinkEmail(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2010,17 +2085,12 @@ func (api *NakamaApi) LinkEmail(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2040,7 +2110,7 @@ func (api *NakamaApi) LinkFacebook(
 	}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -2066,17 +2136,17 @@ func (api *NakamaApi) LinkFacebook(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2087,7 +2157,7 @@ func (api *NakamaApi) LinkFacebook(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2098,17 +2168,12 @@ func (api *NakamaApi) LinkFacebook(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2124,7 +2189,7 @@ func (api *NakamaApi) LinkFacebookInstantGame(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2150,17 +2215,17 @@ func (api *NakamaApi) LinkFacebookInstantGame(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2171,7 +2236,7 @@ func (api *NakamaApi) LinkFacebookInstantGame(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2182,17 +2247,12 @@ func (api *NakamaApi) LinkFacebookInstantGame(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2208,7 +2268,7 @@ func (api *NakamaApi) LinkGameCenter(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2234,17 +2294,17 @@ func (api *NakamaApi) LinkGameCenter(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2255,7 +2315,7 @@ func (api *NakamaApi) LinkGameCenter(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2266,17 +2326,12 @@ func (api *NakamaApi) LinkGameCenter(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2292,7 +2347,7 @@ func (api *NakamaApi) LinkGoogle(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2318,17 +2373,17 @@ func (api *NakamaApi) LinkGoogle(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2339,7 +2394,7 @@ func (api *NakamaApi) LinkGoogle(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2350,17 +2405,12 @@ func (api *NakamaApi) LinkGoogle(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2376,7 +2426,7 @@ func (api *NakamaApi) LinkSteam(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2402,17 +2452,17 @@ func (api *NakamaApi) LinkSteam(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2423,7 +2473,7 @@ func (api *NakamaApi) LinkSteam(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2434,17 +2484,12 @@ func (api *NakamaApi) LinkSteam(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2461,7 +2506,7 @@ func (api *NakamaApi) SessionRefresh(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2477,7 +2522,7 @@ func (api *NakamaApi) SessionRefresh(
 	req.Header.Set("Content-Type", "application/json")
 
 	// Set Basic Auth header
-	if basicAuthUsername != "" && basicAuthPassword != "" {
+	if basicAuthUsername != "" {
 		auth := basicAuthUsername + ":" + basicAuthPassword
 		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
 	}
@@ -2488,17 +2533,17 @@ func (api *NakamaApi) SessionRefresh(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2509,7 +2554,7 @@ func (api *NakamaApi) SessionRefresh(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2520,17 +2565,12 @@ func (api *NakamaApi) SessionRefresh(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiSession
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2546,7 +2586,7 @@ func (api *NakamaApi) UnlinkApple(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2572,17 +2612,17 @@ func (api *NakamaApi) UnlinkApple(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2593,7 +2633,7 @@ func (api *NakamaApi) UnlinkApple(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2604,17 +2644,12 @@ func (api *NakamaApi) UnlinkApple(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2630,7 +2665,7 @@ func (api *NakamaApi) UnlinkCustom(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2656,17 +2691,17 @@ func (api *NakamaApi) UnlinkCustom(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2677,7 +2712,7 @@ func (api *NakamaApi) UnlinkCustom(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2688,17 +2723,12 @@ func (api *NakamaApi) UnlinkCustom(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2714,7 +2744,7 @@ func (api *NakamaApi) UnlinkDevice(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2740,17 +2770,17 @@ func (api *NakamaApi) UnlinkDevice(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2761,7 +2791,7 @@ func (api *NakamaApi) UnlinkDevice(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2772,17 +2802,12 @@ func (api *NakamaApi) UnlinkDevice(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2798,7 +2823,7 @@ func (api *NakamaApi) UnlinkEmail(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2824,17 +2849,17 @@ func (api *NakamaApi) UnlinkEmail(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2845,7 +2870,7 @@ func (api *NakamaApi) UnlinkEmail(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2856,17 +2881,12 @@ func (api *NakamaApi) UnlinkEmail(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2882,7 +2902,7 @@ func (api *NakamaApi) UnlinkFacebook(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2908,17 +2928,17 @@ func (api *NakamaApi) UnlinkFacebook(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -2929,7 +2949,7 @@ func (api *NakamaApi) UnlinkFacebook(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -2940,17 +2960,12 @@ func (api *NakamaApi) UnlinkFacebook(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -2966,7 +2981,7 @@ func (api *NakamaApi) UnlinkFacebookInstantGame(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -2992,17 +3007,17 @@ func (api *NakamaApi) UnlinkFacebookInstantGame(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3013,7 +3028,7 @@ func (api *NakamaApi) UnlinkFacebookInstantGame(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3024,17 +3039,12 @@ func (api *NakamaApi) UnlinkFacebookInstantGame(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3050,7 +3060,7 @@ func (api *NakamaApi) UnlinkGameCenter(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -3076,17 +3086,17 @@ func (api *NakamaApi) UnlinkGameCenter(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3097,7 +3107,7 @@ func (api *NakamaApi) UnlinkGameCenter(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3108,17 +3118,12 @@ func (api *NakamaApi) UnlinkGameCenter(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3134,7 +3139,7 @@ func (api *NakamaApi) UnlinkGoogle(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -3160,17 +3165,17 @@ func (api *NakamaApi) UnlinkGoogle(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3181,7 +3186,7 @@ func (api *NakamaApi) UnlinkGoogle(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3192,17 +3197,12 @@ func (api *NakamaApi) UnlinkGoogle(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3218,7 +3218,7 @@ func (api *NakamaApi) UnlinkSteam(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -3244,17 +3244,17 @@ func (api *NakamaApi) UnlinkSteam(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3265,7 +3265,7 @@ func (api *NakamaApi) UnlinkSteam(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3276,17 +3276,12 @@ func (api *NakamaApi) UnlinkSteam(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3339,17 +3334,17 @@ func (api *NakamaApi) ListChannelMessages(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3360,7 +3355,7 @@ func (api *NakamaApi) ListChannelMessages(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiChannelMessageList{}, errors.New("request timed out")
+		return ApiChannelMessageList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiChannelMessageList{}, err
 	case resp := <-responseChan:
@@ -3371,17 +3366,12 @@ func (api *NakamaApi) ListChannelMessages(
 			return ApiChannelMessageList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiChannelMessageList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiChannelMessageList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiChannelMessageList{}, err
 			}
 			return result, nil
 		} else {
-			return ApiChannelMessageList{}, errors.New(resp.Status)
+			return ApiChannelMessageList{}, api.responseError(resp)
 		}
 	}
 }
@@ -3397,7 +3387,7 @@ func (api *NakamaApi) Event(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -3423,17 +3413,17 @@ func (api *NakamaApi) Event(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3444,7 +3434,7 @@ func (api *NakamaApi) Event(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3455,17 +3445,12 @@ func (api *NakamaApi) Event(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3508,17 +3493,17 @@ func (api *NakamaApi) DeleteFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3529,7 +3514,7 @@ func (api *NakamaApi) DeleteFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3540,17 +3525,12 @@ func (api *NakamaApi) DeleteFriends(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3598,17 +3578,17 @@ func (api *NakamaApi) ListFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3619,7 +3599,7 @@ func (api *NakamaApi) ListFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiFriendList{}, errors.New("request timed out")
+		return ApiFriendList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiFriendList{}, err
 	case resp := <-responseChan:
@@ -3630,17 +3610,12 @@ func (api *NakamaApi) ListFriends(
 			return ApiFriendList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiFriendList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiFriendList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiFriendList{}, err
 			}
 			return result, nil
 		} else {
-			return ApiFriendList{}, errors.New(resp.Status)
+			return ApiFriendList{}, api.responseError(resp)
 		}
 	}
 }
@@ -3683,17 +3658,17 @@ func (api *NakamaApi) AddFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3704,7 +3679,7 @@ func (api *NakamaApi) AddFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3715,17 +3690,12 @@ func (api *NakamaApi) AddFriends(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3768,17 +3738,17 @@ func (api *NakamaApi) BlockFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3789,7 +3759,7 @@ func (api *NakamaApi) BlockFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3800,17 +3770,12 @@ func (api *NakamaApi) BlockFriends(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3827,7 +3792,7 @@ func (api *NakamaApi) ImportFacebookFriends(
 	queryParams.Set("reset", strconv.FormatBool(reset))
 
 	// Serialize the account object to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -3853,17 +3818,17 @@ func (api *NakamaApi) ImportFacebookFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3874,7 +3839,7 @@ func (api *NakamaApi) ImportFacebookFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3885,17 +3850,12 @@ func (api *NakamaApi) ImportFacebookFriends(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3938,17 +3898,17 @@ func (api *NakamaApi) ListFriendsOfFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -3959,7 +3919,7 @@ func (api *NakamaApi) ListFriendsOfFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -3970,17 +3930,12 @@ func (api *NakamaApi) ListFriendsOfFriends(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiFriendsOfFriendsList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -3997,7 +3952,7 @@ func (api *NakamaApi) ImportSteamFriends(
 	queryParams.Set("reset", strconv.FormatBool(reset))
 
 	// Serialize the account object to JSON
-	bodyJson, err := json.Marshal(account)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(account)
 	if err != nil {
 		return nil, err
 	}
@@ -4023,17 +3978,17 @@ func (api *NakamaApi) ImportSteamFriends(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4044,7 +3999,7 @@ func (api *NakamaApi) ImportSteamFriends(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4055,17 +4010,12 @@ func (api *NakamaApi) ImportSteamFriends(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4124,17 +4074,17 @@ func (api *NakamaApi) ListGroups(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4145,7 +4095,7 @@ func (api *NakamaApi) ListGroups(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4156,17 +4106,12 @@ func (api *NakamaApi) ListGroups(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiGroupList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4182,7 +4127,7 @@ func (api *NakamaApi) CreateGroup(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return ApiGroup{}, err
 	}
@@ -4208,17 +4153,17 @@ func (api *NakamaApi) CreateGroup(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4229,7 +4174,7 @@ func (api *NakamaApi) CreateGroup(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiGroup{}, errors.New("request timed out")
+		return ApiGroup{}, api.contextError()
 	case err := <-errorChan:
 		return ApiGroup{}, err
 	case resp := <-responseChan:
@@ -4240,17 +4185,12 @@ func (api *NakamaApi) CreateGroup(
 			return ApiGroup{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiGroup
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiGroup{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiGroup{}, err
 			}
 			return result, nil
 		} else {
-			return ApiGroup{}, errors.New(resp.Status)
+			return ApiGroup{}, api.responseError(resp)
 		}
 	}
 }
@@ -4290,17 +4230,17 @@ func (api *NakamaApi) DeleteGroup(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4311,7 +4251,7 @@ func (api *NakamaApi) DeleteGroup(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4322,17 +4262,12 @@ func (api *NakamaApi) DeleteGroup(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4357,7 +4292,7 @@ func (api *NakamaApi) UpdateGroup(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -4383,17 +4318,17 @@ func (api *NakamaApi) UpdateGroup(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4404,7 +4339,7 @@ func (api *NakamaApi) UpdateGroup(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4415,17 +4350,12 @@ func (api *NakamaApi) UpdateGroup(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4471,17 +4401,17 @@ func (api *NakamaApi) AddGroupUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4492,7 +4422,7 @@ func (api *NakamaApi) AddGroupUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4503,17 +4433,12 @@ func (api *NakamaApi) AddGroupUsers(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4558,17 +4483,17 @@ func (api *NakamaApi) BanGroupUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4579,7 +4504,7 @@ func (api *NakamaApi) BanGroupUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4590,17 +4515,12 @@ func (api *NakamaApi) BanGroupUsers(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4645,17 +4565,17 @@ func (api *NakamaApi) DemoteGroupUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4666,7 +4586,7 @@ func (api *NakamaApi) DemoteGroupUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4677,17 +4597,12 @@ func (api *NakamaApi) DemoteGroupUsers(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4727,17 +4642,17 @@ func (api *NakamaApi) JoinGroup(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4748,7 +4663,7 @@ func (api *NakamaApi) JoinGroup(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4759,17 +4674,12 @@ func (api *NakamaApi) JoinGroup(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4815,17 +4725,17 @@ func (api *NakamaApi) KickGroupUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4836,7 +4746,7 @@ func (api *NakamaApi) KickGroupUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4847,17 +4757,12 @@ func (api *NakamaApi) KickGroupUsers(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4898,17 +4803,17 @@ func (api *NakamaApi) LeaveGroup(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -4919,7 +4824,7 @@ func (api *NakamaApi) LeaveGroup(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -4930,17 +4835,12 @@ func (api *NakamaApi) LeaveGroup(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -4985,17 +4885,17 @@ func (api *NakamaApi) PromoteGroupUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5006,7 +4906,7 @@ func (api *NakamaApi) PromoteGroupUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5017,17 +4917,12 @@ func (api *NakamaApi) PromoteGroupUsers(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5080,17 +4975,17 @@ func (api *NakamaApi) ListGroupUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5101,7 +4996,7 @@ func (api *NakamaApi) ListGroupUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5112,17 +5007,12 @@ func (api *NakamaApi) ListGroupUsers(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiGroupUserList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5137,7 +5027,7 @@ func (api *NakamaApi) ValidatePurchaseApple(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -5163,17 +5053,17 @@ func (api *NakamaApi) ValidatePurchaseApple(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5184,7 +5074,7 @@ func (api *NakamaApi) ValidatePurchaseApple(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5195,17 +5085,12 @@ func (api *NakamaApi) ValidatePurchaseApple(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiValidatePurchaseResponse
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5226,7 +5111,7 @@ func (api *NakamaApi) ValidatePurchaseFacebookInstant(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -5252,17 +5137,17 @@ func (api *NakamaApi) ValidatePurchaseFacebookInstant(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5273,7 +5158,7 @@ func (api *NakamaApi) ValidatePurchaseFacebookInstant(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5284,17 +5169,12 @@ func (api *NakamaApi) ValidatePurchaseFacebookInstant(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiValidatePurchaseResponse
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5315,7 +5195,7 @@ func (api *NakamaApi) ValidatePurchaseGoogle(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -5341,17 +5221,17 @@ func (api *NakamaApi) ValidatePurchaseGoogle(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5362,7 +5242,7 @@ func (api *NakamaApi) ValidatePurchaseGoogle(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5373,17 +5253,12 @@ func (api *NakamaApi) ValidatePurchaseGoogle(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiValidatePurchaseResponse
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5404,7 +5279,7 @@ func (api *NakamaApi) ValidatePurchaseHuawei(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -5430,17 +5305,17 @@ func (api *NakamaApi) ValidatePurchaseHuawei(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5451,7 +5326,7 @@ func (api *NakamaApi) ValidatePurchaseHuawei(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5462,17 +5337,12 @@ func (api *NakamaApi) ValidatePurchaseHuawei(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiValidatePurchaseResponse
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5494,7 +5364,7 @@ func (api *NakamaApi) ListSubscriptions(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return ApiSubscriptionList{}, err
 	}
@@ -5520,17 +5390,17 @@ func (api *NakamaApi) ListSubscriptions(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5541,7 +5411,7 @@ func (api *NakamaApi) ListSubscriptions(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiSubscriptionList{}, errors.New("request timed out")
+		return ApiSubscriptionList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiSubscriptionList{}, err
 	case resp := <-responseChan:
@@ -5552,17 +5422,12 @@ func (api *NakamaApi) ListSubscriptions(
 			return ApiSubscriptionList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiSubscriptionList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiSubscriptionList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiSubscriptionList{}, err
 			}
 			return result, nil
 		} else {
-			return ApiSubscriptionList{}, errors.New(resp.Status)
+			return ApiSubscriptionList{}, api.responseError(resp)
 		}
 	}
 }
@@ -5584,7 +5449,7 @@ func (api *NakamaApi) ValidateSubscriptionApple(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -5610,17 +5475,17 @@ func (api *NakamaApi) ValidateSubscriptionApple(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5631,7 +5496,7 @@ func (api *NakamaApi) ValidateSubscriptionApple(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5642,17 +5507,12 @@ func (api *NakamaApi) ValidateSubscriptionApple(
 			return &ApiValidateSubscriptionResponse{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiValidateSubscriptionResponse
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return &result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5674,7 +5534,7 @@ func (api *NakamaApi) ValidateSubscriptionGoogle(
 	queryParams := url.Values{}
 
 	// Serialize the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -5700,17 +5560,17 @@ func (api *NakamaApi) ValidateSubscriptionGoogle(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5721,7 +5581,7 @@ func (api *NakamaApi) ValidateSubscriptionGoogle(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -5732,17 +5592,12 @@ func (api *NakamaApi) ValidateSubscriptionGoogle(
 			return &ApiValidateSubscriptionResponse{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result *ApiValidateSubscriptionResponse
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -5783,17 +5638,17 @@ func (api *NakamaApi) GetSubscription(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5804,7 +5659,7 @@ func (api *NakamaApi) GetSubscription(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiValidatedSubscription{}, errors.New("request timed out")
+		return ApiValidatedSubscription{}, api.contextError()
 	case err := <-errorChan:
 		return ApiValidatedSubscription{}, err
 	case resp := <-responseChan:
@@ -5815,17 +5670,12 @@ func (api *NakamaApi) GetSubscription(
 			return ApiValidatedSubscription{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiValidatedSubscription
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiValidatedSubscription{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiValidatedSubscription{}, err
 			}
 			return result, nil
 		} else {
-			return ApiValidatedSubscription{}, errors.New(resp.Status)
+			return ApiValidatedSubscription{}, api.responseError(resp)
 		}
 	}
 }
@@ -5866,17 +5716,17 @@ func (api *NakamaApi) DeleteLeaderboardRecord(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5887,7 +5737,7 @@ func (api *NakamaApi) DeleteLeaderboardRecord(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return errors.New("request timed out")
+		return api.contextError()
 	case err := <-errorChan:
 		return err
 	case resp := <-responseChan:
@@ -5899,7 +5749,7 @@ func (api *NakamaApi) DeleteLeaderboardRecord(
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			return nil
 		} else {
-			return errors.New(resp.Status)
+			return api.responseError(resp)
 		}
 	}
 }
@@ -5960,17 +5810,17 @@ func (api *NakamaApi) ListLeaderboardRecords(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -5981,7 +5831,7 @@ func (api *NakamaApi) ListLeaderboardRecords(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiLeaderboardRecordList{}, errors.New("request timed out")
+		return ApiLeaderboardRecordList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiLeaderboardRecordList{}, err
 	case resp := <-responseChan:
@@ -5992,17 +5842,12 @@ func (api *NakamaApi) ListLeaderboardRecords(
 			return ApiLeaderboardRecordList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiLeaderboardRecordList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiLeaderboardRecordList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiLeaderboardRecordList{}, err
 			}
 			return result, nil
 		} else {
-			return ApiLeaderboardRecordList{}, errors.New(resp.Status)
+			return ApiLeaderboardRecordList{}, api.responseError(resp)
 		}
 	}
 }
@@ -6028,7 +5873,7 @@ func (api *NakamaApi) WriteLeaderboardRecord(
 	queryParams := url.Values{}
 
 	// Convert the record to JSON
-	bodyJson, err := json.Marshal(record)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(record)
 	if err != nil {
 		return ApiLeaderboardRecord{}, err
 	}
@@ -6056,17 +5901,17 @@ func (api *NakamaApi) WriteLeaderboardRecord(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6077,7 +5922,7 @@ func (api *NakamaApi) WriteLeaderboardRecord(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiLeaderboardRecord{}, errors.New("request timed out")
+		return ApiLeaderboardRecord{}, api.contextError()
 	case err := <-errorChan:
 		return ApiLeaderboardRecord{}, err
 	case resp := <-responseChan:
@@ -6088,18 +5933,12 @@ func (api *NakamaApi) WriteLeaderboardRecord(
 			return ApiLeaderboardRecord{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiLeaderboardRecord
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiLeaderboardRecord{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiLeaderboardRecord{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiLeaderboardRecord{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiLeaderboardRecord{}, api.responseError(resp)
 		}
 	}
 }
@@ -6162,17 +6001,17 @@ func (api *NakamaApi) ListLeaderboardRecordsAroundOwner(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6183,7 +6022,7 @@ func (api *NakamaApi) ListLeaderboardRecordsAroundOwner(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiLeaderboardRecordList{}, errors.New("request timed out")
+		return ApiLeaderboardRecordList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiLeaderboardRecordList{}, err
 	case resp := <-responseChan:
@@ -6194,18 +6033,12 @@ func (api *NakamaApi) ListLeaderboardRecordsAroundOwner(
 			return ApiLeaderboardRecordList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiLeaderboardRecordList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiLeaderboardRecordList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiLeaderboardRecordList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiLeaderboardRecordList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiLeaderboardRecordList{}, api.responseError(resp)
 		}
 	}
 }
@@ -6265,17 +6098,17 @@ func (api *NakamaApi) ListMatches(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6286,7 +6119,7 @@ func (api *NakamaApi) ListMatches(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiMatchList{}, errors.New("request timed out")
+		return ApiMatchList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiMatchList{}, err
 	case resp := <-responseChan:
@@ -6297,18 +6130,12 @@ func (api *NakamaApi) ListMatches(
 			return ApiMatchList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiMatchList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiMatchList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiMatchList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiMatchList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiMatchList{}, api.responseError(resp)
 		}
 	}
 }
@@ -6351,17 +6178,17 @@ func (api *NakamaApi) DeleteNotifications(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6372,7 +6199,7 @@ func (api *NakamaApi) DeleteNotifications(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -6383,18 +6210,12 @@ func (api *NakamaApi) DeleteNotifications(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result any
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -6438,17 +6259,17 @@ func (api *NakamaApi) ListNotifications(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6459,7 +6280,7 @@ func (api *NakamaApi) ListNotifications(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiNotificationList{}, errors.New("request timed out")
+		return ApiNotificationList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiNotificationList{}, err
 	case resp := <-responseChan:
@@ -6470,18 +6291,12 @@ func (api *NakamaApi) ListNotifications(
 			return ApiNotificationList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiNotificationList
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiNotificationList{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiNotificationList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiNotificationList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiNotificationList{}, api.responseError(resp)
 		}
 	}
 }
@@ -6534,17 +6349,17 @@ func (api *NakamaApi) RpcFunc2(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6555,7 +6370,7 @@ func (api *NakamaApi) RpcFunc2(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiRpc{}, errors.New("request timed out")
+		return ApiRpc{}, api.contextError()
 	case err := <-errorChan:
 		return ApiRpc{}, err
 	case resp := <-responseChan:
@@ -6566,18 +6381,12 @@ func (api *NakamaApi) RpcFunc2(
 			return ApiRpc{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiRpc
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiRpc{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiRpc{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiRpc{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiRpc{}, api.responseError(resp)
 		}
 	}
 }
@@ -6607,7 +6416,7 @@ func (api *NakamaApi) RpcFunc(
 	}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return ApiRpc{}, err
 	}
@@ -6632,17 +6441,17 @@ func (api *NakamaApi) RpcFunc(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	// Run the HTTP request in a goroutine
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6653,7 +6462,7 @@ func (api *NakamaApi) RpcFunc(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiRpc{}, errors.New("request timed out")
+		return ApiRpc{}, api.contextError()
 	case err := <-errorChan:
 		return ApiRpc{}, err
 	case resp := <-responseChan:
@@ -6664,18 +6473,12 @@ func (api *NakamaApi) RpcFunc(
 			return ApiRpc{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var result ApiRpc
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiRpc{}, err
-			}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiRpc{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiRpc{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiRpc{}, api.responseError(resp)
 		}
 	}
 }
@@ -6697,7 +6500,7 @@ func (api *NakamaApi) SessionLogout(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -6722,16 +6525,16 @@ func (api *NakamaApi) SessionLogout(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6742,7 +6545,7 @@ func (api *NakamaApi) SessionLogout(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -6752,19 +6555,13 @@ func (api *NakamaApi) SessionLogout(
 		if resp.StatusCode == http.StatusNoContent {
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
 			var result interface{}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -6781,7 +6578,7 @@ func (api *NakamaApi) ReadStorageObjects(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return ApiStorageObjects{}, err
 	}
@@ -6806,16 +6603,16 @@ func (api *NakamaApi) ReadStorageObjects(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6826,7 +6623,7 @@ func (api *NakamaApi) ReadStorageObjects(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiStorageObjects{}, errors.New("request timed out")
+		return ApiStorageObjects{}, api.contextError()
 	case err := <-errorChan:
 		return ApiStorageObjects{}, err
 	case resp := <-responseChan:
@@ -6836,19 +6633,13 @@ func (api *NakamaApi) ReadStorageObjects(
 		if resp.StatusCode == http.StatusNoContent {
 			return ApiStorageObjects{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiStorageObjects{}, err
-			}
 			var result ApiStorageObjects
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiStorageObjects{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiStorageObjects{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiStorageObjects{}, api.responseError(resp)
 		}
 	}
 }
@@ -6865,7 +6656,7 @@ func (api *NakamaApi) WriteStorageObjects(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return ApiStorageObjectAcks{}, err
 	}
@@ -6890,16 +6681,16 @@ func (api *NakamaApi) WriteStorageObjects(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6910,7 +6701,7 @@ func (api *NakamaApi) WriteStorageObjects(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiStorageObjectAcks{}, errors.New("request timed out")
+		return ApiStorageObjectAcks{}, api.contextError()
 	case err := <-errorChan:
 		return ApiStorageObjectAcks{}, err
 	case resp := <-responseChan:
@@ -6920,19 +6711,13 @@ func (api *NakamaApi) WriteStorageObjects(
 		if resp.StatusCode == http.StatusNoContent {
 			return ApiStorageObjectAcks{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiStorageObjectAcks{}, err
-			}
 			var result ApiStorageObjectAcks
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiStorageObjectAcks{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiStorageObjectAcks{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiStorageObjectAcks{}, api.responseError(resp)
 		}
 	}
 }
@@ -6949,7 +6734,7 @@ func (api *NakamaApi) DeleteStorageObjects(
 	queryParams := url.Values{}
 
 	// Convert the body to JSON
-	bodyJson, err := json.Marshal(body)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(body)
 	if err != nil {
 		return nil, err
 	}
@@ -6974,16 +6759,16 @@ func (api *NakamaApi) DeleteStorageObjects(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -6994,7 +6779,7 @@ func (api *NakamaApi) DeleteStorageObjects(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -7014,8 +6799,7 @@ func (api *NakamaApi) DeleteStorageObjects(
 			}
 			return bodyBytes, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -7071,16 +6855,16 @@ func (api *NakamaApi) ListStorageObjects(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7091,7 +6875,7 @@ func (api *NakamaApi) ListStorageObjects(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiStorageObjectList{}, errors.New("request timed out")
+		return ApiStorageObjectList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiStorageObjectList{}, err
 	case resp := <-responseChan:
@@ -7101,19 +6885,13 @@ func (api *NakamaApi) ListStorageObjects(
 		if resp.StatusCode == http.StatusNoContent {
 			return ApiStorageObjectList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiStorageObjectList{}, err
-			}
 			var result ApiStorageObjectList
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiStorageObjectList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiStorageObjectList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiStorageObjectList{}, api.responseError(resp)
 		}
 	}
 }
@@ -7173,16 +6951,16 @@ func (api *NakamaApi) ListStorageObjects2(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7193,7 +6971,7 @@ func (api *NakamaApi) ListStorageObjects2(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiStorageObjectList{}, errors.New("request timed out")
+		return ApiStorageObjectList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiStorageObjectList{}, err
 	case resp := <-responseChan:
@@ -7203,19 +6981,13 @@ func (api *NakamaApi) ListStorageObjects2(
 		if resp.StatusCode == http.StatusNoContent {
 			return ApiStorageObjectList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiStorageObjectList{}, err
-			}
 			var result ApiStorageObjectList
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiStorageObjectList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiStorageObjectList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiStorageObjectList{}, api.responseError(resp)
 		}
 	}
 }
@@ -7278,16 +7050,16 @@ func (api *NakamaApi) ListTournaments(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7298,7 +7070,7 @@ func (api *NakamaApi) ListTournaments(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiTournamentList{}, errors.New("request timed out")
+		return ApiTournamentList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiTournamentList{}, err
 	case resp := <-responseChan:
@@ -7308,19 +7080,13 @@ func (api *NakamaApi) ListTournaments(
 		if resp.StatusCode == http.StatusNoContent {
 			return ApiTournamentList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiTournamentList{}, err
-			}
 			var result ApiTournamentList
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiTournamentList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiTournamentList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiTournamentList{}, api.responseError(resp)
 		}
 	}
 }
@@ -7365,12 +7131,12 @@ func (api *NakamaApi) DeleteTournamentRecord(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
-	resp, err := client.Do(req.WithContext(ctx))
+	client := api.httpClient()
+	resp, err := api.do(client, req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -7393,8 +7159,7 @@ func (api *NakamaApi) DeleteTournamentRecord(
 		return bodyBytes, nil
 	} else {
 		// Handle error response
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+		return nil, api.responseError(resp)
 	}
 }
 
@@ -7457,16 +7222,16 @@ func (api *NakamaApi) ListTournamentRecords(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7477,7 +7242,7 @@ func (api *NakamaApi) ListTournamentRecords(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiTournamentRecordList{}, errors.New("request timed out")
+		return ApiTournamentRecordList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiTournamentRecordList{}, err
 	case resp := <-responseChan:
@@ -7487,19 +7252,13 @@ func (api *NakamaApi) ListTournamentRecords(
 		if resp.StatusCode == http.StatusNoContent {
 			return ApiTournamentRecordList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiTournamentRecordList{}, err
-			}
 			var result ApiTournamentRecordList
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiTournamentRecordList{}, err
 			}
 			return result, nil
 		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiTournamentRecordList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiTournamentRecordList{}, api.responseError(resp)
 		}
 	}
 }
@@ -7524,7 +7283,7 @@ func (api *NakamaApi) WriteTournamentRecord2(
 	urlPath := "/v2/tournament/" + url.QueryEscape(tournamentId)
 
 	// Prepare the request body
-	bodyJson, err := json.Marshal(record)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(record)
 	if err != nil {
 		return ApiLeaderboardRecord{}, fmt.Errorf("failed to marshal record: %w", err)
 	}
@@ -7549,12 +7308,12 @@ func (api *NakamaApi) WriteTournamentRecord2(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
-	resp, err := client.Do(req.WithContext(ctx))
+	client := api.httpClient()
+	resp, err := api.do(client, req.WithContext(ctx))
 	if err != nil {
 		return ApiLeaderboardRecord{}, err
 	}
@@ -7566,20 +7325,14 @@ func (api *NakamaApi) WriteTournamentRecord2(
 		return ApiLeaderboardRecord{}, nil
 	} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		// Success with content, parse response body
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return ApiLeaderboardRecord{}, err
-		}
 		var result ApiLeaderboardRecord
-		err = json.Unmarshal(bodyBytes, &result)
-		if err != nil {
+		if err := api.decode(resp, &result); err != nil {
 			return ApiLeaderboardRecord{}, err
 		}
 		return result, nil
 	} else {
 		// Handle error response
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return ApiLeaderboardRecord{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+		return ApiLeaderboardRecord{}, api.responseError(resp)
 	}
 }
 
@@ -7603,7 +7356,7 @@ func (api *NakamaApi) WriteTournamentRecord(
 	urlPath := "/v2/tournament/" + url.QueryEscape(tournamentId)
 
 	// Prepare the request body
-	bodyJson, err := json.Marshal(record)
+	bodyJson, err := codecOrStd(api.Codec).Marshal(record)
 	if err != nil {
 		return ApiLeaderboardRecord{}, fmt.Errorf("failed to marshal record: %w", err)
 	}
@@ -7628,16 +7381,16 @@ func (api *NakamaApi) WriteTournamentRecord(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7648,7 +7401,7 @@ func (api *NakamaApi) WriteTournamentRecord(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiLeaderboardRecord{}, errors.New("request timed out")
+		return ApiLeaderboardRecord{}, api.contextError()
 	case err := <-errorChan:
 		return ApiLeaderboardRecord{}, err
 	case resp := <-responseChan:
@@ -7658,20 +7411,14 @@ func (api *NakamaApi) WriteTournamentRecord(
 			return ApiLeaderboardRecord{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success with content, parse response body
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiLeaderboardRecord{}, err
-			}
 			var result ApiLeaderboardRecord
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiLeaderboardRecord{}, err
 			}
 			return result, nil
 		} else {
 			// Handle error response
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiLeaderboardRecord{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiLeaderboardRecord{}, api.responseError(resp)
 		}
 	}
 }
@@ -7717,16 +7464,16 @@ func (api *NakamaApi) JoinTournament(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7737,7 +7484,7 @@ func (api *NakamaApi) JoinTournament(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("request timed out")
+		return nil, api.contextError()
 	case err := <-errorChan:
 		return nil, err
 	case resp := <-responseChan:
@@ -7748,20 +7495,14 @@ func (api *NakamaApi) JoinTournament(
 			return nil, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success with content, parse response body
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
 			var result interface{}
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return nil, err
 			}
 			return result, nil
 		} else {
 			// Handle error response
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return nil, api.responseError(resp)
 		}
 	}
 }
@@ -7823,16 +7564,16 @@ func (api *NakamaApi) ListTournamentRecordsAroundOwner(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7843,7 +7584,7 @@ func (api *NakamaApi) ListTournamentRecordsAroundOwner(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiTournamentRecordList{}, errors.New("request timed out")
+		return ApiTournamentRecordList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiTournamentRecordList{}, err
 	case resp := <-responseChan:
@@ -7854,20 +7595,14 @@ func (api *NakamaApi) ListTournamentRecordsAroundOwner(
 			return ApiTournamentRecordList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success with content, parse response body
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiTournamentRecordList{}, err
-			}
 			var result ApiTournamentRecordList
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiTournamentRecordList{}, err
 			}
 			return result, nil
 		} else {
 			// Handle error response
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiTournamentRecordList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiTournamentRecordList{}, api.responseError(resp)
 		}
 	}
 }
@@ -7919,16 +7654,16 @@ func (api *NakamaApi) GetUsers(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -7939,7 +7674,7 @@ func (api *NakamaApi) GetUsers(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiUsers{}, errors.New("request timed out")
+		return ApiUsers{}, api.contextError()
 	case err := <-errorChan:
 		return ApiUsers{}, err
 	case resp := <-responseChan:
@@ -7950,20 +7685,14 @@ func (api *NakamaApi) GetUsers(
 			return ApiUsers{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success with content, parse response body
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiUsers{}, err
-			}
 			var result ApiUsers
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiUsers{}, err
 			}
 			return result, nil
 		} else {
 			// Handle error response
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiUsers{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiUsers{}, api.responseError(resp)
 		}
 	}
 }
@@ -8022,16 +7751,16 @@ func (api *NakamaApi) ListUserGroups(
 	}
 
 	// Create a context with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(api.TimeoutMs)*time.Millisecond)
+	ctx, cancel := api.requestContext()
 	defer cancel()
 
 	// Make the HTTP request
-	client := &http.Client{}
+	client := api.httpClient()
 	responseChan := make(chan *http.Response, 1)
 	errorChan := make(chan error, 1)
 
 	go func() {
-		resp, err := client.Do(req.WithContext(ctx))
+		resp, err := api.do(client, req.WithContext(ctx))
 		if err != nil {
 			errorChan <- err
 			return
@@ -8042,7 +7771,7 @@ func (api *NakamaApi) ListUserGroups(
 	// Wait for the response or the timeout
 	select {
 	case <-ctx.Done():
-		return ApiUserGroupList{}, errors.New("request timed out")
+		return ApiUserGroupList{}, api.contextError()
 	case err := <-errorChan:
 		return ApiUserGroupList{}, err
 	case resp := <-responseChan:
@@ -8053,20 +7782,14 @@ func (api *NakamaApi) ListUserGroups(
 			return ApiUserGroupList{}, nil
 		} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success with content, parse response body
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return ApiUserGroupList{}, err
-			}
 			var result ApiUserGroupList
-			err = json.Unmarshal(bodyBytes, &result)
-			if err != nil {
+			if err := api.decode(resp, &result); err != nil {
 				return ApiUserGroupList{}, err
 			}
 			return result, nil
 		} else {
 			// Handle error response
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return ApiUserGroupList{}, fmt.Errorf("unexpected response: %s", string(bodyBytes))
+			return ApiUserGroupList{}, api.responseError(resp)
 		}
 	}
 }
@@ -8087,3 +7810,447 @@ func (api *NakamaApi) buildFullUrl(basePath string, fragment string, queryParams
 
 	return fullPath
 }
+
+// decode decodes a JSON response body into v as it is read, rejecting unknown fields if
+// DisallowUnknownFields is set. A Codec is given the whole body instead. A body that is not JSON and
+// is declared as something else, such as a proxy's HTML page, is reported with a snippet of its
+// content rather than as a JSON syntax error.
+func (api *NakamaApi) decode(resp *http.Response, v any) error {
+	mediaType, params := responseContentType(resp)
+	decoded, err := charsetReader(resp.Body, params["charset"])
+	if err != nil {
+		return err
+	}
+	body := bufio.NewReader(decoded)
+	if bom, _ := body.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		_, _ = body.Discard(len(utf8BOM))
+	}
+
+	// Keep the start of a body that may not be JSON, to report it if decoding fails.
+	var snippet []byte
+	if !isJSONMediaType(mediaType) {
+		peeked, _ := body.Peek(maxBodySnippet)
+		snippet = bytes.Clone(peeked)
+	}
+
+	if api.Codec != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		err = api.Codec.Unmarshal(data, v)
+		if err != nil && snippet != nil {
+			return fmt.Errorf("unexpected response content type %q: %s", mediaType, bodySnippet(snippet))
+		}
+		return err
+	}
+
+	decoder := json.NewDecoder(body)
+	if api.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	err = decoder.Decode(v)
+	var syntaxError *json.SyntaxError
+	if snippet != nil && (errors.As(err, &syntaxError) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		return fmt.Errorf("unexpected response content type %q: %s", mediaType, bodySnippet(snippet))
+	}
+	return err
+}
+
+// responseContentType returns the media type and parameters of a response's Content-Type header.
+// The media type is empty if the header is missing or malformed.
+func responseContentType(resp *http.Response) (string, map[string]string) {
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", nil
+	}
+	return mediaType, params
+}
+
+// isJSONMediaType reports whether a response with the media type may hold JSON. A missing media
+// type is given the benefit of the doubt.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// utf8BOM is the byte order mark that some servers put before a UTF-8 body.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// charsetReader returns a reader that converts a response body in the given charset to UTF-8 as it
+// is read. JSON is UTF-8, but proxies and misconfigured servers sometimes declare Latin-1.
+func charsetReader(body io.Reader, charset string) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii":
+		return body, nil
+	case "iso-8859-1", "latin1":
+		return &latin1Reader{r: body}, nil
+	default:
+		return nil, fmt.Errorf("unsupported response charset %q", charset)
+	}
+}
+
+// decodeCharset converts a buffered response body in the given charset to UTF-8 and strips a byte
+// order mark.
+func decodeCharset(body []byte, charset string) ([]byte, error) {
+	reader, err := charsetReader(bytes.NewReader(body), charset)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimPrefix(decoded, utf8BOM), nil
+}
+
+// latin1Reader converts an ISO-8859-1 stream to UTF-8. Bytes below 0x80 are copied, and the others
+// become two-byte sequences.
+type latin1Reader struct {
+	r       io.Reader
+	raw     [512]byte
+	buf     []byte // The read bytes not yet converted.
+	pending byte   // The second byte of a sequence whose first byte was returned, or 0.
+	err     error  // The error of the last read from r.
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if l.pending != 0 {
+			p[n] = l.pending
+			l.pending = 0
+			n++
+			continue
+		}
+		if len(l.buf) == 0 {
+			// Return what has been converted rather than block on another read.
+			if n > 0 || l.err != nil {
+				break
+			}
+			var m int
+			m, l.err = l.r.Read(l.raw[:])
+			l.buf = l.raw[:m]
+			continue
+		}
+		b := l.buf[0]
+		l.buf = l.buf[1:]
+		if b < 0x80 {
+			p[n] = b
+		} else {
+			p[n] = 0xc0 | b>>6
+			l.pending = 0x80 | b&0x3f
+		}
+		n++
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, l.err
+	}
+	return n, nil
+}
+
+// httpClient returns the HTTP client used for requests.
+func (api *NakamaApi) httpClient() *http.Client {
+	if api.HTTPClient != nil {
+		return api.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// requestContext creates the context for a single request, bounded by TimeoutMs and by the call
+// context if one is set.
+func (api *NakamaApi) requestContext() (context.Context, context.CancelFunc) {
+	parent := api.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, time.Duration(api.TimeoutMs)*time.Millisecond)
+	if api.callContext == nil {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(api.callContext, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// contextError returns the error for a request whose context is done.
+func (api *NakamaApi) contextError() error {
+	if api.Context != nil && api.Context.Err() != nil {
+		return ErrClientClosed
+	}
+	if api.callContext != nil && api.callContext.Err() != nil {
+		return api.callContext.Err()
+	}
+	return errors.New("request timed out")
+}
+
+// wait pauses for d before a retry. It returns early with the same errors as a request if the
+// client is closed or the call context is done.
+func (api *NakamaApi) wait(d time.Duration) error {
+	var closed, cancelled <-chan struct{}
+	if api.Context != nil {
+		closed = api.Context.Done()
+	}
+	if api.callContext != nil {
+		cancelled = api.callContext.Done()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-closed:
+		return ErrClientClosed
+	case <-cancelled:
+		return api.callContext.Err()
+	}
+}
+
+// do sends the request with the given client. A request rejected with 401 is retried once with the
+// token returned by OnUnauthorized.
+func (api *NakamaApi) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if api.AdminToken != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+api.AdminToken)
+	}
+	api.setIdempotencyKey(req)
+	resp, err := api.sendWithRetries(client, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || api.OnUnauthorized == nil {
+		return resp, err
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	newToken, refreshErr := api.OnUnauthorized(token)
+	if refreshErr != nil || newToken == "" || newToken == token {
+		loggerOrNoop(api.Logger).Debug("Nakama request unauthorized, not retrying", "method", req.Method, "url", redactURL(req.URL), "error", refreshErr)
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+newToken)
+	resp.Body.Close()
+	return api.sendWithRetries(client, retry)
+}
+
+// idempotencyKeyHeader is the header that identifies a write across its retries.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// setIdempotencyKey sets the Idempotency-Key of a write that has none: the one from
+// Client.WithIdempotencyKey, or a generated one if retries are enabled.
+func (api *NakamaApi) setIdempotencyKey(req *http.Request) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || req.Header.Get(idempotencyKeyHeader) != "" {
+		return
+	}
+	switch {
+	case api.idempotencyKey != "":
+		req.Header.Set(idempotencyKeyHeader, api.idempotencyKey)
+	case api.MaxRetries > 0:
+		req.Header.Set(idempotencyKeyHeader, generateIdempotencyKey())
+	}
+}
+
+// sendWithRetries sends the request, retrying it up to MaxRetries times while retryable allows.
+func (api *NakamaApi) sendWithRetries(client *http.Client, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := api.send(client, req)
+		if attempt >= api.MaxRetries || !api.retryable(req, resp, err) {
+			return resp, err
+		}
+		loggerOrNoop(api.Logger).Debug("Nakama request failed, retrying", "method", req.Method, "url", redactURL(req.URL), "attempt", attempt+1, "error", err)
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			retry.Body = body
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if waitErr := api.wait(api.backoff().Delay(attempt)); waitErr != nil {
+			return nil, waitErr
+		}
+		req = retry
+	}
+}
+
+// backoff returns the delays between retries.
+func (api *NakamaApi) backoff() BackoffConfig {
+	if api.Backoff == nil {
+		return DefaultBackoffConfig()
+	}
+	return *api.Backoff
+}
+
+// retryable reports whether a failed request may be sent again: it failed with a transport error or
+// a 502, 503 or 504, was not aborted, and is a read or carries an Idempotency-Key.
+func (api *NakamaApi) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// Requests aborted by Close, the caller or the circuit breaker are not retried.
+		if errors.Is(err, ErrClientClosed) || errors.Is(err, ErrUnavailable) || req.Context().Err() != nil {
+			return false
+		}
+	} else {
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return false
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	return req.Method == http.MethodGet || req.Method == http.MethodHead || req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// send sends the request with the given client, firing the request hooks around the call.
+func (api *NakamaApi) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	if api.Context != nil && api.Context.Err() != nil {
+		return nil, ErrClientClosed
+	}
+
+	if api.Breaker != nil {
+		if err := api.Breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	if api.OnRequestStart != nil {
+		api.OnRequestStart(req.Method, req.URL.Path)
+	}
+
+	if api.Debug {
+		api.logRequest(req)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil && api.Context != nil && api.Context.Err() != nil {
+		err = ErrClientClosed
+	}
+	if api.Breaker != nil {
+		// Requests aborted by Close or by the caller say nothing about connectivity.
+		if errors.Is(err, ErrClientClosed) || api.callContext != nil && api.callContext.Err() != nil {
+			api.Breaker.release()
+		} else {
+			api.Breaker.record(err != nil)
+		}
+	}
+	if resp != nil {
+		resp.Body = api.limitBody(resp.Body)
+	}
+
+	if api.Debug {
+		api.logResponse(req, resp, err)
+	}
+
+	if api.OnRequestEnd != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		api.OnRequestEnd(req.Method, req.URL.Path, status, time.Since(start), err)
+	}
+	return resp, err
+}
+
+// limitBody wraps a response body so that reading more than MaxResponseBytes fails.
+func (api *NakamaApi) limitBody(body io.ReadCloser) io.ReadCloser {
+	limit := api.MaxResponseBytes
+	if limit <= 0 {
+		limit = DefaultMaxResponseBytes
+	}
+	return &limitedBody{ReadCloser: body, limit: limit, remaining: limit}
+}
+
+// limitedBody is a response body that fails with ErrResponseTooLarge once more than limit bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Read one more byte to tell a body of exactly limit bytes from a larger one.
+		var probe [1]byte
+		if n, err := b.ReadCloser.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// errReader is a reader that returns err, or io.EOF if err is nil.
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if r.err == nil {
+		return 0, io.EOF
+	}
+	return 0, r.err
+}
+
+// logRequest logs a request with its credentials redacted.
+func (api *NakamaApi) logRequest(req *http.Request) {
+	var body []byte
+	if req.GetBody != nil {
+		if reader, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(reader)
+			reader.Close()
+		}
+	}
+
+	authorization := ""
+	if req.Header.Get("Authorization") != "" {
+		authorization = redactedValue
+	}
+
+	loggerOrNoop(api.Logger).Debug("Nakama request",
+		"method", req.Method,
+		"url", redactURL(req.URL),
+		"authorization", authorization,
+		"body", redactJSONBody(body),
+	)
+}
+
+// logResponse logs a response, restoring its body so it can still be decoded.
+func (api *NakamaApi) logResponse(req *http.Request, resp *http.Response, err error) {
+	if err != nil {
+		loggerOrNoop(api.Logger).Debug("Nakama response", "method", req.Method, "url", redactURL(req.URL), "error", err)
+		return
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), &errReader{readErr}))
+	if readErr != nil {
+		loggerOrNoop(api.Logger).Debug("Nakama response", "method", req.Method, "url", redactURL(req.URL), "status", resp.StatusCode, "error", readErr)
+		return
+	}
+
+	loggerOrNoop(api.Logger).Debug("Nakama response",
+		"method", req.Method,
+		"url", redactURL(req.URL),
+		"status", resp.StatusCode,
+		"body", redactJSONBody(body),
+	)
+}
@@ -1,7 +1,17 @@
 package nakama
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,3 +33,200 @@ func TestAuthenticateWithDeviceId(t *testing.T) {
 	assert.NotNil(t, session)
 	assert.IsType(t, &Session{}, session)
 }
+
+func TestNakamaApi_RequestHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var started, ended []string
+	var endStatus int
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs}
+	api.OnRequestStart = func(method string, path string) {
+		started = append(started, method+" "+path)
+	}
+	api.OnRequestEnd = func(method string, path string, status int, dur time.Duration, err error) {
+		ended = append(ended, method+" "+path)
+		endStatus = status
+		assert.NoError(t, err)
+	}
+
+	_, err := api.Healthcheck("token", map[string]string{})
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"GET /healthcheck"}, started)
+	assert.Equal(t, []string{"GET /healthcheck"}, ended)
+	assert.Equal(t, http.StatusServiceUnavailable, endStatus)
+}
+
+func TestNakamaApi_MaxResponseBytes(t *testing.T) {
+	body := `{"notifications":[{"subject":"` + strings.Repeat("x", 2048) + `"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	for _, debug := range []bool{false, true} {
+		api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs, MaxResponseBytes: 1024, Debug: debug}
+		_, err := api.ListNotifications("token", nil, nil, map[string]string{})
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+
+		api.MaxResponseBytes = int64(len(body))
+		list, err := api.ListNotifications("token", nil, nil, map[string]string{})
+		assert.NoError(t, err)
+		assert.Len(t, list.Notifications, 1)
+	}
+}
+
+func TestNakamaApi_DisallowUnknownFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"wallet":"{}","future_field":true}`))
+	}))
+	defer server.Close()
+
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs}
+	account, err := api.GetAccount("token", map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", *account.Wallet)
+
+	api.DisallowUnknownFields = true
+	_, err = api.GetAccount("token", map[string]string{})
+	assert.ErrorContains(t, err, `unknown field "future_field"`)
+}
+
+func TestNakamaApi_DecodeContentType(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs}
+
+	// A captive portal or misrouted proxy answering with a page.
+	contentType, body = "text/html; charset=utf-8", "<html><body>Please sign in to the network</body></html>"
+	_, err := api.GetAccount("token", map[string]string{})
+	assert.EqualError(t, err, `unexpected response content type "text/html": <html><body>Please sign in to the network</body></html>`)
+
+	// JSON served with a generic content type is still decoded.
+	contentType, body = "text/plain", `{"wallet":"{}"}`
+	account, err := api.GetAccount("token", map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", *account.Wallet)
+
+	contentType, body = "application/json; charset=UTF-8", "\xef\xbb\xbf{\"wallet\":\"{}\"}"
+	account, err = api.GetAccount("token", map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", *account.Wallet)
+
+	contentType, body = "application/json; charset=ISO-8859-1", "{\"wallet\":\"{}\",\"custom_id\":\"caf\xe9\"}"
+	account, err = api.GetAccount("token", map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "café", *account.CustomID)
+
+	contentType = "application/json; charset=UTF-16"
+	_, err = api.GetAccount("token", map[string]string{})
+	assert.EqualError(t, err, `unsupported response charset "UTF-16"`)
+}
+
+func TestNakamaApi_DecodeStreamed(t *testing.T) {
+	// Many notifications, well past the buffers of bufio and the JSON decoder, written in small
+	// flushed chunks so that each read returns only part of the body.
+	var notifications []string
+	for i := 0; i < 500; i++ {
+		notifications = append(notifications, fmt.Sprintf(`{"id":"n%d","subject":"%s"}`, i, strings.Repeat("x", 200)))
+	}
+	body := []byte(`{"notifications":[` + strings.Join(notifications, ",") + `]}`)
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		for chunk := range slices.Chunk(body, 512) {
+			_, _ = w.Write(chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	for _, contentType = range []string{"application/json", "application/json; charset=ISO-8859-1"} {
+		api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs}
+		list, err := api.ListNotifications("token", nil, nil, map[string]string{})
+		assert.NoError(t, err, contentType)
+		assert.Len(t, list.Notifications, 500, contentType)
+		assert.Equal(t, "n499", *list.Notifications[499].ID, contentType)
+		assert.Equal(t, strings.Repeat("x", 200), *list.Notifications[0].Subject, contentType)
+
+		// The limit is reached part way through the body, after decoding has started.
+		api.MaxResponseBytes = int64(len(body) / 2)
+		_, err = api.ListNotifications("token", nil, nil, map[string]string{})
+		assert.ErrorIs(t, err, ErrResponseTooLarge, contentType)
+	}
+}
+
+func TestLatin1Reader(t *testing.T) {
+	latin1 := []byte("caf\xe9 \xff" + strings.Repeat("\xe9", 1000))
+	want := "café ÿ" + strings.Repeat("é", 1000)
+
+	// One-byte reads split every two-byte sequence across calls.
+	decoded, err := io.ReadAll(iotest.OneByteReader(&latin1Reader{r: iotest.OneByteReader(bytes.NewReader(latin1))}))
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(decoded))
+
+	decoded, err = io.ReadAll(&latin1Reader{r: iotest.DataErrReader(bytes.NewReader(latin1))})
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(decoded))
+}
+
+func TestNakamaApi_DebugLoggingRedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token":"secret-token","refresh_token":"secret-refresh","created":true}`))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs, Logger: logger, Debug: true}
+	email, password := "user@example.com", "hunter22"
+
+	session, err := api.AuthenticateEmail("defaultkey", "", ApiAccountEmail{Email: &email, Password: &password}, nil, nil, map[string]string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-token", *session.Token, "the response body must still be decodable after logging")
+	assert.Equal(t, []string{"debug: Nakama request", "debug: Nakama response"}, logger.Messages())
+	assert.Equal(t, "[REDACTED]", logger.Attr(0, "authorization"))
+	assert.Contains(t, logger.Attr(0, "body"), "user@example.com")
+	assert.NotContains(t, logger.Attr(0, "body"), "hunter22")
+	assert.Equal(t, http.StatusOK, logger.Attr(1, "status"))
+	assert.NotContains(t, logger.Attr(1, "body"), "secret-token")
+	assert.NotContains(t, logger.Attr(1, "body"), "secret-refresh")
+}
+
+// countingCodec is a Codec that counts its calls and delegates to encoding/json.
+type countingCodec struct {
+	marshals, unmarshals atomic.Int32
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals.Add(1)
+	return StdCodec{}.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals.Add(1)
+	return StdCodec{}.Unmarshal(data, v)
+}
+
+func TestNakamaApi_Codec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"echo","payload":"{}"}`))
+	}))
+	defer server.Close()
+
+	codec := &countingCodec{}
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs, Codec: codec}
+	rpc, err := api.RpcFunc("token", "echo", "{}", nil, map[string]string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", *rpc.ID)
+	assert.Equal(t, int32(1), codec.marshals.Load())
+	assert.Equal(t, int32(1), codec.unmarshals.Load())
+}
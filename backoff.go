@@ -0,0 +1,76 @@
+package nakama
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// JitterStrategy is how a BackoffConfig randomises its delays.
+type JitterStrategy int
+
+const (
+	JitterFull  JitterStrategy = iota // A random delay between zero and the exponential delay.
+	JitterEqual                       // Half the exponential delay plus a random delay up to the other half.
+	JitterNone                        // The exponential delay exactly.
+)
+
+// Default backoff values
+const (
+	DefaultBackoffBase   = 100 * time.Millisecond
+	DefaultBackoffMax    = 10 * time.Second
+	DefaultBackoffFactor = 2.0
+)
+
+// BackoffConfig describes the delays between retry attempts. It is shared by everything in the
+// client that retries, so that they all back off the same way.
+type BackoffConfig struct {
+	Base   time.Duration  // The delay before the first retry. Zero retries immediately.
+	Max    time.Duration  // The upper bound of any delay. Zero means no bound.
+	Factor float64        // The growth of the delay per attempt. Values below 1 are treated as 1.
+	Jitter JitterStrategy // How the delay is randomised. Defaults to JitterFull.
+	Rand   func() float64 // Returns a number in [0, 1). Defaults to math/rand; set it for deterministic tests.
+}
+
+// DefaultBackoffConfig returns the backoff used by a new Client: exponential from 100ms doubling up to
+// 10s, with full jitter.
+func DefaultBackoffConfig() BackoffConfig {
+	return BackoffConfig{
+		Base:   DefaultBackoffBase,
+		Max:    DefaultBackoffMax,
+		Factor: DefaultBackoffFactor,
+		Jitter: JitterFull,
+	}
+}
+
+// isZero reports whether the config was left unset.
+func (b BackoffConfig) isZero() bool {
+	return b.Base == 0 && b.Max == 0 && b.Factor == 0 && b.Jitter == JitterFull && b.Rand == nil
+}
+
+// Delay returns how long to wait before retry number attempt, counting from 0.
+func (b BackoffConfig) Delay(attempt int) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+
+	factor := math.Max(b.Factor, 1)
+	delay := float64(b.Base) * math.Pow(factor, float64(max(attempt, 0)))
+	limit := b.Max
+	if limit <= 0 {
+		limit = math.MaxInt64 / 2
+	}
+	delay = math.Min(delay, float64(limit))
+
+	random := b.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+	switch b.Jitter {
+	case JitterFull:
+		delay *= random()
+	case JitterEqual:
+		delay = delay/2 + delay/2*random()
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,60 @@
+package nakama
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffConfig_Delay(t *testing.T) {
+	half := func() float64 { return 0.5 }
+	tests := []struct {
+		name    string
+		config  BackoffConfig
+		attempt int
+		want    time.Duration
+	}{
+		{"no jitter first attempt", BackoffConfig{Base: 100 * time.Millisecond, Factor: 2, Jitter: JitterNone}, 0, 100 * time.Millisecond},
+		{"no jitter grows by factor", BackoffConfig{Base: 100 * time.Millisecond, Factor: 2, Jitter: JitterNone}, 3, 800 * time.Millisecond},
+		{"capped at max", BackoffConfig{Base: 100 * time.Millisecond, Max: time.Second, Factor: 2, Jitter: JitterNone}, 10, time.Second},
+		{"full jitter", BackoffConfig{Base: 100 * time.Millisecond, Factor: 2, Jitter: JitterFull, Rand: half}, 1, 100 * time.Millisecond},
+		{"equal jitter", BackoffConfig{Base: 100 * time.Millisecond, Factor: 2, Jitter: JitterEqual, Rand: half}, 1, 150 * time.Millisecond},
+		{"factor below one is constant", BackoffConfig{Base: 100 * time.Millisecond, Factor: 0, Jitter: JitterNone}, 5, 100 * time.Millisecond},
+		{"zero base", BackoffConfig{}, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.config.Delay(tt.attempt))
+		})
+	}
+}
+
+func TestBackoffConfig_UnboundedDoesNotOverflow(t *testing.T) {
+	config := BackoffConfig{Base: time.Second, Factor: 10, Jitter: JitterNone}
+	assert.Greater(t, config.Delay(1000), time.Duration(0))
+	assert.GreaterOrEqual(t, config.Delay(1000), config.Delay(10))
+}
+
+func TestBackoffConfig_DefaultJitterStaysInRange(t *testing.T) {
+	config := DefaultBackoffConfig()
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := config.Delay(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, DefaultBackoffMax)
+	}
+}
+
+func TestNakamaApi_WaitStopsOnCallContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	api := &NakamaApi{callContext: ctx}
+
+	start := time.Now()
+	err := api.wait(time.Minute)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,119 @@
+package nakama
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests are sent.
+	BreakerOpen                         // Requests fail fast with ErrUnavailable until the cooldown ends.
+	BreakerHalfOpen                     // The cooldown has ended and a single trial request is sent.
+)
+
+// String returns a human-readable name for the breaker state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// CircuitBreaker fails requests fast while the server is unreachable, instead of letting each wait
+// for its timeout. After Threshold consecutive transport failures, such as a failed dial or a timed
+// out request, it opens and requests fail immediately with ErrUnavailable for Cooldown. It then lets
+// one trial request through, closing again if it succeeds or reopening if it fails. Error responses
+// from the server are not transport failures and reset the count.
+type CircuitBreaker struct {
+	Threshold int           // Consecutive transport failures that open the breaker.
+	Cooldown  time.Duration // How long the breaker stays open before a trial request.
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // Whether a trial request is in flight.
+}
+
+// NewCircuitBreaker creates a closed breaker that opens after threshold consecutive transport
+// failures and stays open for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state(time.Now())
+}
+
+// Reset closes the breaker and clears its failure count, for example when the device reports that
+// connectivity is back.
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.trial = false
+}
+
+// state returns the state at now. The caller must hold the lock.
+func (b *CircuitBreaker) state(now time.Time) BreakerState {
+	switch {
+	case b.openUntil.IsZero():
+		return BreakerClosed
+	case now.Before(b.openUntil):
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// allow reports whether a request may be sent, claiming the trial request when half-open.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state(time.Now()) {
+	case BreakerOpen:
+		return fmt.Errorf("%w: circuit breaker open", ErrUnavailable)
+	case BreakerHalfOpen:
+		if b.trial {
+			return fmt.Errorf("%w: circuit breaker half-open", ErrUnavailable)
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record records the outcome of a request sent after allow.
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold || !b.openUntil.IsZero() {
+		b.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
+
+// release gives up the trial request of a request that was aborted without an outcome.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
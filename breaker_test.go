@@ -0,0 +1,80 @@
+package nakama
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_FailsFastWhileOpen(t *testing.T) {
+	var requests int32
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if down.Load() {
+			// Drop the connection to simulate a transport failure.
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(2, 50*time.Millisecond)
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs, Breaker: breaker}
+
+	for i := 0; i < 2; i++ {
+		_, err := api.GetAccount("token", map[string]string{})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrUnavailable)
+	}
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	_, err := api.GetAccount("token", map[string]string{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests), "an open breaker must not send requests")
+
+	// After the cooldown a failed trial reopens the breaker.
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	_, err = api.GetAccount("token", map[string]string{})
+	assert.NotErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	// A successful trial closes it.
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	_, err = api.GetAccount("token", map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestCircuitBreaker_ServerErrorsAreNotFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(1, time.Minute)
+	api := &NakamaApi{ServerKey: "defaultkey", BasePath: server.URL, TimeoutMs: DefaultTimeoutMs, Breaker: breaker}
+
+	_, err := api.GetAccount("token", map[string]string{})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestCircuitBreaker_Reset(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.record(true)
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	breaker.Reset()
+	assert.Equal(t, BreakerClosed, breaker.State())
+	assert.NoError(t, breaker.allow())
+}
@@ -1,9 +1,20 @@
 package nakama
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +27,10 @@ const (
 	DefaultExpiredTimespanMs = 5 * 60 * 1000 // 5 minutes in milliseconds
 )
 
+// The Get methods of the high-level types below are generated into accessors.go. Add a type here when
+// adding one with pointer fields.
+//go:generate go run ./internal/cmd/genaccessors -types LeaderboardRecord,LeaderboardRecordList,Tournament,TournamentList,TournamentRecordList,StorageObject,StorageObjectList,ChannelMessage,ChannelMessageList,User,Friend,Friends,FriendOfFriend,FriendsOfFriends,GroupUser,GroupUserList,Group,GroupList,UserGroup,UserGroupList,Notification,NotificationList,ValidatedSubscription,SubscriptionList -output accessors.go
+
 // RpcResponse defines the response for an RPC function executed on the server.
 type RpcResponse struct {
 	// ID is the identifier of the function.
@@ -30,16 +45,72 @@ type LeaderboardRecord struct {
 	ExpiryTime    *string                `json:"expiry_time,omitempty"`
 	LeaderboardID *string                `json:"leaderboard_id,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	RawMetadata   string                 `json:"-"` // Metadata as the server sent it.
 	NumScore      *int                   `json:"num_score,omitempty"`
 	OwnerID       *string                `json:"owner_id,omitempty"`
-	Rank          *int                   `json:"rank,omitempty"`
-	Score         *int                   `json:"score,omitempty"`
-	SubScore      *int                   `json:"subscore,omitempty"`
+	Rank          *int64                 `json:"rank,omitempty"`
+	Score         *int64                 `json:"score,omitempty"`
+	SubScore      *int64                 `json:"subscore,omitempty"`
 	UpdateTime    *string                `json:"update_time,omitempty"`
 	Username      *string                `json:"username,omitempty"`
 	MaxNumScore   *int                   `json:"max_num_score,omitempty"`
 }
 
+// leaderboardRecordFromApi converts a low-level leaderboard record, parsing its string-encoded
+// rank and scores as int64 and decoding its metadata.
+func leaderboardRecordFromApi(o ApiLeaderboardRecord) (*LeaderboardRecord, error) {
+	record := &LeaderboardRecord{
+		LeaderboardID: o.LeaderboardID,
+		NumScore:      o.NumScore,
+		OwnerID:       o.OwnerID,
+		Username:      o.Username,
+		MaxNumScore:   o.MaxNumScore,
+	}
+
+	if o.CreateTime != nil {
+		record.CreateTime = timeToStringPointer(*o.CreateTime, time.RFC3339)
+	}
+	if o.ExpiryTime != nil {
+		record.ExpiryTime = timeToStringPointer(*o.ExpiryTime, time.RFC3339)
+	}
+	if o.UpdateTime != nil {
+		record.UpdateTime = timeToStringPointer(*o.UpdateTime, time.RFC3339)
+	}
+
+	var err error
+	if record.Rank, err = stringPointerToInt64Pointer(o.Rank); err != nil {
+		return nil, fmt.Errorf("invalid leaderboard record rank: %w", err)
+	}
+	if record.Score, err = stringPointerToInt64Pointer(o.Score); err != nil {
+		return nil, fmt.Errorf("invalid leaderboard record score: %w", err)
+	}
+	if record.SubScore, err = stringPointerToInt64Pointer(o.Subscore); err != nil {
+		return nil, fmt.Errorf("invalid leaderboard record subscore: %w", err)
+	}
+
+	if o.Metadata != nil {
+		if err := DecodeJSONField(*o.Metadata, &record.Metadata); err != nil {
+			return nil, err
+		}
+		record.RawMetadata = *o.Metadata
+	}
+
+	return record, nil
+}
+
+// rankCountFromApi parses the rank count of a record list. An absent or empty rank count is
+// unknown and maps to nil rather than zero.
+func rankCountFromApi(s *string) (*int, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(*s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rank count %q: %w", *s, err)
+	}
+	return &value, nil
+}
+
 type LeaderboardRecordList struct {
 	NextCursor   *string             `json:"next_cursor,omitempty"`
 	OwnerRecords []LeaderboardRecord `json:"owner_records,omitempty"`
@@ -62,22 +133,91 @@ type Tournament struct {
 	CanEnter      *bool                  `json:"can_enter,omitempty"`
 	EndActive     *int                   `json:"end_active,omitempty"`
 	NextReset     *int                   `json:"next_reset,omitempty"`
+	PrevReset     *int                   `json:"prev_reset,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	RawMetadata   string                 `json:"-"` // Metadata as the server sent it.
 	CreateTime    *string                `json:"create_time,omitempty"`
 	StartTime     *string                `json:"start_time,omitempty"`
 	EndTime       *string                `json:"end_time,omitempty"`
 	StartActive   *int                   `json:"start_active,omitempty"`
 }
 
+// CurrentWindow returns the active window of the tournament at now, or the next window if the
+// tournament is between windows. The active fields are a snapshot taken when the tournament was
+// listed; if now is past NextReset the following window is derived from NextReset and Duration.
+// A zero end means the window is open-ended. Both are zero if the tournament has no active window.
+func (t *Tournament) CurrentWindow(now time.Time) (start, end time.Time) {
+	startActive, endActive := intValue(t.StartActive), intValue(t.EndActive)
+	if nextReset := intValue(t.NextReset); nextReset != 0 && now.Unix() >= int64(nextReset) {
+		startActive, endActive = nextReset, 0
+		if duration := intValue(t.Duration); duration > 0 {
+			endActive = nextReset + duration
+		}
+	}
+	if startActive == 0 {
+		return time.Time{}, time.Time{}
+	}
+
+	start = time.Unix(int64(startActive), 0)
+	if endActive != 0 {
+		end = time.Unix(int64(endActive), 0)
+	}
+	if t.EndTime != nil {
+		if endTime, err := time.Parse(time.RFC3339, *t.EndTime); err == nil && endTime.Unix() > 0 {
+			if !endTime.After(start) {
+				return time.Time{}, time.Time{}
+			}
+			if end.IsZero() || endTime.Before(end) {
+				end = endTime
+			}
+		}
+	}
+	return start, end
+}
+
+// IsActive reports whether records can be submitted to the tournament at now.
+func (t *Tournament) IsActive(now time.Time) bool {
+	start, end := t.CurrentWindow(now)
+	if start.IsZero() || now.Before(start) {
+		return false
+	}
+	return end.IsZero() || now.Before(end)
+}
+
+// TimeUntilReset returns the time from now until the tournament next resets. It returns 0 for a
+// tournament without a reset schedule (NextReset is 0) or whose reset has already passed.
+func (t *Tournament) TimeUntilReset(now time.Time) time.Duration {
+	nextReset := intValue(t.NextReset)
+	if nextReset == 0 {
+		return 0
+	}
+	if d := time.Unix(int64(nextReset), 0).Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
 type TournamentList struct {
 	Tournaments []Tournament `json:"tournaments,omitempty"`
 	Cursor      *string      `json:"cursor,omitempty"`
 }
 
+// FilterActiveTournaments returns the tournaments that are active at now, as reported by IsActive.
+func FilterActiveTournaments(tournaments []Tournament, now time.Time) []Tournament {
+	active := []Tournament{}
+	for _, tournament := range tournaments {
+		if tournament.IsActive(now) {
+			active = append(active, tournament)
+		}
+	}
+	return active
+}
+
 type TournamentRecordList struct {
 	NextCursor   *string             `json:"next_cursor,omitempty"`
 	OwnerRecords []LeaderboardRecord `json:"owner_records,omitempty"`
 	PrevCursor   *string             `json:"prev_cursor,omitempty"`
+	RankCount    *int                `json:"rank_count,omitempty"`
 	Records      []LeaderboardRecord `json:"records,omitempty"`
 }
 
@@ -93,6 +233,19 @@ type WriteLeaderboardRecord struct {
 	SubScore *string                `json:"subscore,omitempty"`
 }
 
+// Storage object read permissions, as used in PermissionRead.
+const (
+	PermissionReadNoAccess = 0 // Only the server can read the object.
+	PermissionReadOwner    = 1 // Only the owner can read the object.
+	PermissionReadPublic   = 2 // Any user can read the object.
+)
+
+// Storage object write permissions, as used in PermissionWrite.
+const (
+	PermissionWriteNoAccess = 0 // Only the server can write the object.
+	PermissionWriteOwner    = 1 // Only the owner can write the object.
+)
+
 type WriteStorageObject struct {
 	Collection      *string                `json:"collection,omitempty"`
 	Key             *string                `json:"key,omitempty"`
@@ -100,6 +253,11 @@ type WriteStorageObject struct {
 	PermissionWrite *int                   `json:"permission_write,omitempty"`
 	Value           map[string]interface{} `json:"value,omitempty"`
 	Version         *string                `json:"version,omitempty"`
+
+	// RawValue, if set, is written as the value instead of encoding Value. Set it to the RawValue of
+	// a StorageObject to write the value back byte for byte, as re-encoding Value sorts its keys and
+	// may change how numbers are formatted.
+	RawValue string `json:"-"`
 }
 
 type StorageObject struct {
@@ -112,6 +270,11 @@ type StorageObject struct {
 	UserID          *string                `json:"user_id,omitempty"`
 	Value           map[string]interface{} `json:"value,omitempty"`
 	Version         *string                `json:"version,omitempty"`
+
+	// RawValue is Value as the server sent it. The raw fields of the high-level types share the
+	// strings of the response, so they cost no copy, but they keep the JSON in memory alongside the
+	// decoded map for as long as the object is held.
+	RawValue string `json:"-"`
 }
 
 type StorageObjectList struct {
@@ -127,6 +290,7 @@ type ChannelMessage struct {
 	ChannelID   *string                `json:"channel_id,omitempty"`
 	Code        *int                   `json:"code,omitempty"`
 	Content     map[string]interface{} `json:"content,omitempty"`
+	RawContent  string                 `json:"-"` // Content as the server sent it.
 	CreateTime  *string                `json:"create_time,omitempty"`
 	GroupID     *string                `json:"group_id,omitempty"`
 	MessageID   *string                `json:"message_id,omitempty"`
@@ -160,6 +324,7 @@ type User struct {
 	LangTag               *string                `json:"lang_tag,omitempty"`
 	Location              *string                `json:"location,omitempty"`
 	Metadata              map[string]interface{} `json:"metadata,omitempty"`
+	RawMetadata           string                 `json:"-"` // Metadata as the server sent it.
 	Online                *bool                  `json:"online,omitempty"`
 	SteamID               *string                `json:"steam_id,omitempty"`
 	Timezone              *string                `json:"timezone,omitempty"`
@@ -171,6 +336,14 @@ type Users struct {
 	Users []User `json:"users,omitempty"`
 }
 
+// Friend relationship states, as reported in Friend.State and accepted by ListFriends.
+const (
+	FriendStateMutual   = 0 // Both users are friends.
+	FriendStateOutgoing = 1 // The current user sent a friend request.
+	FriendStateIncoming = 2 // The current user received a friend request.
+	FriendStateBlocked  = 3 // The current user blocked the other user.
+)
+
 type Friend struct {
 	State *int  `json:"state,omitempty"`
 	User  *User `json:"user,omitempty"`
@@ -181,6 +354,16 @@ type Friends struct {
 	Cursor  *string  `json:"cursor,omitempty"`
 }
 
+// ByState groups the friends by their FriendState. A friend without a state is mutual.
+func (f *Friends) ByState() map[int][]Friend {
+	byState := make(map[int][]Friend)
+	for _, friend := range f.Friends {
+		state := intValue(friend.State)
+		byState[state] = append(byState[state], friend)
+	}
+	return byState
+}
+
 type FriendOfFriend struct {
 	Referrer *string `json:"referrer,omitempty"`
 	User     *User   `json:"user,omitempty"`
@@ -191,6 +374,15 @@ type FriendsOfFriends struct {
 	FriendsOfFriends []FriendOfFriend `json:"friends_of_friends,omitempty"`
 }
 
+// Group membership states, as reported in GroupUser.State and UserGroup.State and accepted by
+// ListGroupUsers and ListUserGroups.
+const (
+	GroupStateSuperadmin  = 0 // The user created the group or was promoted to superadmin.
+	GroupStateAdmin       = 1 // The user is a group admin.
+	GroupStateMember      = 2 // The user is a regular member.
+	GroupStateJoinRequest = 3 // The user requested to join a closed group.
+)
+
 type GroupUser struct {
 	User  *User `json:"user,omitempty"`
 	State *int  `json:"state,omitempty"`
@@ -211,6 +403,7 @@ type Group struct {
 	LangTag     *string                `json:"lang_tag,omitempty"`
 	MaxCount    *int                   `json:"max_count,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	RawMetadata string                 `json:"-"` // Metadata as the server sent it.
 	Name        *string                `json:"name,omitempty"`
 	Open        *bool                  `json:"open,omitempty"`
 	UpdateTime  *string                `json:"update_time,omitempty"`
@@ -234,11 +427,85 @@ type UserGroupList struct {
 type Notification struct {
 	Code       *int                   `json:"code,omitempty"`
 	Content    map[string]interface{} `json:"content,omitempty"`
+	RawContent string                 `json:"-"` // Content as the server sent it.
 	CreateTime *string                `json:"create_time,omitempty"`
 	ID         *string                `json:"id,omitempty"`
 	Persistent *bool                  `json:"persistent,omitempty"`
 	SenderID   *string                `json:"sender_id,omitempty"`
 	Subject    *string                `json:"subject,omitempty"`
+	codec      Codec                  // The codec of the client or socket that received it, used by DecodeContent.
+}
+
+// System notification codes. The server sends these with negative codes; notifications sent by
+// runtime code use codes of zero or more.
+const (
+	SystemNotificationDirectMessageRequest = -1 // A user sent a direct message while the recipient was offline.
+	SystemNotificationFriendRequest        = -2 // A user wants to add the recipient as a friend.
+	SystemNotificationFriendAccept         = -3 // A user accepted the recipient's friend request.
+	SystemNotificationGroupAccept          = -4 // The recipient was added to or accepted into a group.
+	SystemNotificationGroupJoinRequest     = -5 // A user wants to join a group the recipient administers.
+	SystemNotificationFriendJoinGame       = -6 // A friend joined the game for the first time.
+	SystemNotificationSingleSocket         = -7 // The recipient's other sessions were disconnected by a new login.
+	SystemNotificationUserBanned           = -8 // The recipient was banned.
+)
+
+// FriendRequestNotification is the content of a SystemNotificationFriendRequest notification.
+type FriendRequestNotification struct {
+	Username string `json:"username"` // The username of the user asking to be friends; SenderID holds their ID.
+}
+
+// FriendAcceptNotification is the content of a SystemNotificationFriendAccept notification.
+type FriendAcceptNotification struct {
+	Username string `json:"username"` // The username of the new friend; SenderID holds their ID.
+}
+
+// GroupAcceptNotification is the content of a SystemNotificationGroupAccept notification.
+type GroupAcceptNotification struct {
+	Name string `json:"name"` // The name of the group.
+}
+
+// GroupJoinRequestNotification is the content of a SystemNotificationGroupJoinRequest notification.
+type GroupJoinRequestNotification struct {
+	Name string `json:"name"` // The name of the group; SenderID holds the ID of the user asking to join.
+}
+
+// GroupRoleChangeNotification is the content of a notification with one of the role change codes
+// passed to Notification.DecodeContent.
+type GroupRoleChangeNotification struct {
+	GroupID string `json:"group_id"` // The group whose role changed.
+	State   int    `json:"state"`    // The recipient's new role, one of the GroupState constants.
+}
+
+// DecodeContent decodes the notification content according to its code. It returns a
+// *FriendRequestNotification, *FriendAcceptNotification, *GroupAcceptNotification or
+// *GroupJoinRequestNotification for those system codes, a *GroupRoleChangeNotification for the
+// roleChangeCodes, and the raw content map for any other code. The content is decoded with the Codec
+// of the client or socket that received the notification.
+//
+// The server sends no notification when a user is promoted or demoted, so there are no role change
+// codes by default: send one from a runtime after hook on PromoteGroupUsers or DemoteGroupUsers, with
+// content such as {"group_id": "...", "state": 1}, and pass its code here.
+func (n *Notification) DecodeContent(roleChangeCodes ...int) (interface{}, error) {
+	var out interface{}
+	switch code := intValue(n.Code); {
+	case slices.Contains(roleChangeCodes, code):
+		out = &GroupRoleChangeNotification{}
+	case code == SystemNotificationFriendRequest:
+		out = &FriendRequestNotification{}
+	case code == SystemNotificationFriendAccept:
+		out = &FriendAcceptNotification{}
+	case code == SystemNotificationGroupAccept:
+		out = &GroupAcceptNotification{}
+	case code == SystemNotificationGroupJoinRequest:
+		out = &GroupJoinRequestNotification{}
+	default:
+		return n.Content, nil
+	}
+
+	if err := decodeEnvelopeField(n.codec, n.Content, out); err != nil {
+		return nil, fmt.Errorf("invalid content for notification code %d: %w", intValue(n.Code), err)
+	}
+	return out, nil
 }
 
 type NotificationList struct {
@@ -269,15 +536,98 @@ type SubscriptionList struct {
 }
 
 // Client represents a client for the Nakama server.
+// The exported fields must be set before the client is used from several goroutines. Auto refresh
+// and the expired timespan can be changed at any time with SetAutoRefreshSession and
+// SetExpiredTimespanMs.
 type Client struct {
-	ExpiredTimespanMs  int64      // The expired timespan used to check session lifetime.
-	ApiClient          *NakamaApi // The low-level API client for Nakama server.
-	ServerKey          string
-	Host               string
-	Port               string
-	UseSSL             bool
-	Timeout            int
-	AutoRefreshSession bool
+	ApiClient         *NakamaApi // The low-level API client for Nakama server.
+	ServerKey         string
+	Host              string
+	Port              string
+	UseSSL            bool
+	Timeout           int
+	ValidateUsernames bool          // Check usernames locally before authenticating. Disable to defer to the server.
+	ValidateVars      bool          // Check session vars locally before authenticating or refreshing. Disable to defer to the server.
+	Logger            Logger        // The logger used by the client. Defaults to a no-op logger.
+	CorrectClockSkew  bool          // Offset session expiry checks by the clock skew observed by ServerTime.
+	Backoff           BackoffConfig // The delays between retried requests, refreshes and socket reconnects. Defaults to DefaultBackoffConfig.
+	StorageCache      StorageCache  // Caches decoded storage objects by version. Defaults to none.
+	HttpKey           string        // The server's runtime HTTP key, used by ServerInfo. Defaults to none.
+	settings          *clientSettings
+	refresher         *sessionRefresher
+	clockSkew         *atomic.Int64 // Server clock minus local clock, in nanoseconds.
+	lifecycle         *clientLifecycle
+	serverInfo        *serverInfoCache
+}
+
+// ServerInfo describes the server's version and the limits the client validates against. Limits the
+// server does not report are set to the client's defaults.
+type ServerInfo struct {
+	Version             string `json:"version"`                          // The server version.
+	MaxUsernameLength   int    `json:"max_username_length,omitempty"`    // The longest username, in bytes.
+	MaxMessageSizeBytes int64  `json:"max_message_size_bytes,omitempty"` // The largest socket message, in bytes.
+}
+
+// serverInfoCache holds the ServerInfo fetched by a client and its copies.
+type serverInfoCache struct {
+	mu   sync.Mutex // Held while fetching, so that concurrent calls share a single request.
+	info atomic.Pointer[ServerInfo]
+}
+
+// clientSettings holds the settings that may change while requests are in flight.
+type clientSettings struct {
+	autoRefreshSession atomic.Bool  // Refresh expiring sessions before requests.
+	expiredTimespanMs  atomic.Int64 // How long before expiry a session is refreshed.
+}
+
+// clientLifecycle holds the context shared by a client's requests and the sockets it created,
+// so that Close can abort them all.
+type clientLifecycle struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	adapters []*WebSocketAdapter
+}
+
+// sessionRefresher deduplicates concurrent refreshes of the same session.
+type sessionRefresher struct {
+	mu       sync.Mutex
+	inflight map[*Session]*refreshCall
+	sessions map[string]*Session // The sessions of recent requests by token, to refresh one whose token is rejected.
+}
+
+// maxTrackedSessions bounds sessionRefresher.sessions. Sessions beyond it are not retried on a 401.
+const maxTrackedSessions = 64
+
+// track records the session under its current token.
+func (r *sessionRefresher) track(session *Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sessions == nil {
+		r.sessions = make(map[string]*Session)
+	}
+	current := session.token()
+	if _, ok := r.sessions[current]; !ok && len(r.sessions) >= maxTrackedSessions {
+		now := time.Now().Unix()
+		for token, tracked := range r.sessions {
+			if snapshot := tracked.load(); snapshot.Token != token || snapshot.IsRefreshExpired(now) {
+				delete(r.sessions, token)
+			}
+		}
+		for token := range r.sessions {
+			if len(r.sessions) < maxTrackedSessions {
+				break
+			}
+			delete(r.sessions, token)
+		}
+	}
+	r.sessions[current] = session
+}
+
+// refreshCall is a session refresh in progress, shared by every caller waiting on it.
+type refreshCall struct {
+	done chan struct{}
+	err  error
 }
 
 // NewClient creates a new instance of Client with the specified configuration.
@@ -314,28 +664,307 @@ func NewClient(
 	}
 	basePath := scheme + host + ":" + port
 
-	return &Client{
-		ExpiredTimespanMs:  DefaultExpiredTimespanMs,
-		ApiClient:          &NakamaApi{serverKey, basePath, *timeout},
-		ServerKey:          serverKey,
-		Host:               host,
-		Port:               port,
-		UseSSL:             useSSL,
-		Timeout:            *timeout,
-		AutoRefreshSession: *autoRefreshSession,
+	ctx, cancel := context.WithCancel(context.Background())
+
+	settings := &clientSettings{}
+	settings.autoRefreshSession.Store(*autoRefreshSession)
+	settings.expiredTimespanMs.Store(DefaultExpiredTimespanMs)
+
+	client := &Client{
+		ApiClient:         &NakamaApi{ServerKey: serverKey, BasePath: basePath, TimeoutMs: *timeout, Logger: NoopLogger{}, Context: ctx},
+		ServerKey:         serverKey,
+		Host:              host,
+		Port:              port,
+		UseSSL:            useSSL,
+		Timeout:           *timeout,
+		ValidateUsernames: true,
+		ValidateVars:      true,
+		Logger:            NoopLogger{},
+		Backoff:           DefaultBackoffConfig(),
+		settings:          settings,
+		refresher:         &sessionRefresher{inflight: make(map[*Session]*refreshCall)},
+		clockSkew:         new(atomic.Int64),
+		lifecycle:         &clientLifecycle{ctx: ctx, cancel: cancel},
+		serverInfo:        &serverInfoCache{},
+	}
+	client.ApiClient.OnUnauthorized = client.refreshUnauthorized
+	client.ApiClient.Backoff = &client.Backoff
+	return client
+}
+
+// SetHTTPClient sets the HTTP client used for all requests and for the WebSocket handshake of
+// sockets created afterwards.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.ApiClient.HTTPClient = httpClient
+}
+
+// SetTLSConfig uses the given TLS configuration for all requests, for example to trust a private
+// CA or present a client certificate.
+func (c *Client) SetTLSConfig(config *tls.Config) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	c.SetHTTPClient(&http.Client{Transport: transport})
+}
+
+// SetConnectTimeout limits how long connecting to the server may take, including the TLS handshake,
+// separately from the request timeout, so that an unreachable host fails fast while slow responses
+// still have the full Timeout. It applies to all requests and to the WebSocket handshake of sockets
+// created afterwards.
+func (c *Client) SetConnectTimeout(timeout time.Duration) {
+	httpClient := *c.ApiClient.httpClient()
+	var transport *http.Transport
+	if current, ok := httpClient.Transport.(*http.Transport); ok {
+		transport = current.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = timeout
+	httpClient.Transport = transport
+	c.SetHTTPClient(&httpClient)
+}
+
+// SetInsecureSkipVerify disables verification of the server's TLS certificate.
+// This is unsafe and exposes the connection to interception; only use it for local development.
+func (c *Client) SetInsecureSkipVerify(skip bool) {
+	var config *tls.Config
+	if transport, ok := c.ApiClient.httpClient().Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		config = transport.TLSClientConfig.Clone()
+	} else {
+		config = &tls.Config{}
+	}
+	config.InsecureSkipVerify = skip
+	c.SetTLSConfig(config)
+}
+
+// Close cancels all in-flight requests and closes the sockets created by the client.
+// Subsequent requests fail fast with ErrClientClosed. Close is idempotent.
+func (c *Client) Close() {
+	if c.lifecycle == nil {
+		return
+	}
+
+	c.lifecycle.mu.Lock()
+	c.lifecycle.cancel()
+	adapters := c.lifecycle.adapters
+	c.lifecycle.adapters = nil
+	c.lifecycle.mu.Unlock()
+
+	for _, adapter := range adapters {
+		adapter.Close()
+	}
+}
+
+// SetLogger sets the logger used by the client and its underlying API client.
+func (c *Client) SetLogger(logger Logger) {
+	c.Logger = logger
+	c.ApiClient.Logger = logger
+}
+
+// AutoRefreshSession reports whether expiring sessions are refreshed before requests.
+func (c *Client) AutoRefreshSession() bool {
+	return c.settings.autoRefreshSession.Load()
+}
+
+// SetAutoRefreshSession sets whether expiring sessions are refreshed before requests. It is safe to
+// call while requests are in flight. See WithoutAutoRefresh to skip the refresh for a single call.
+func (c *Client) SetAutoRefreshSession(enabled bool) {
+	c.settings.autoRefreshSession.Store(enabled)
+}
+
+// ExpiredTimespanMs returns how long before its expiry, in milliseconds, a session is refreshed.
+func (c *Client) ExpiredTimespanMs() int64 {
+	return c.settings.expiredTimespanMs.Load()
+}
+
+// SetExpiredTimespanMs sets how long before its expiry, in milliseconds, a session is refreshed.
+// It is safe to call while requests are in flight.
+func (c *Client) SetExpiredTimespanMs(ms int64) {
+	c.settings.expiredTimespanMs.Store(ms)
+}
+
+// WithoutAutoRefresh returns a copy of the client that never refreshes sessions before a request,
+// for a single call made with a session that may have expired:
+//
+//	client.WithoutAutoRefresh().ListFriends(session, nil, nil, nil)
+//
+// The copy shares the client's API client, sockets and Close with the original.
+func (c *Client) WithoutAutoRefresh() *Client {
+	clone := *c
+	clone.settings = &clientSettings{}
+	clone.settings.expiredTimespanMs.Store(c.ExpiredTimespanMs())
+	api := *c.ApiClient
+	api.OnUnauthorized = nil
+	clone.ApiClient = &api
+	return &clone
+}
+
+// WithTimeout returns a copy of the client whose requests time out after timeout instead of the
+// client's Timeout, for a single call that needs a shorter or longer deadline:
+//
+//	client.WithTimeout(30*time.Second).ListLeaderboardRecords(session, id, nil, nil, nil, nil)
+//
+// The timeout and a context set with WithContext both apply, and whichever expires first ends the
+// request. Close aborts requests made through the copy as well.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	clone := *c
+	api := *c.ApiClient
+	api.TimeoutMs = int(timeout.Milliseconds())
+	clone.ApiClient = &api
+	clone.Timeout = api.TimeoutMs
+	return &clone
+}
+
+// WithContext returns a copy of the client whose requests are also bound to ctx, so that cancelling
+// ctx or reaching its deadline aborts them with ctx's error. The client's timeout still applies; see
+// WithTimeout for precedence.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clone := *c
+	api := *c.ApiClient
+	api.callContext = ctx
+	clone.ApiClient = &api
+	return &clone
+}
+
+// ServerInfo returns the server's version and limits, fetched once with the ServerInfoRpcID RPC and
+// the client's HttpKey, and cached by the client and its copies. Once fetched, the client validates
+// against the server's limits instead of its defaults. Cancel the fetch with ctx.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	if c.serverInfo == nil {
+		return nil, errors.New("client was not created with NewClient")
 	}
+	c.serverInfo.mu.Lock()
+	defer c.serverInfo.mu.Unlock()
+	if info := c.serverInfo.info.Load(); info != nil {
+		clone := *info
+		return &clone, nil
+	}
+	if c.HttpKey == "" {
+		return nil, errors.New("fetching server info requires the client's HttpKey")
+	}
+
+	response, err := c.WithContext(ctx).ApiClient.RpcFunc2("", ServerInfoRpcID, nil, &c.HttpKey, make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+	var info ServerInfo
+	if response.Payload != nil {
+		if err := DecodeJSONField(*response.Payload, &info); err != nil {
+			return nil, fmt.Errorf("invalid server info: %w", err)
+		}
+	}
+	if info.MaxUsernameLength <= 0 {
+		info.MaxUsernameLength = MaxUsernameLength
+	}
+	if info.MaxMessageSizeBytes <= 0 {
+		info.MaxMessageSizeBytes = DefaultReadLimit
+	}
+	c.serverInfo.info.Store(&info)
+	clone := info
+	return &clone, nil
+}
+
+// cachedServerInfo returns the ServerInfo fetched by ServerInfo, or nil if it has not been fetched.
+func (c *Client) cachedServerInfo() *ServerInfo {
+	if c.serverInfo == nil {
+		return nil
+	}
+	return c.serverInfo.info.Load()
+}
+
+// validateUsername checks a username against the server's limits if ServerInfo has been fetched, or
+// the defaults otherwise.
+func (c *Client) validateUsername(username *string) error {
+	if info := c.cachedServerInfo(); info != nil {
+		return validateUsername(username, info.MaxUsernameLength)
+	}
+	return ValidateUsername(username)
+}
+
+// WithIdempotencyKey returns a copy of the client whose writes are sent with key as their
+// Idempotency-Key header, so that the server can recognise a logical operation the caller retries
+// itself, for example after a restart:
+//
+//	_, err := client.WithIdempotencyKey(orderID).ValidatePurchaseGoogle(session, &receipt, true)
+//
+// Use a new key for each logical operation. The server, or a proxy in front of it, must honour the
+// header; stock Nakama ignores it. See NakamaApi.MaxRetries for automatic retries.
+func (c *Client) WithIdempotencyKey(key string) *Client {
+	clone := *c
+	api := *c.ApiClient
+	api.idempotencyKey = key
+	clone.ApiClient = &api
+	return &clone
+}
+
+// Batch runs independent calls concurrently, at most MaxBatchParallelism at a time, for example the
+// reads made at startup:
+//
+//	err := client.Batch(ctx,
+//		func(c *Client) (err error) { account, err = c.GetAccount(session); return },
+//		func(c *Client) (err error) { friends, err = c.ListFriends(session, nil, nil, nil); return },
+//	)
+//
+// Each call is given a copy of the client bound to a context shared by the batch. The first call to
+// fail cancels it, aborting the calls in flight and skipping those not yet started, and its error is
+// returned. Calls made with the same *Session share a single refresh when it needs one.
+func (c *Client) Batch(ctx context.Context, calls ...func(client *Client) error) error {
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	client := c.WithContext(batchCtx)
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	slots := make(chan struct{}, max(MaxBatchParallelism, 1))
+	skipped := false
+launch:
+	for _, call := range calls {
+		select {
+		case slots <- struct{}{}:
+		case <-batchCtx.Done():
+			skipped = true
+			break launch
+		}
+		if batchCtx.Err() != nil {
+			<-slots
+			skipped = true
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := call(client); err != nil {
+				once.Do(func() {
+					first = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+	if skipped {
+		return ctx.Err()
+	}
+	return nil
 }
 
 // AddGroupUsers adds users to a group, or accepts their join requests.
 func (c *Client) AddGroupUsers(session *Session, groupId string, ids []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().UnixMilli()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.AddGroupUsers(session.Token, groupId, ids, make(map[string]string))
+	response, err := c.ApiClient.AddGroupUsers(session.token(), groupId, ids, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -345,14 +974,11 @@ func (c *Client) AddGroupUsers(session *Session, groupId string, ids []string) (
 
 // AddFriends adds friends by ID or username to a user's account.
 func (c *Client) AddFriends(session *Session, ids []string, usernames []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().UnixMilli()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.AddFriends(session.Token, ids, usernames, make(map[string]string))
+	response, err := c.ApiClient.AddFriends(session.token(), ids, usernames, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -360,8 +986,38 @@ func (c *Client) AddFriends(session *Session, ids []string, usernames []string)
 	return response != nil, nil
 }
 
+// validateAuthenticate checks the server key, and the username and vars of an authentication request
+// when ValidateUsernames and ValidateVars are set, before it is sent.
+func (c *Client) validateAuthenticate(username *string, vars map[string]string) error {
+	if err := ValidateServerKey(c.ServerKey); err != nil {
+		return err
+	}
+	if c.ValidateUsernames {
+		if err := c.validateUsername(username); err != nil {
+			return err
+		}
+	}
+	if c.ValidateVars {
+		if err := ValidateVars(vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionFromApi converts an authentication response into a Session, with the expiry and user read
+// from its tokens so that it can be checked and refreshed.
+func sessionFromApi(apiSession *ApiSession) *Session {
+	created := apiSession.Created != nil && *apiSession.Created
+	return NewSession(stringValue(apiSession.Token), stringValue(apiSession.RefreshToken), created)
+}
+
 // AuthenticateApple authenticates a user with an Apple ID against the server.
 func (c *Client) AuthenticateApple(token string, create *bool, username *string, vars map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountApple{
 		Token: &token,
@@ -374,16 +1030,17 @@ func (c *Client) AuthenticateApple(token string, create *bool, username *string,
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
-// AuthenticateCustom authenticates a user with a custom ID against the server.
+// AuthenticateCustom authenticates a user with a custom ID against the server. If create is false and
+// no account has the ID, it fails with ErrNotFound rather than creating one. To attach the ID to an
+// existing account instead, see LinkCustomWithConflictResolution.
 func (c *Client) AuthenticateCustom(id string, create *bool, username *string, vars map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountCustom{
 		ID:   &id,
@@ -396,16 +1053,15 @@ func (c *Client) AuthenticateCustom(id string, create *bool, username *string, v
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
 // AuthenticateDevice authenticates a user with a device ID against the server.
 func (c *Client) AuthenticateDevice(id string, create *bool, username *string, vars map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountDevice{
 		ID:   &id,
@@ -418,23 +1074,19 @@ func (c *Client) AuthenticateDevice(id string, create *bool, username *string, v
 		return nil, err
 	}
 
-	created := false
-	if apiSession.Created != nil {
-		created = *apiSession.Created
-	} else if create != nil {
-		created = *create
+	session := sessionFromApi(apiSession)
+	if apiSession.Created == nil && create != nil {
+		session.Created = *create
 	}
-
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      created,
-	}, nil
+	return session, nil
 }
 
 // AuthenticateEmail authenticates a user with an email and password against the server.
 func (c *Client) AuthenticateEmail(email string, password string, create *bool, username *string, vars map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountEmail{
 		Email:    &email,
@@ -448,16 +1100,15 @@ func (c *Client) AuthenticateEmail(email string, password string, create *bool,
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
-// AuthenticateFacebookInstantGame authenticates a user with a Facebook Instant Game token against the server.
+// AuthenticateFacebookInstantGame authenticates a user with the signed player info of a Facebook Instant Game against the server.
 func (c *Client) AuthenticateFacebookInstantGame(signedPlayerInfo string, create *bool, username *string, vars map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountFacebookInstantGame{
 		SignedPlayerInfo: &signedPlayerInfo,
@@ -470,16 +1121,15 @@ func (c *Client) AuthenticateFacebookInstantGame(signedPlayerInfo string, create
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
 // AuthenticateFacebook authenticates a user with a Facebook OAuth token against the server.
 func (c *Client) AuthenticateFacebook(token string, create *bool, username *string, sync *bool, vars map[string]string, options map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountFacebook{
 		Token: &token,
@@ -492,16 +1142,15 @@ func (c *Client) AuthenticateFacebook(token string, create *bool, username *stri
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
 // AuthenticateGoogle authenticates a user with a Google token against the server.
 func (c *Client) AuthenticateGoogle(token string, create *bool, username *string, vars map[string]string, options map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountGoogle{
 		Token: &token,
@@ -514,16 +1163,15 @@ func (c *Client) AuthenticateGoogle(token string, create *bool, username *string
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
 // AuthenticateGameCenter authenticates a user with GameCenter against the server.
 func (c *Client) AuthenticateGameCenter(bundleId string, playerId string, publicKeyUrl string, salt string, signature string, timestamp string, create *bool, username *string, vars map[string]string, options map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountGameCenter{
 		BundleID:     &bundleId,
@@ -541,16 +1189,15 @@ func (c *Client) AuthenticateGameCenter(bundleId string, playerId string, public
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
 }
 
 // AuthenticateSteam authenticates a user with a Steam token against the server.
 func (c *Client) AuthenticateSteam(token string, create *bool, username *string, sync *bool, vars map[string]string) (*Session, error) {
+	if err := c.validateAuthenticate(username, vars); err != nil {
+		return nil, err
+	}
+
 	// Prepare the authentication request
 	request := ApiAccountSteam{
 		Token: &token,
@@ -565,25 +1212,47 @@ func (c *Client) AuthenticateSteam(token string, create *bool, username *string,
 		return nil, err
 	}
 
-	// Return a new Session object
-	return &Session{
-		Token:        *apiSession.Token,
-		RefreshToken: *apiSession.RefreshToken,
-		Created:      *apiSession.Created,
-	}, nil
+	return sessionFromApi(apiSession), nil
+}
+
+// AuthenticateDeviceAndFetch authenticates with a device ID and then fetches the account, the usual
+// startup sequence. Session.Created tells whether the account was just created. If fetching the
+// account fails, the session is still returned alongside the error.
+func (c *Client) AuthenticateDeviceAndFetch(id string, create *bool, username *string, vars map[string]string) (*Session, *ApiAccount, error) {
+	return c.fetchAccount(c.AuthenticateDevice(id, create, username, vars))
+}
+
+// AuthenticateCustomAndFetch authenticates with a custom ID and then fetches the account.
+// See AuthenticateDeviceAndFetch.
+func (c *Client) AuthenticateCustomAndFetch(id string, create *bool, username *string, vars map[string]string) (*Session, *ApiAccount, error) {
+	return c.fetchAccount(c.AuthenticateCustom(id, create, username, vars))
+}
+
+// AuthenticateEmailAndFetch authenticates with an email and password and then fetches the account.
+// See AuthenticateDeviceAndFetch.
+func (c *Client) AuthenticateEmailAndFetch(email string, password string, create *bool, username *string, vars map[string]string) (*Session, *ApiAccount, error) {
+	return c.fetchAccount(c.AuthenticateEmail(email, password, create, username, vars))
+}
+
+// fetchAccount fetches the account of a session returned by an Authenticate method.
+func (c *Client) fetchAccount(session *Session, err error) (*Session, *ApiAccount, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	account, err := c.GetAccount(session)
+	if err != nil {
+		return session, nil, fmt.Errorf("failed to fetch account: %w", err)
+	}
+	return session, account, nil
 }
 
 // BanGroupUsers bans users from a group.
 func (c *Client) BanGroupUsers(session *Session, groupId string, ids []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.BanGroupUsers(session.Token, groupId, ids, make(map[string]string))
+	response, err := c.ApiClient.BanGroupUsers(session.token(), groupId, ids, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -593,15 +1262,11 @@ func (c *Client) BanGroupUsers(session *Session, groupId string, ids []string) (
 
 // BlockFriends blocks one or more users by ID or username.
 func (c *Client) BlockFriends(session *Session, ids []string, usernames []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.BlockFriends(session.Token, ids, usernames, make(map[string]string))
+	response, err := c.ApiClient.BlockFriends(session.token(), ids, usernames, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -612,20 +1277,23 @@ func (c *Client) BlockFriends(session *Session, ids []string, usernames []string
 // CreateGroup creates a new group with the current user as the creator and superadmin.
 func (c *Client) CreateGroup(session *Session, request ApiCreateGroupRequest) (*Group, error) {
 	// Check if the session requires refresh
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	// Call the API client to create the group
-	apiGroup, err := c.ApiClient.CreateGroup(session.Token, request, make(map[string]string))
+	apiGroup, err := c.ApiClient.CreateGroup(session.token(), request, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
 
+	var metadata map[string]interface{}
+	if apiGroup.Metadata != nil {
+		if err := DecodeJSONField(*apiGroup.Metadata, &metadata); err != nil {
+			return nil, err
+		}
+	}
+
 	// Map the response to the Group struct
 	return &Group{
 		AvatarURL:   apiGroup.AvatarURL,
@@ -636,33 +1304,45 @@ func (c *Client) CreateGroup(session *Session, request ApiCreateGroupRequest) (*
 		ID:          apiGroup.ID,
 		LangTag:     apiGroup.LangTag,
 		MaxCount:    apiGroup.MaxCount,
-		Metadata: func() map[string]interface{} {
-			var metadata map[string]interface{}
-			json.Unmarshal([]byte(*apiGroup.Metadata), &metadata)
-			return metadata
-		}(),
-		Name:       apiGroup.Name,
-		Open:       apiGroup.Open,
-		UpdateTime: timeToStringPointer(*apiGroup.UpdateTime, time.RFC3339),
+		Metadata:    metadata,
+		RawMetadata: stringValue(apiGroup.Metadata),
+		Name:        apiGroup.Name,
+		Open:        apiGroup.Open,
+		UpdateTime:  timeToStringPointer(*apiGroup.UpdateTime, time.RFC3339),
 	}, nil
 }
 
-// CreateSocket creates a socket using the client's configuration.
+// CreateSocket creates a socket using the client's configuration. A socket with the default adapter
+// uses the ApiClient's Codec, and accepts messages up to the server's MaxMessageSizeBytes once
+// ServerInfo has been fetched. The socket reconnects with the client's Backoff by default.
 func (c *Client) CreateSocket(useSSL bool, verbose bool, adapter *WebSocketAdapter, sendTimeoutMs *int) DefaultSocket {
-	return NewDefaultSocket(c.Host, c.Port, useSSL, verbose, adapter, sendTimeoutMs)
+	if adapter == nil {
+		adapter = NewWebSocketAdapterText()
+		adapter.Logger = c.Logger
+		adapter.HTTPClient = c.ApiClient.HTTPClient
+		adapter.Codec = c.ApiClient.Codec
+		if info := c.cachedServerInfo(); info != nil {
+			adapter.ReadLimit = info.MaxMessageSizeBytes
+		}
+	}
+	if c.lifecycle != nil {
+		c.lifecycle.mu.Lock()
+		if c.lifecycle.ctx.Err() == nil {
+			c.lifecycle.adapters = append(c.lifecycle.adapters, adapter)
+		}
+		c.lifecycle.mu.Unlock()
+	}
+	socket := NewDefaultSocket(c.Host, c.Port, useSSL, verbose, adapter, sendTimeoutMs)
+	socket.Backoff = c.Backoff
+	return socket
 }
 
 // DeleteAccount deletes the current user's account.
+// The session is never auto refreshed first, so no new token is issued for an account about to be
+// deleted, not even to retry a request rejected with 401. Call EnsureValidSession beforehand if the
+// session token may have expired.
 func (c *Client) DeleteAccount(session *Session) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
-	}
-
-	response, err := c.ApiClient.DeleteAccount(session.Token, make(map[string]string))
+	response, err := c.WithoutAutoRefresh().ApiClient.DeleteAccount(session.token(), make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -672,15 +1352,11 @@ func (c *Client) DeleteAccount(session *Session) (bool, error) {
 
 // DeleteFriends deletes one or more users by ID or username.
 func (c *Client) DeleteFriends(session *Session, ids []string, usernames []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.DeleteFriends(session.Token, ids, usernames, make(map[string]string))
+	response, err := c.ApiClient.DeleteFriends(session.token(), ids, usernames, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -688,35 +1364,110 @@ func (c *Client) DeleteFriends(session *Session, ids []string, usernames []strin
 	return response != nil, nil
 }
 
-// DeleteGroup deletes a group the user is part of and has permissions to delete.
+// DeleteGroup deletes a group the user is a superadmin of. Deletion removes all members and the
+// group's storage and cannot be undone.
+// If the session identifies the user, their role is checked first; a user who is not the group's
+// superadmin, or not a member at all, gets ErrNotGroupAdmin without the delete being attempted. A
+// permission error from the server is reported as ErrNotGroupAdmin too.
 func (c *Client) DeleteGroup(session *Session, groupId string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
+	}
+
+	if userID := sessionUserID(session); userID != "" {
+		role, err := c.GetGroupUserRole(session, groupId, userID)
+		if errors.Is(err, ErrNotGroupMember) {
+			return false, fmt.Errorf("%w: %w", ErrNotGroupAdmin, err)
+		}
 		if err != nil {
 			return false, err
 		}
+		if role != GroupStateSuperadmin {
+			return false, fmt.Errorf("%w: cannot delete group %s", ErrNotGroupAdmin, groupId)
+		}
 	}
 
-	response, err := c.ApiClient.DeleteGroup(session.Token, groupId, make(map[string]string))
+	response, err := c.ApiClient.DeleteGroup(session.token(), groupId, make(map[string]string))
 	if err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			return false, fmt.Errorf("%w: %w", ErrNotGroupAdmin, err)
+		}
 		return false, err
 	}
 
 	return response != nil, nil
 }
 
+// DeleteLeaderboardRecord deletes the current user's record from a leaderboard.
+func (c *Client) DeleteLeaderboardRecord(session *Session, leaderboardId string) (bool, error) {
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
+	}
+
+	if err := c.ApiClient.DeleteLeaderboardRecord(session.token(), leaderboardId, make(map[string]string)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// LeaderboardDeleteError reports the sessions whose leaderboard records could not be deleted.
+type LeaderboardDeleteError struct {
+	Failures map[int]error // Keyed by the index of the session in the request.
+}
+
+// Error implements the error interface.
+func (e *LeaderboardDeleteError) Error() string {
+	return fmt.Sprintf("failed to delete %d leaderboard record(s)", len(e.Failures))
+}
+
+// Unwrap returns the failures in session order, for errors.Is and errors.As.
+func (e *LeaderboardDeleteError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, i := range slices.Sorted(maps.Keys(e.Failures)) {
+		errs = append(errs, e.Failures[i])
+	}
+	return errs
+}
+
+// DeleteLeaderboardRecordsForOwners deletes the leaderboard record of every owner, one call per
+// session. The client API only lets a user delete their own record, so each owner must be
+// represented by its own session. This is meant for small cohorts such as test accounts; large
+// wipes should be done by a server-side RPC instead.
+//
+// Deletion stops when ctx is cancelled, aborting the call in flight, and the remaining sessions are
+// reported as failed with the context error. Any failures are returned as a *LeaderboardDeleteError.
+func (c *Client) DeleteLeaderboardRecordsForOwners(ctx context.Context, sessions []*Session, leaderboardId string) error {
+	client := c.WithContext(ctx)
+	failures := make(map[int]error)
+
+	for i, session := range sessions {
+		if err := ctx.Err(); err != nil {
+			for j := i; j < len(sessions); j++ {
+				failures[j] = err
+			}
+			break
+		}
+
+		if _, err := client.DeleteLeaderboardRecord(session, leaderboardId); err != nil {
+			failures[i] = err
+		}
+	}
+
+	if len(failures) > 0 {
+		return &LeaderboardDeleteError{Failures: failures}
+	}
+
+	return nil
+}
+
 // DeleteNotifications deletes one or more notifications.
 func (c *Client) DeleteNotifications(session *Session, ids []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.DeleteNotifications(session.Token, ids, make(map[string]string))
+	response, err := c.ApiClient.DeleteNotifications(session.token(), ids, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -726,15 +1477,11 @@ func (c *Client) DeleteNotifications(session *Session, ids []string) (bool, erro
 
 // DeleteStorageObjects deletes one or more storage objects.
 func (c *Client) DeleteStorageObjects(session *Session, request ApiDeleteStorageObjectsRequest) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.DeleteStorageObjects(session.Token, request, make(map[string]string))
+	response, err := c.ApiClient.DeleteStorageObjects(session.token(), request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -742,35 +1489,31 @@ func (c *Client) DeleteStorageObjects(session *Session, request ApiDeleteStorage
 	return response != nil, nil
 }
 
-// DeleteTournamentRecord deletes a tournament record.
+// DeleteTournamentRecord deletes the user's record in the tournament's current period, for example to
+// reset their progress. Records of earlier periods are kept. Authoritative tournaments only accept
+// deletions made by the server, and for them a wrapped ErrRecordDeletionNotAllowed is returned.
 func (c *Client) DeleteTournamentRecord(session *Session, tournamentId string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.DeleteTournamentRecord(session.Token, tournamentId, make(map[string]string))
-	if err != nil {
+	if _, err := c.ApiClient.DeleteTournamentRecord(session.token(), tournamentId, make(map[string]string)); err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			return false, fmt.Errorf("%w: %w", ErrRecordDeletionNotAllowed, err)
+		}
 		return false, err
 	}
 
-	return response != nil, nil
+	return true, nil
 }
 
 // DemoteGroupUsers demotes a set of users in a group to the next role down.
 func (c *Client) DemoteGroupUsers(session *Session, groupId string, ids []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.DemoteGroupUsers(session.Token, groupId, ids, make(map[string]string))
+	response, err := c.ApiClient.DemoteGroupUsers(session.token(), groupId, ids, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -780,15 +1523,11 @@ func (c *Client) DemoteGroupUsers(session *Session, groupId string, ids []string
 
 // EmitEvent submits an event for processing in the server's registered runtime custom events handler.
 func (c *Client) EmitEvent(session *Session, request ApiEvent) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.Event(session.Token, request, make(map[string]string))
+	response, err := c.ApiClient.Event(session.token(), request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -796,17 +1535,31 @@ func (c *Client) EmitEvent(session *Session, request ApiEvent) (bool, error) {
 	return response != nil, nil
 }
 
+// Event submits a client-originated analytics event with the given name and properties.
+// The event is timestamped with the current time and marked as external.
+func (c *Client) Event(session *Session, name string, properties map[string]string) error {
+	if err := c.refreshIfNeeded(session); err != nil {
+		return err
+	}
+
+	external := true
+	timestamp := time.Now().UTC()
+	_, err := c.ApiClient.Event(session.token(), ApiEvent{
+		External:   &external,
+		Name:       &name,
+		Properties: properties,
+		Timestamp:  &timestamp,
+	}, make(map[string]string))
+	return err
+}
+
 // GetAccount fetches the current user's account.
 func (c *Client) GetAccount(session *Session) (*ApiAccount, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	account, err := c.ApiClient.GetAccount(session.Token, make(map[string]string))
+	account, err := c.ApiClient.GetAccount(session.token(), make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -814,34 +1567,140 @@ func (c *Client) GetAccount(session *Session) (*ApiAccount, error) {
 	return account, nil
 }
 
-// GetSubscription fetches a subscription by product ID.
-func (c *Client) GetSubscription(session *Session, productId string) (*ApiValidatedSubscription, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
+// AccountExport is the document returned by ExportAccount.
+type AccountExport struct {
+	ExportTime string          `json:"export_time"`
+	Account    *ApiAccount     `json:"account"`
+	Friends    []Friend        `json:"friends"`
+	Groups     []UserGroup     `json:"groups"`
+	Storage    []StorageObject `json:"storage"`
+}
+
+// ExportAccount gathers the current user's data into a single JSON document, for example to answer
+// a data access request. Nakama has no client endpoint for account export, so the document is
+// assembled from the account (including devices and wallet), all friends and blocked users, all group
+// memberships, and the user's storage objects in the given collections. The client API cannot list
+// collections, so storage is only included for the collections named.
+func (c *Client) ExportAccount(session *Session, collections ...string) ([]byte, error) {
+	account, err := c.GetAccount(session)
+	if err != nil {
+		return nil, err
+	}
+	if account.User == nil || account.User.ID == nil {
+		return nil, fmt.Errorf("account has no user ID")
+	}
+	userId := *account.User.ID
+
+	export := AccountExport{
+		ExportTime: c.now().UTC().Format(time.RFC3339),
+		Account:    account,
+		Friends:    []Friend{},
+		Groups:     []UserGroup{},
+		Storage:    []StorageObject{},
+	}
+
+	limit := 100
+	var cursor *string
+	for {
+		friends, err := c.ListFriends(session, nil, &limit, cursor)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to export friends: %w", err)
+		}
+		export.Friends = append(export.Friends, friends.Friends...)
+		if cursor = friends.Cursor; cursor == nil || *cursor == "" {
+			break
 		}
 	}
 
-	subscription, err := c.ApiClient.GetSubscription(session.Token, productId, make(map[string]string))
+	cursor = nil
+	for {
+		groups, err := c.ListUserGroups(session, userId, nil, &limit, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export groups: %w", err)
+		}
+		export.Groups = append(export.Groups, groups.UserGroups...)
+		if cursor = groups.Cursor; cursor == nil || *cursor == "" {
+			break
+		}
+	}
+
+	for _, collection := range collections {
+		cursor = nil
+		for {
+			objects, err := c.ListStorageObjects(session, collection, &userId, &limit, cursor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to export storage collection %s: %w", collection, err)
+			}
+			export.Storage = append(export.Storage, objects.Objects...)
+			if cursor = objects.Cursor; cursor == nil || *cursor == "" {
+				break
+			}
+		}
+	}
+
+	return json.Marshal(export)
+}
+
+// ListLinkedDevices lists the device IDs linked to the current user's account.
+func (c *Client) ListLinkedDevices(session *Session) ([]ApiAccountDevice, error) {
+	account, err := c.GetAccount(session)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.Devices == nil {
+		return []ApiAccountDevice{}, nil
+	}
+	return account.Devices, nil
+}
+
+// GetSubscription fetches a subscription by product ID.
+func (c *Client) GetSubscription(session *Session, productId string) (*ApiValidatedSubscription, error) {
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	subscription, err := c.ApiClient.GetSubscription(session.token(), productId, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
 	return &subscription, nil
 }
 
+// IsSubscriptionActive reports whether the user currently holds an active subscription to the product.
+// The expiry time is also checked against the current time to catch expiries the server has not reconciled yet.
+// It returns false without an error when no subscription exists.
+func (c *Client) IsSubscriptionActive(session *Session, productId string) (bool, error) {
+	subscription, err := c.GetSubscription(session, productId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return subscriptionActiveAt(subscription, time.Now()), nil
+}
+
+// subscriptionActiveAt reports whether the subscription is marked active and has not expired at the given time.
+func subscriptionActiveAt(subscription *ApiValidatedSubscription, now time.Time) bool {
+	if subscription == nil || subscription.Active == nil || !*subscription.Active {
+		return false
+	}
+	if subscription.ExpiryTime != nil && *subscription.ExpiryTime != "" {
+		expiry, err := time.Parse(time.RFC3339, *subscription.ExpiryTime)
+		if err == nil && !expiry.After(now) {
+			return false
+		}
+	}
+	return true
+}
+
 // ImportFacebookFriends imports Facebook friends and adds them to a user's account.
 func (c *Client) ImportFacebookFriends(session *Session, request ApiAccountFacebook) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.ImportFacebookFriends(session.Token, request, false, make(map[string]string))
+	response, err := c.ApiClient.ImportFacebookFriends(session.token(), request, false, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -851,15 +1710,11 @@ func (c *Client) ImportFacebookFriends(session *Session, request ApiAccountFaceb
 
 // ImportSteamFriends imports Steam friends and adds them to a user's account.
 func (c *Client) ImportSteamFriends(session *Session, request ApiAccountSteam, reset bool) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.ImportSteamFriends(session.Token, request, reset, make(map[string]string))
+	response, err := c.ApiClient.ImportSteamFriends(session.token(), request, reset, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -867,70 +1722,127 @@ func (c *Client) ImportSteamFriends(session *Session, request ApiAccountSteam, r
 	return response != nil, nil
 }
 
+// MaxUsersPerRequest is the largest number of IDs, usernames and Facebook IDs combined that FetchUsers
+// sends in a single request. Larger lookups are split across requests to stay within URL length limits.
+const MaxUsersPerRequest = 100
+
 // FetchUsers fetches zero or more users by ID and/or username.
+// Large lookups are split into requests of at most MaxUsersPerRequest identifiers, and users
+// matched by more than one identifier are returned once.
 func (c *Client) FetchUsers(session *Session, ids []string, usernames []string, facebookIds []string) (*Users, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	apiResponse, err := c.ApiClient.GetUsers(session.Token, ids, usernames, facebookIds, make(map[string]string))
-	if err != nil {
+	if err := c.refreshIfNeeded(session); err != nil {
 		return nil, err
 	}
 
 	result := &Users{
 		Users: []User{},
 	}
+	seen := make(map[string]bool)
 
-	if apiResponse.Users == nil {
-		return result, nil
-	}
+	for len(ids)+len(usernames)+len(facebookIds) > 0 {
+		// Fill the next chunk from ids, then usernames, then Facebook IDs.
+		remaining := MaxUsersPerRequest
+		take := func(values []string) ([]string, []string) {
+			n := min(len(values), remaining)
+			remaining -= n
+			return values[:n], values[n:]
+		}
+		var chunkIds, chunkUsernames, chunkFacebookIds []string
+		chunkIds, ids = take(ids)
+		chunkUsernames, usernames = take(usernames)
+		chunkFacebookIds, facebookIds = take(facebookIds)
 
-	for _, u := range *apiResponse.Users {
-		user := User{
-			AvatarURL:    u.AvatarURL,
-			CreateTime:   timeToStringPointer(*u.CreateTime, time.RFC3339),
-			DisplayName:  u.DisplayName,
-			EdgeCount:    u.EdgeCount,
-			FacebookID:   u.FacebookID,
-			GameCenterID: u.GameCenterID,
-			GoogleID:     u.GoogleID,
-			ID:           u.ID,
-			LangTag:      u.LangTag,
-			Location:     u.Location,
-			Online:       u.Online,
-			SteamID:      u.SteamID,
-			Timezone:     u.Timezone,
-			UpdateTime:   timeToStringPointer(*u.UpdateTime, time.RFC3339),
-			Username:     u.Username,
-			Metadata:     nil,
-		}
-		if u.Metadata != nil {
-			if err := json.Unmarshal([]byte(*u.Metadata), &user.Metadata); err != nil {
+		apiResponse, err := c.ApiClient.GetUsers(session.token(), chunkIds, chunkUsernames, chunkFacebookIds, make(map[string]string))
+		if err != nil {
+			return nil, err
+		}
+		if apiResponse.Users == nil {
+			continue
+		}
+
+		for _, u := range *apiResponse.Users {
+			if u.ID != nil {
+				if seen[*u.ID] {
+					continue
+				}
+				seen[*u.ID] = true
+			}
+			user, err := userFromApi(u)
+			if err != nil {
 				return nil, err
 			}
+			result.Users = append(result.Users, *user)
 		}
-		result.Users = append(result.Users, user)
 	}
 
 	return result, nil
 }
 
+// GetUserById fetches a single user by ID, returning ErrUserNotFound if there is no such user.
+func (c *Client) GetUserById(session *Session, id string) (*User, error) {
+	users, err := c.FetchUsers(session, []string{id}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(users.Users) == 0 {
+		return nil, fmt.Errorf("%w: id %s", ErrUserNotFound, id)
+	}
+	return &users.Users[0], nil
+}
+
+// GetUserByUsername fetches a single user by username, returning ErrUserNotFound if there is no such
+// user.
+func (c *Client) GetUserByUsername(session *Session, username string) (*User, error) {
+	users, err := c.FetchUsers(session, nil, []string{username}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(users.Users) == 0 {
+		return nil, fmt.Errorf("%w: username %s", ErrUserNotFound, username)
+	}
+	return &users.Users[0], nil
+}
+
+// userFromApi converts an ApiUser into a User, decoding its metadata.
+func userFromApi(u ApiUser) (*User, error) {
+	user := &User{
+		AvatarURL:    u.AvatarURL,
+		DisplayName:  u.DisplayName,
+		EdgeCount:    u.EdgeCount,
+		FacebookID:   u.FacebookID,
+		GameCenterID: u.GameCenterID,
+		GoogleID:     u.GoogleID,
+		ID:           u.ID,
+		LangTag:      u.LangTag,
+		Location:     u.Location,
+		Online:       u.Online,
+		SteamID:      u.SteamID,
+		Timezone:     u.Timezone,
+		Username:     u.Username,
+		Metadata:     nil,
+	}
+	if u.CreateTime != nil {
+		user.CreateTime = timeToStringPointer(*u.CreateTime, time.RFC3339)
+	}
+	if u.UpdateTime != nil {
+		user.UpdateTime = timeToStringPointer(*u.UpdateTime, time.RFC3339)
+	}
+	if u.Metadata != nil {
+		if err := DecodeJSONField(*u.Metadata, &user.Metadata); err != nil {
+			return nil, err
+		}
+		user.RawMetadata = *u.Metadata
+	}
+	return user, nil
+}
+
 // JoinGroup either joins a group that's open or sends a request to join a group that's closed.
 func (c *Client) JoinGroup(session *Session, groupId string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.JoinGroup(session.Token, groupId, make(map[string]string))
+	response, err := c.ApiClient.JoinGroup(session.token(), groupId, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -940,15 +1852,11 @@ func (c *Client) JoinGroup(session *Session, groupId string) (bool, error) {
 
 // JoinTournament allows a user to join a tournament by its ID.
 func (c *Client) JoinTournament(session *Session, tournamentId string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.JoinTournament(session.Token, tournamentId, make(map[string]string))
+	response, err := c.ApiClient.JoinTournament(session.token(), tournamentId, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -958,15 +1866,11 @@ func (c *Client) JoinTournament(session *Session, tournamentId string) (bool, er
 
 // KickGroupUsers kicks users from a group or declines their join requests.
 func (c *Client) KickGroupUsers(session *Session, groupId string, ids []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.KickGroupUsers(session.Token, groupId, ids, make(map[string]string))
+	response, err := c.ApiClient.KickGroupUsers(session.token(), groupId, ids, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -976,15 +1880,11 @@ func (c *Client) KickGroupUsers(session *Session, groupId string, ids []string)
 
 // LeaveGroup allows a user to leave a group they are part of.
 func (c *Client) LeaveGroup(session *Session, groupId string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LeaveGroup(session.Token, groupId, make(map[string]string))
+	response, err := c.ApiClient.LeaveGroup(session.token(), groupId, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -994,15 +1894,16 @@ func (c *Client) LeaveGroup(session *Session, groupId string) (bool, error) {
 
 // ListChannelMessages retrieves a channel's message history.
 func (c *Client) ListChannelMessages(session *Session, channelId string, limit *int, forward *bool, cursor *string) (*ChannelMessageList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
 	}
 
-	apiResponse, err := c.ApiClient.ListChannelMessages(session.Token, channelId, limit, forward, cursor, make(map[string]string))
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	apiResponse, err := c.ApiClient.ListChannelMessages(session.token(), channelId, limit, forward, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1035,9 +1936,10 @@ func (c *Client) ListChannelMessages(session *Session, channelId string, limit *
 			UserIDTwo:  m.UserIDTwo,
 		}
 		if m.Content != nil {
-			if err := json.Unmarshal([]byte(*m.Content), &message.Content); err != nil {
+			if err := DecodeJSONField(*m.Content, &message.Content); err != nil {
 				return nil, err
 			}
+			message.RawContent = *m.Content
 		}
 
 		result.Messages = append(result.Messages, message)
@@ -1047,16 +1949,18 @@ func (c *Client) ListChannelMessages(session *Session, channelId string, limit *
 }
 
 // ListGroupUsers retrieves a group's users with optional state, limit, and cursor parameters.
+// The state filter takes one of the GroupState constants.
 func (c *Client) ListGroupUsers(session *Session, groupId string, state *int, limit *int, cursor *string) (*GroupUserList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
 	}
 
-	apiResponse, err := c.ApiClient.ListGroupUsers(session.Token, groupId, state, limit, cursor, make(map[string]string))
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	apiResponse, err := c.ApiClient.ListGroupUsers(session.token(), groupId, state, limit, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1094,9 +1998,10 @@ func (c *Client) ListGroupUsers(session *Session, groupId string, state *int, li
 		}
 
 		if gu.User.Metadata != nil {
-			if err := json.Unmarshal([]byte(*gu.User.Metadata), &groupUser.User.Metadata); err != nil {
+			if err := DecodeJSONField(*gu.User.Metadata, &groupUser.User.Metadata); err != nil {
 				return nil, err
 			}
+			groupUser.User.RawMetadata = *gu.User.Metadata
 		}
 
 		result.GroupUsers = append(result.GroupUsers, groupUser)
@@ -1105,17 +2010,49 @@ func (c *Client) ListGroupUsers(session *Session, groupId string, state *int, li
 	return result, nil
 }
 
-// ListUserGroups lists a user's groups.
-func (c *Client) ListUserGroups(session *Session, userId string, state *int, limit *int, cursor *string) (*UserGroupList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
+// GetGroupUserRole resolves a user's current membership state in a group, for example after a
+// promote or demote. It returns one of the GroupState constants, or ErrNotGroupMember if the user is
+// not in the group.
+func (c *Client) GetGroupUserRole(session *Session, groupId string, userId string) (int, error) {
+	limit := 100
+	var cursor *string
+	for {
+		list, err := c.ListGroupUsers(session, groupId, nil, &limit, cursor)
 		if err != nil {
-			return nil, err
+			return -1, err
 		}
+
+		for _, groupUser := range list.GroupUsers {
+			if groupUser.User == nil || groupUser.User.ID == nil || *groupUser.User.ID != userId {
+				continue
+			}
+			// The server omits zero values, so a missing state is a superadmin.
+			if groupUser.State == nil {
+				return GroupStateSuperadmin, nil
+			}
+			return *groupUser.State, nil
+		}
+
+		if list.Cursor == nil || *list.Cursor == "" {
+			return -1, fmt.Errorf("%w: user %s, group %s", ErrNotGroupMember, userId, groupId)
+		}
+		cursor = list.Cursor
 	}
+}
 
-	apiResponse, err := c.ApiClient.ListUserGroups(session.Token, userId, state, limit, cursor, make(map[string]string))
+// ListUserGroups lists a user's groups.
+// The state filter takes one of the GroupState constants.
+func (c *Client) ListUserGroups(session *Session, userId string, state *int, limit *int, cursor *string) (*UserGroupList, error) {
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	apiResponse, err := c.ApiClient.ListUserGroups(session.token(), userId, state, limit, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1149,9 +2086,10 @@ func (c *Client) ListUserGroups(session *Session, userId string, state *int, lim
 		}
 
 		if ug.Group.Metadata != nil {
-			if err := json.Unmarshal([]byte(*ug.Group.Metadata), &userGroup.Group.Metadata); err != nil {
+			if err := DecodeJSONField(*ug.Group.Metadata, &userGroup.Group.Metadata); err != nil {
 				return nil, err
 			}
+			userGroup.Group.RawMetadata = *ug.Group.Metadata
 		}
 
 		result.UserGroups = append(result.UserGroups, userGroup)
@@ -1160,17 +2098,21 @@ func (c *Client) ListUserGroups(session *Session, userId string, state *int, lim
 	return result, nil
 }
 
-// ListGroups retrieves a list of groups based on the given filters.
-func (c *Client) ListGroups(session *Session, name *string, cursor *string, limit *int) (*GroupList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+// ListGroups retrieves a list of groups based on the given filters. Nil filters are not applied.
+// The name matches a group name exactly, or as a prefix when it ends with "%". The server does not
+// combine a name filter with the langTag, members and open filters. Members filters groups by
+// their member count.
+func (c *Client) ListGroups(session *Session, name *string, cursor *string, limit *int, langTag *string, members *int, open *bool) (*GroupList, error) {
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
 	}
 
-	apiResponse, err := c.ApiClient.ListGroups(session.Token, name, cursor, limit, nil, nil, nil, make(map[string]string))
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	apiResponse, err := c.ApiClient.ListGroups(session.token(), name, cursor, limit, langTag, members, open, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1205,9 +2147,10 @@ func (c *Client) ListGroups(session *Session, name *string, cursor *string, limi
 			group.EdgeCount = ug.EdgeCount
 		}
 		if ug.Metadata != nil {
-			if err := json.Unmarshal([]byte(*ug.Metadata), &group.Metadata); err != nil {
+			if err := DecodeJSONField(*ug.Metadata, &group.Metadata); err != nil {
 				return nil, err
 			}
+			group.RawMetadata = *ug.Metadata
 		}
 
 		result.Groups = append(result.Groups, group)
@@ -1216,19 +2159,23 @@ func (c *Client) ListGroups(session *Session, name *string, cursor *string, limi
 	return result, nil
 }
 
+// linkError reports an ID that is already linked to another account as ErrLinkConflict.
+func linkError(err error) error {
+	if errors.Is(err, ErrAlreadyExists) {
+		return fmt.Errorf("%w: %w", ErrLinkConflict, err)
+	}
+	return err
+}
+
 // LinkApple adds an Apple ID to the social profiles on the current user's account.
 func (c *Client) LinkApple(session *Session, request *ApiAccountApple) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkApple(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkApple(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1236,35 +2183,49 @@ func (c *Client) LinkApple(session *Session, request *ApiAccountApple) (bool, er
 
 // LinkCustom adds a custom ID to the social profiles on the current user's account.
 func (c *Client) LinkCustom(session *Session, request *ApiAccountCustom) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkCustom(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkCustom(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
 }
 
+// LinkCustomWithConflictResolution links a custom ID to the current user's account and returns the
+// session to continue with. The server never merges accounts: if the ID already belongs to another
+// account, this authenticates as that account, without creating one, and passes its session to resolve.
+// resolve can move data between the accounts as the game requires and returns the session to keep,
+// typically existing. A nil resolve returns ErrLinkConflict instead.
+func (c *Client) LinkCustomWithConflictResolution(session *Session, request *ApiAccountCustom, resolve func(existing *Session) (*Session, error)) (*Session, error) {
+	_, err := c.LinkCustom(session, request)
+	if err == nil {
+		return session, nil
+	}
+	if !errors.Is(err, ErrLinkConflict) || resolve == nil || request.ID == nil {
+		return nil, err
+	}
+
+	create := false
+	existing, authErr := c.AuthenticateCustom(*request.ID, &create, nil, request.Vars)
+	if authErr != nil {
+		return nil, fmt.Errorf("failed to authenticate as the account linked to the custom ID: %w", authErr)
+	}
+	return resolve(existing)
+}
+
 // LinkDevice adds a device ID to the social profiles on the current user's account.
 func (c *Client) LinkDevice(session *Session, request *ApiAccountDevice) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkDevice(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkDevice(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1272,17 +2233,13 @@ func (c *Client) LinkDevice(session *Session, request *ApiAccountDevice) (bool,
 
 // LinkEmail adds an email and password to the social profiles on the current user's account.
 func (c *Client) LinkEmail(session *Session, request *ApiAccountEmail) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkEmail(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkEmail(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1290,17 +2247,13 @@ func (c *Client) LinkEmail(session *Session, request *ApiAccountEmail) (bool, er
 
 // LinkFacebook adds a Facebook ID to the social profiles on the current user's account.
 func (c *Client) LinkFacebook(session *Session, request *ApiAccountFacebook) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkFacebook(session.Token, *request, nil, make(map[string]string))
+	response, err := c.ApiClient.LinkFacebook(session.token(), *request, nil, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1308,17 +2261,13 @@ func (c *Client) LinkFacebook(session *Session, request *ApiAccountFacebook) (bo
 
 // LinkFacebookInstant adds Facebook Instant to the social profiles on the current user's account.
 func (c *Client) LinkFacebookInstant(session *Session, request *ApiAccountFacebookInstantGame) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkFacebookInstantGame(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkFacebookInstantGame(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1326,17 +2275,13 @@ func (c *Client) LinkFacebookInstant(session *Session, request *ApiAccountFacebo
 
 // LinkGoogle adds a Google account to the social profiles on the current user's account.
 func (c *Client) LinkGoogle(session *Session, request *ApiAccountGoogle) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkGoogle(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkGoogle(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1344,17 +2289,13 @@ func (c *Client) LinkGoogle(session *Session, request *ApiAccountGoogle) (bool,
 
 // LinkGameCenter adds GameCenter to the social profiles on the current user's account.
 func (c *Client) LinkGameCenter(session *Session, request *ApiAccountGameCenter) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkGameCenter(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkGameCenter(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
@@ -1362,33 +2303,60 @@ func (c *Client) LinkGameCenter(session *Session, request *ApiAccountGameCenter)
 
 // LinkSteam adds Steam to the social profiles on the current user's account.
 func (c *Client) LinkSteam(session *Session, request *ApiLinkSteamRequest) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.LinkSteam(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.LinkSteam(session.token(), *request, make(map[string]string))
 	if err != nil {
-		return false, err
+		return false, linkError(err)
 	}
 
 	return response != nil, nil
 }
 
+// ListFriendsWithPresence lists the current user's friends of every state with their online status.
+// The server omits offline users' Online field and mutual friends' State, so both are always set
+// here. Online reflects the moment of the request; follow the users over a socket for live updates.
+// A limit of 0 and an empty cursor use the server defaults. Use Friends.ByState to split the result.
+func (c *Client) ListFriendsWithPresence(session *Session, limit int, cursor string) (*Friends, error) {
+	var limitParam *int
+	if limit > 0 {
+		limitParam = &limit
+	}
+
+	friends, err := c.ListFriends(session, nil, limitParam, &cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range friends.Friends {
+		friend := &friends.Friends[i]
+		if friend.State == nil {
+			state := FriendStateMutual
+			friend.State = &state
+		}
+		if friend.User != nil && friend.User.Online == nil {
+			online := false
+			friend.User.Online = &online
+		}
+	}
+	return friends, nil
+}
+
 // ListFriends lists all friends for the current user.
+// The state filter takes one of the FriendState constants.
 func (c *Client) ListFriends(session *Session, state *int, limit *int, cursor *string) (*Friends, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ListFriends(session.Token, limit, state, cursor, make(map[string]string))
+	response, err := c.ApiClient.ListFriends(session.token(), limit, state, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1427,9 +2395,10 @@ func (c *Client) ListFriends(session *Session, state *int, limit *int, cursor *s
 		}
 
 		if f.User.Metadata != nil {
-			if err := json.Unmarshal([]byte(*f.User.Metadata), &friend.User.Metadata); err != nil {
+			if err := DecodeJSONField(*f.User.Metadata, &friend.User.Metadata); err != nil {
 				return nil, err
 			}
+			friend.User.RawMetadata = *f.User.Metadata
 		}
 
 		result.Friends = append(result.Friends, friend)
@@ -1440,15 +2409,16 @@ func (c *Client) ListFriends(session *Session, state *int, limit *int, cursor *s
 
 // ListFriendsOfFriends lists the friends of friends for the current user.
 func (c *Client) ListFriendsOfFriends(session *Session, limit *int, cursor *string) (*FriendsOfFriends, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ListFriendsOfFriends(session.Token, limit, cursor, make(map[string]string))
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	response, err := c.ApiClient.ListFriendsOfFriends(session.token(), limit, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1487,9 +2457,10 @@ func (c *Client) ListFriendsOfFriends(session *Session, limit *int, cursor *stri
 		}
 
 		if f.User.Metadata != nil {
-			if err := json.Unmarshal([]byte(*f.User.Metadata), &friendOfFriend.User.Metadata); err != nil {
+			if err := DecodeJSONField(*f.User.Metadata, &friendOfFriend.User.Metadata); err != nil {
 				return nil, err
 			}
+			friendOfFriend.User.RawMetadata = *f.User.Metadata
 		}
 
 		result.FriendsOfFriends = append(result.FriendsOfFriends, friendOfFriend)
@@ -1500,15 +2471,21 @@ func (c *Client) ListFriendsOfFriends(session *Session, limit *int, cursor *stri
 
 // ListLeaderboardRecords lists the leaderboard records with optional ownerIds, pagination, and expiry filters.
 func (c *Client) ListLeaderboardRecords(session *Session, leaderboardId string, ownerIds []string, limit *int, cursor *string, expiry *string) (*LeaderboardRecordList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ListLeaderboardRecords(session.Token, leaderboardId, ownerIds, limit, cursor, expiry, make(map[string]string))
+	response, err := c.ApiClient.ListLeaderboardRecords(session.token(), leaderboardId, ownerIds, limit, cursor, expiry, make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+
+	rankCount, err := rankCountFromApi(response.RankCount)
 	if err != nil {
 		return nil, err
 	}
@@ -1516,73 +2493,89 @@ func (c *Client) ListLeaderboardRecords(session *Session, leaderboardId string,
 	list := &LeaderboardRecordList{
 		NextCursor:   response.NextCursor,
 		PrevCursor:   response.PrevCursor,
-		RankCount:    stringPointerToIntPointer(response.RankCount),
+		RankCount:    rankCount,
 		OwnerRecords: []LeaderboardRecord{},
 		Records:      []LeaderboardRecord{},
 	}
 
 	if response.OwnerRecords != nil {
 		for _, o := range response.OwnerRecords {
-			metadata := map[string]interface{}{}
-			if o.Metadata != nil {
-				if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err != nil {
-					return nil, err
-				}
+			record, err := leaderboardRecordFromApi(o)
+			if err != nil {
+				return nil, err
 			}
-
-			list.OwnerRecords = append(list.OwnerRecords, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*o.ExpiryTime, time.RFC3339),
-				LeaderboardID: o.LeaderboardID,
-				Metadata:      metadata,
-				NumScore:      o.NumScore,
-				OwnerID:       o.OwnerID,
-				Rank:          stringPointerToIntPointer(o.Rank),
-				Score:         stringPointerToIntPointer(o.Score),
-				SubScore:      stringPointerToIntPointer(o.Subscore),
-				UpdateTime:    timeToStringPointer(*o.UpdateTime, time.RFC3339),
-				Username:      o.Username,
-				MaxNumScore:   o.MaxNumScore,
-			})
+			list.OwnerRecords = append(list.OwnerRecords, *record)
 		}
 	}
 
 	if response.Records != nil {
 		for _, o := range response.Records {
-			metadata := map[string]interface{}{}
-			if o.Metadata != nil {
-				if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err != nil {
-					return nil, err
-				}
+			record, err := leaderboardRecordFromApi(o)
+			if err != nil {
+				return nil, err
 			}
-			list.Records = append(list.Records, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*o.ExpiryTime, time.RFC3339),
-				LeaderboardID: o.LeaderboardID,
-				Metadata:      metadata,
-				NumScore:      o.NumScore,
-				OwnerID:       o.OwnerID,
-				Rank:          stringPointerToIntPointer(o.Rank),
-				Score:         stringPointerToIntPointer(o.Score),
-				SubScore:      stringPointerToIntPointer(o.Subscore),
-				UpdateTime:    timeToStringPointer(*o.UpdateTime, time.RFC3339),
-				Username:      o.Username,
-				MaxNumScore:   o.MaxNumScore,
-			})
+			list.Records = append(list.Records, *record)
 		}
 	}
 
 	return list, nil
 }
 
-func (c *Client) ListLeaderboardRecordsAroundOwner(session *Session, leaderboardId string, ownerId string, limit *int, expiry *string, cursor *string) (*LeaderboardRecordList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
+// ListLeaderboardRecordsFromRank lists a page of up to limit leaderboard records starting at the given
+// 1-based rank, for "jump to rank" navigation. The server only pages by opaque cursors, so this seeks
+// by following NextCursor through pages of limit records until it reaches the page holding rank, and
+// returns that page without the records ranked before it. Its cursors continue paging from there.
+//
+// Seeking costs about rank/limit requests, so use the largest limit the UI allows for deep ranks.
+// The result is approximate: records that change rank while seeking can shift the page, and records
+// with tied scores may start slightly before or after the requested rank. An empty page is returned
+// when the leaderboard has fewer records than rank.
+func (c *Client) ListLeaderboardRecordsFromRank(session *Session, leaderboardId string, rank int64, limit int, expiry *string) (*LeaderboardRecordList, error) {
+	if rank < 1 {
+		return nil, fmt.Errorf("rank must be at least 1, got %d", rank)
+	}
+
+	var cursor *string
+	for {
+		page, err := c.ListLeaderboardRecords(session, leaderboardId, nil, &limit, cursor, expiry)
 		if err != nil {
 			return nil, err
 		}
+		if page.RankCount != nil && int64(*page.RankCount) < rank {
+			page.Records = []LeaderboardRecord{}
+			page.NextCursor = nil
+			return page, nil
+		}
+
+		last := len(page.Records) - 1
+		if last >= 0 && page.Records[last].Rank != nil && *page.Records[last].Rank < rank && page.NextCursor != nil {
+			cursor = page.NextCursor
+			continue
+		}
+
+		page.Records = slices.DeleteFunc(page.Records, func(record LeaderboardRecord) bool {
+			return record.Rank != nil && *record.Rank < rank
+		})
+		return page, nil
 	}
+}
 
-	response, err := c.ApiClient.ListLeaderboardRecordsAroundOwner(session.Token, leaderboardId, ownerId, limit, expiry, cursor, make(map[string]string))
+func (c *Client) ListLeaderboardRecordsAroundOwner(session *Session, leaderboardId string, ownerId string, limit *int, expiry *string, cursor *string) (*LeaderboardRecordList, error) {
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	response, err := c.ApiClient.ListLeaderboardRecordsAroundOwner(session.token(), leaderboardId, ownerId, limit, expiry, cursor, make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+
+	rankCount, err := rankCountFromApi(response.RankCount)
 	if err != nil {
 		return nil, err
 	}
@@ -1590,56 +2583,28 @@ func (c *Client) ListLeaderboardRecordsAroundOwner(session *Session, leaderboard
 	list := &LeaderboardRecordList{
 		NextCursor:   response.NextCursor,
 		PrevCursor:   response.PrevCursor,
-		RankCount:    stringPointerToIntPointer(response.RankCount),
+		RankCount:    rankCount,
 		OwnerRecords: []LeaderboardRecord{},
 		Records:      []LeaderboardRecord{},
 	}
 
 	if response.OwnerRecords != nil {
 		for _, o := range response.OwnerRecords {
-			metadata := map[string]interface{}{}
-			if o.Metadata != nil {
-				if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err != nil {
-					return nil, err
-				}
+			record, err := leaderboardRecordFromApi(o)
+			if err != nil {
+				return nil, err
 			}
-			list.OwnerRecords = append(list.OwnerRecords, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*o.ExpiryTime, time.RFC3339),
-				LeaderboardID: o.LeaderboardID,
-				Metadata:      metadata,
-				NumScore:      o.NumScore,
-				OwnerID:       o.OwnerID,
-				Rank:          stringPointerToIntPointer(o.Rank),
-				Score:         stringPointerToIntPointer(o.Score),
-				SubScore:      stringPointerToIntPointer(o.Subscore),
-				UpdateTime:    timeToStringPointer(*o.UpdateTime, time.RFC3339),
-				Username:      o.Username,
-				MaxNumScore:   o.MaxNumScore,
-			})
+			list.OwnerRecords = append(list.OwnerRecords, *record)
 		}
 	}
 
 	if response.Records != nil {
 		for _, o := range response.Records {
-			metadata := map[string]interface{}{}
-			if o.Metadata != nil {
-				if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err != nil {
-					return nil, err
-				}
+			record, err := leaderboardRecordFromApi(o)
+			if err != nil {
+				return nil, err
 			}
-			list.Records = append(list.Records, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*o.ExpiryTime, time.RFC3339),
-				LeaderboardID: o.LeaderboardID,
-				Metadata:      metadata,
-				NumScore:      o.NumScore,
-				OwnerID:       o.OwnerID,
-				Rank:          stringPointerToIntPointer(o.Rank),
-				Score:         stringPointerToIntPointer(o.Score),
-				SubScore:      stringPointerToIntPointer(o.Subscore),
-				UpdateTime:    timeToStringPointer(*o.UpdateTime, time.RFC3339),
-				Username:      o.Username,
-				MaxNumScore:   o.MaxNumScore,
-			})
+			list.Records = append(list.Records, *record)
 		}
 	}
 
@@ -1647,16 +2612,14 @@ func (c *Client) ListLeaderboardRecordsAroundOwner(session *Session, leaderboard
 }
 
 // ListMatches fetches a list of running matches.
+// The label filter matches labels exactly, while query searches JSON labels of authoritative matches
+// using the server's query syntax, for example "+label.mode:ranked".
 func (c *Client) ListMatches(session *Session, limit *int, authoritative *bool, label *string, minSize *int, maxSize *int, query *string) (*ApiMatchList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ListMatches(session.Token, limit, authoritative, label, minSize, maxSize, query, make(map[string]string))
+	response, err := c.ApiClient.ListMatches(session.token(), limit, authoritative, label, minSize, maxSize, query, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1665,15 +2628,16 @@ func (c *Client) ListMatches(session *Session, limit *int, authoritative *bool,
 
 // ListNotifications fetches a list of notifications.
 func (c *Client) ListNotifications(session *Session, limit *int, cacheableCursor *string) (*NotificationList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cacheableCursor, err := normalizeCursor(cacheableCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ListNotifications(session.Token, limit, cacheableCursor, make(map[string]string))
+	response, err := c.ApiClient.ListNotifications(session.token(), limit, cacheableCursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1688,38 +2652,99 @@ func (c *Client) ListNotifications(session *Session, limit *int, cacheableCursor
 	}
 
 	for _, n := range response.Notifications {
-		var content map[string]interface{}
-		if n.Content != nil {
-			if err := json.Unmarshal([]byte(*n.Content), &content); err != nil {
-				return nil, err
-			}
+		notification, err := notificationFromApi(n, c.ApiClient.Codec)
+		if err != nil {
+			return nil, err
 		}
-
-		result.Notifications = append(result.Notifications, Notification{
-			Code:       n.Code,
-			CreateTime: timeToStringPointer(*n.CreateTime, time.RFC3339),
-			ID:         n.ID,
-			Persistent: n.Persistent,
-			SenderID:   n.SenderID,
-			Subject:    n.Subject,
-			Content:    content,
-		})
+		result.Notifications = append(result.Notifications, *notification)
 	}
 
 	return result, nil
 }
 
-// ListStorageObjects retrieves a list of storage objects.
-func (c *Client) ListStorageObjects(session *Session, collection string, userID *string, limit *int, cursor *string) (*StorageObjectList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
+// ListNewNotifications lists up to limit notifications created after sinceCursor, the CacheableCursor
+// of a previous list, for polling. An empty sinceCursor lists from the oldest notification. The returned
+// CacheableCursor is always set once a cursor is known, even when there is nothing new, so pass it to
+// the next poll to advance. Cursors mark a point in time rather than a page, so an old cursor does not
+// go stale: it lists everything newer that has not been deleted since.
+func (c *Client) ListNewNotifications(session *Session, sinceCursor string, limit int) (*NotificationList, error) {
+	var cursor *string
+	if sinceCursor != "" {
+		cursor = &sinceCursor
+	}
+
+	list, err := c.ListNotifications(session, &limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+	if stringValue(list.CacheableCursor) == "" && cursor != nil {
+		list.CacheableCursor = cursor
+	}
+	return list, nil
+}
+
+// notificationFromApi converts an ApiNotification into a Notification, decoding its content. codec is
+// kept for DecodeContent.
+func notificationFromApi(n ApiNotification, codec Codec) (*Notification, error) {
+	notification := &Notification{
+		Code:       n.Code,
+		ID:         n.ID,
+		Persistent: n.Persistent,
+		SenderID:   n.SenderID,
+		Subject:    n.Subject,
+		codec:      codec,
+	}
+	if n.CreateTime != nil {
+		notification.CreateTime = timeToStringPointer(*n.CreateTime, time.RFC3339)
+	}
+	if n.Content != nil {
+		if err := DecodeJSONField(*n.Content, &notification.Content); err != nil {
 			return nil, err
 		}
+		notification.RawContent = *n.Content
+	}
+	return notification, nil
+}
+
+// notificationCountPageSize is the page size CountNotifications lists with, the largest the server allows.
+const notificationCountPageSize = 100
+
+// CountNotifications returns how many notifications the user has, for example for a badge. The
+// server keeps notifications until they are deleted and has no count or read state, so this lists
+// them all, one request per 100 notifications, and stops at the first short page. Keep the count up
+// to date by listing only newer notifications with the cacheable cursor of ListNotifications instead
+// of calling this repeatedly.
+func (c *Client) CountNotifications(session *Session) (int, error) {
+	limit := notificationCountPageSize
+	var cursor *string
+	count := 0
+	for {
+		list, err := c.ListNotifications(session, &limit, cursor)
+		if err != nil {
+			return 0, err
+		}
+		count += len(list.Notifications)
+
+		next := stringValue(list.CacheableCursor)
+		if len(list.Notifications) < limit || next == "" || next == stringValue(cursor) {
+			return count, nil
+		}
+		cursor = &next
 	}
+}
 
-	response, err := c.ApiClient.ListStorageObjects(session.Token, collection, userID, limit, cursor, make(map[string]string))
+// ListStorageObjects retrieves a list of storage objects.
+func (c *Client) ListStorageObjects(session *Session, collection string, userID *string, limit *int, cursor *string) (*StorageObjectList, error) {
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	response, err := c.ApiClient.ListStorageObjects(session.token(), collection, userID, limit, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1736,7 +2761,7 @@ func (c *Client) ListStorageObjects(session *Session, collection string, userID
 	for _, o := range response.Objects {
 		var value interface{}
 		if o.Value != nil {
-			if err := json.Unmarshal([]byte(*o.Value), &value); err != nil {
+			if err := DecodeJSONField(*o.Value, &value); err != nil {
 				return nil, err
 			}
 		}
@@ -1748,15 +2773,15 @@ func (c *Client) ListStorageObjects(session *Session, collection string, userID
 				if o.PermissionRead != nil {
 					return o.PermissionRead
 				} else {
-					defaultValue := 0
+					defaultValue := PermissionReadNoAccess
 					return &defaultValue
 				}
 			}(),
 			PermissionWrite: func() *int {
-				if o.PermissionRead != nil {
+				if o.PermissionWrite != nil {
 					return o.PermissionWrite
 				} else {
-					defaultValue := 0
+					defaultValue := PermissionWriteNoAccess
 					return &defaultValue
 				}
 			}(),
@@ -1766,6 +2791,7 @@ func (c *Client) ListStorageObjects(session *Session, collection string, userID
 				}
 				return nil
 			}(),
+			RawValue:   stringValue(o.Value),
 			Version:    o.Version,
 			UserID:     o.UserID,
 			CreateTime: timeToStringPointer(*o.CreateTime, time.RFC3339),
@@ -1776,17 +2802,32 @@ func (c *Client) ListStorageObjects(session *Session, collection string, userID
 	return result, nil
 }
 
+// ListActiveTournaments lists a page of tournaments and keeps those whose active window includes the
+// current time, for a "current tournaments" view. The server has no filter for joined or active
+// tournaments, so the page is filtered locally and may hold fewer than limit tournaments; keep paging
+// with the cursor. Tournaments that cannot be entered by new players (CanEnter false, for example
+// because they are full) are kept, as the user may already have joined them.
+func (c *Client) ListActiveTournaments(session *Session, categoryStart *int, categoryEnd *int, limit *int, cursor *string) (*TournamentList, error) {
+	list, err := c.ListTournaments(session, categoryStart, categoryEnd, nil, nil, limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+	list.Tournaments = FilterActiveTournaments(list.Tournaments, c.now())
+	return list, nil
+}
+
 // ListTournaments retrieves a list of current or upcoming tournaments.
 func (c *Client) ListTournaments(session *Session, categoryStart *int, categoryEnd *int, startTime *int64, endTime *int64, limit *int, cursor *string) (*TournamentList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		_, err := c.SessionRefresh(session, nil)
-		if err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ListTournaments(session.Token, categoryStart, categoryEnd, startTime, endTime, limit, cursor, make(map[string]string))
+	response, err := c.ApiClient.ListTournaments(session.token(), categoryStart, categoryEnd, startTime, endTime, limit, cursor, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -1803,7 +2844,7 @@ func (c *Client) ListTournaments(session *Session, categoryStart *int, categoryE
 	for _, o := range response.Tournaments {
 		var metadata map[string]interface{}
 		if o.Metadata != nil {
-			if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err != nil {
+			if err := DecodeJSONField(*o.Metadata, &metadata); err != nil {
 				return nil, err
 			}
 		}
@@ -1872,10 +2913,12 @@ func (c *Client) ListTournaments(session *Session, categoryStart *int, categoryE
 				return &defaultValue
 			}(),
 			Metadata:      metadata,
+			RawMetadata:   stringValue(o.Metadata),
 			CreateTime:    timeToStringPointer(*o.CreateTime, time.RFC3339),
 			StartTime:     timeToStringPointer(*o.StartTime, time.RFC3339),
 			EndTime:       timeToStringPointer(*o.EndTime, time.RFC3339),
 			StartActive:   int64PointerToIntPointer(o.StartActive),
+			PrevReset:     int64PointerToIntPointer(o.PrevReset),
 			Authoritative: o.Authoritative,
 		})
 	}
@@ -1885,14 +2928,17 @@ func (c *Client) ListTournaments(session *Session, categoryStart *int, categoryE
 
 // ListSubscriptions lists user subscriptions.
 func (c *Client) ListSubscriptions(session *Session, cursor *string, limit *int) (*SubscriptionList, error) {
-	if c.AutoRefreshSession && session.IsExpired(time.Now().Unix()+c.ExpiredTimespanMs/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	apiSubscriptionList, err := c.ApiClient.ListSubscriptions(
-		session.Token, ApiListSubscriptionsRequest{
+		session.token(), ApiListSubscriptionsRequest{
 			Cursor: cursor,
 			Limit:  limit,
 		},
@@ -1940,16 +2986,19 @@ func (c *Client) ListTournamentRecords(
 	cursor *string,
 	expiry *string,
 ) (*TournamentRecordList, error) {
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
 	// Refresh the session if auto-refresh is enabled and the session is expired.
-	if c.AutoRefreshSession && session.IsExpired(time.Now().Unix()+c.ExpiredTimespanMs/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	// Call the API to list tournament records.
 	apiTournamentRecordList, err := c.ApiClient.ListTournamentRecords(
-		session.Token,
+		session.token(),
 		tournamentId,
 		ownerIds,
 		limit,
@@ -1961,10 +3010,16 @@ func (c *Client) ListTournamentRecords(
 		return nil, err
 	}
 
+	rankCount, err := rankCountFromApi(apiTournamentRecordList.RankCount)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare the response object.
 	list := &TournamentRecordList{
 		NextCursor:   apiTournamentRecordList.NextCursor,
 		PrevCursor:   apiTournamentRecordList.PrevCursor,
+		RankCount:    rankCount,
 		OwnerRecords: []LeaderboardRecord{},
 		Records:      []LeaderboardRecord{},
 	}
@@ -1972,56 +3027,22 @@ func (c *Client) ListTournamentRecords(
 	// Process owner records.
 	if apiTournamentRecordList.OwnerRecords != nil {
 		for _, o := range apiTournamentRecordList.OwnerRecords {
-			list.OwnerRecords = append(list.OwnerRecords, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*o.ExpiryTime, time.RFC3339),
-				LeaderboardID: o.LeaderboardID,
-				Metadata: func() map[string]interface{} {
-					if o.Metadata == nil {
-						return nil
-					}
-					var metadata map[string]interface{}
-					if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err == nil {
-						return metadata
-					}
-					return nil
-				}(),
-				NumScore:    o.NumScore,
-				OwnerID:     o.OwnerID,
-				Rank:        stringPointerToIntPointer(o.Rank),
-				Score:       stringPointerToIntPointer(o.Score),
-				SubScore:    stringPointerToIntPointer(o.Subscore),
-				UpdateTime:  timeToStringPointer(*o.UpdateTime, time.RFC3339),
-				Username:    o.Username,
-				MaxNumScore: o.MaxNumScore,
-			})
+			record, err := leaderboardRecordFromApi(o)
+			if err != nil {
+				return nil, err
+			}
+			list.OwnerRecords = append(list.OwnerRecords, *record)
 		}
 	}
 
 	// Process records.
 	if apiTournamentRecordList.Records != nil {
 		for _, r := range apiTournamentRecordList.Records {
-			list.Records = append(list.Records, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*r.ExpiryTime, time.RFC3339),
-				LeaderboardID: r.LeaderboardID,
-				Metadata: func() map[string]interface{} {
-					if r.Metadata == nil {
-						return nil
-					}
-					var metadata map[string]interface{}
-					if err := json.Unmarshal([]byte(*r.Metadata), &metadata); err == nil {
-						return metadata
-					}
-					return nil
-				}(),
-				NumScore:    r.NumScore,
-				OwnerID:     r.OwnerID,
-				Rank:        stringPointerToIntPointer(r.Rank),
-				Score:       stringPointerToIntPointer(r.Score),
-				SubScore:    stringPointerToIntPointer(r.Subscore),
-				UpdateTime:  timeToStringPointer(*r.UpdateTime, time.RFC3339),
-				Username:    r.Username,
-				MaxNumScore: r.MaxNumScore,
-			})
+			record, err := leaderboardRecordFromApi(r)
+			if err != nil {
+				return nil, err
+			}
+			list.Records = append(list.Records, *record)
 		}
 	}
 
@@ -2037,16 +3058,18 @@ func (c *Client) ListTournamentRecordsAroundOwner(
 	expiry *string,
 	cursor *string,
 ) (*TournamentRecordList, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	cursor, err := normalizeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	// Call the API to get tournament records around owner.
 	apiTournamentRecordList, err := c.ApiClient.ListTournamentRecordsAroundOwner(
-		session.Token,
+		session.token(),
 		tournamentId,
 		ownerId,
 		limit,
@@ -2058,10 +3081,16 @@ func (c *Client) ListTournamentRecordsAroundOwner(
 		return nil, err
 	}
 
+	rankCount, err := rankCountFromApi(apiTournamentRecordList.RankCount)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare the response object.
 	list := &TournamentRecordList{
 		NextCursor:   apiTournamentRecordList.NextCursor,
 		PrevCursor:   apiTournamentRecordList.PrevCursor,
+		RankCount:    rankCount,
 		OwnerRecords: []LeaderboardRecord{},
 		Records:      []LeaderboardRecord{},
 	}
@@ -2069,56 +3098,22 @@ func (c *Client) ListTournamentRecordsAroundOwner(
 	// Process owner records.
 	if apiTournamentRecordList.OwnerRecords != nil {
 		for _, o := range apiTournamentRecordList.OwnerRecords {
-			list.OwnerRecords = append(list.OwnerRecords, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*o.ExpiryTime, time.RFC3339),
-				LeaderboardID: o.LeaderboardID,
-				Metadata: func() map[string]interface{} {
-					if o.Metadata == nil {
-						return nil
-					}
-					var metadata map[string]interface{}
-					if err := json.Unmarshal([]byte(*o.Metadata), &metadata); err == nil {
-						return metadata
-					}
-					return nil
-				}(),
-				NumScore:    o.NumScore,
-				OwnerID:     o.OwnerID,
-				Rank:        stringPointerToIntPointer(o.Rank),
-				Score:       stringPointerToIntPointer(o.Score),
-				SubScore:    stringPointerToIntPointer(o.Subscore),
-				UpdateTime:  timeToStringPointer(*o.UpdateTime, time.RFC3339),
-				Username:    o.Username,
-				MaxNumScore: o.MaxNumScore,
-			})
+			record, err := leaderboardRecordFromApi(o)
+			if err != nil {
+				return nil, err
+			}
+			list.OwnerRecords = append(list.OwnerRecords, *record)
 		}
 	}
 
 	// Process records.
 	if apiTournamentRecordList.Records != nil {
 		for _, r := range apiTournamentRecordList.Records {
-			list.Records = append(list.Records, LeaderboardRecord{
-				ExpiryTime:    timeToStringPointer(*r.ExpiryTime, time.RFC3339),
-				LeaderboardID: r.LeaderboardID,
-				Metadata: func() map[string]interface{} {
-					if r.Metadata == nil {
-						return nil
-					}
-					var metadata map[string]interface{}
-					if err := json.Unmarshal([]byte(*r.Metadata), &metadata); err == nil {
-						return metadata
-					}
-					return nil
-				}(),
-				NumScore:    r.NumScore,
-				OwnerID:     r.OwnerID,
-				Rank:        stringPointerToIntPointer(r.Rank),
-				Score:       stringPointerToIntPointer(r.Score),
-				SubScore:    stringPointerToIntPointer(r.Subscore),
-				UpdateTime:  timeToStringPointer(*r.UpdateTime, time.RFC3339),
-				Username:    r.Username,
-				MaxNumScore: r.MaxNumScore,
-			})
+			record, err := leaderboardRecordFromApi(r)
+			if err != nil {
+				return nil, err
+			}
+			list.Records = append(list.Records, *record)
 		}
 	}
 
@@ -2127,30 +3122,43 @@ func (c *Client) ListTournamentRecordsAroundOwner(
 
 // PromoteGroupUsers promotes the users in a group to the next role up.
 func (c *Client) PromoteGroupUsers(session *Session, groupId string, ids []string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	success, err := c.ApiClient.PromoteGroupUsers(session.Token, groupId, ids, make(map[string]string))
+	success, err := c.ApiClient.PromoteGroupUsers(session.token(), groupId, ids, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
 	return success.(bool), nil
 }
 
-// ReadStorageObjects fetches storage objects.
+// ReadStorageObject fetches a single storage object owned by userId, returning ErrStorageObjectNotFound
+// if it does not exist or cannot be read by the user.
+func (c *Client) ReadStorageObject(session *Session, collection, key, userId string) (*StorageObject, error) {
+	objectId := ApiReadStorageObjectId{Collection: &collection, Key: &key}
+	if userId != "" {
+		objectId.UserID = &userId
+	}
+
+	objects, err := c.ReadStorageObjects(session, &ApiReadStorageObjectsRequest{ObjectIDs: []ApiReadStorageObjectId{objectId}})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects.Objects) == 0 {
+		return nil, fmt.Errorf("%w: %s/%s", ErrStorageObjectNotFound, collection, key)
+	}
+	return &objects.Objects[0], nil
+}
+
+// ReadStorageObjects fetches storage objects. With a StorageCache set, the value of an object whose
+// version matches the cached one is taken from the cache instead of being decoded again.
 func (c *Client) ReadStorageObjects(session *Session, request *ApiReadStorageObjectsRequest) (*StorageObjects, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	apiResponse, err := c.ApiClient.ReadStorageObjects(session.Token, *request, make(map[string]string))
+	apiResponse, err := c.ApiClient.ReadStorageObjects(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -2162,6 +3170,11 @@ func (c *Client) ReadStorageObjects(session *Session, request *ApiReadStorageObj
 	}
 
 	for _, o := range apiResponse.Objects {
+		value, err := c.storageValue(o)
+		if err != nil {
+			return nil, err
+		}
+
 		result.Objects = append(result.Objects, StorageObject{
 			Collection: o.Collection,
 			Key:        o.Key,
@@ -2171,16 +3184,8 @@ func (c *Client) ReadStorageObjects(session *Session, request *ApiReadStorageObj
 			PermissionWrite: func() *int {
 				return o.PermissionWrite
 			}(),
-			Value: func() map[string]interface{} {
-				if o.Value == nil {
-					return nil
-				}
-				var value map[string]interface{}
-				if err := json.Unmarshal([]byte(*o.Value), &value); err == nil {
-					return value
-				}
-				return nil
-			}(),
+			Value:      value,
+			RawValue:   stringValue(o.Value),
 			Version:    o.Version,
 			UserID:     o.UserID,
 			CreateTime: timeToStringPointer(*o.CreateTime, time.RFC3339),
@@ -2191,14 +3196,40 @@ func (c *Client) ReadStorageObjects(session *Session, request *ApiReadStorageObj
 	return result, nil
 }
 
-// Rpc executes an RPC function on the server.
-func (c *Client) Rpc(session *Session, id string, input map[string]interface{}) (*RpcResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
+// storageValue decodes the value of a storage object, using the StorageCache if one is set. The
+// server has no conditional reads, so the value is always transferred; the cache only saves decoding.
+func (c *Client) storageValue(o ApiStorageObject) (map[string]interface{}, error) {
+	if c.StorageCache == nil || o.Version == nil {
+		var value map[string]interface{}
+		if o.Value != nil {
+			if err := DecodeJSONField(*o.Value, &value); err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	}
+
+	key := StorageCacheKey{Collection: stringValue(o.Collection), Key: stringValue(o.Key), UserID: stringValue(o.UserID)}
+	if cached, ok := c.StorageCache.Get(key); ok && stringValue(cached.Version) == *o.Version {
+		return cloneJSONObject(cached.Value), nil
+	}
+
+	var value map[string]interface{}
+	if o.Value != nil {
+		if err := DecodeJSONField(*o.Value, &value); err != nil {
+			c.StorageCache.Remove(key)
 			return nil, err
 		}
 	}
+	c.StorageCache.Put(key, StorageObject{Collection: o.Collection, Key: o.Key, UserID: o.UserID, Version: o.Version, Value: cloneJSONObject(value)})
+	return value, nil
+}
+
+// Rpc executes an RPC function on the server. A nil session calls it with the ApiClient's AdminToken.
+func (c *Client) Rpc(session *Session, id string, input map[string]interface{}) (*RpcResponse, error) {
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
 
 	// Serialize the input to JSON
 	inputJson, err := json.Marshal(input)
@@ -2207,7 +3238,7 @@ func (c *Client) Rpc(session *Session, id string, input map[string]interface{})
 	}
 
 	// Execute the RPC function on the API client
-	apiResponse, err := c.ApiClient.RpcFunc(session.Token, id, string(inputJson), nil, make(map[string]string))
+	apiResponse, err := c.ApiClient.RpcFunc(sessionToken(session), id, string(inputJson), nil, make(map[string]string))
 	if err != nil {
 		return nil, err
 	}
@@ -2219,8 +3250,9 @@ func (c *Client) Rpc(session *Session, id string, input map[string]interface{})
 			if apiResponse.Payload == nil {
 				return nil
 			}
+			// RPC payloads need not be JSON objects, so a payload that does not decode is dropped.
 			var parsedPayload map[string]interface{}
-			if err := json.Unmarshal([]byte(*apiResponse.Payload), &parsedPayload); err == nil {
+			if err := DecodeJSONField(*apiResponse.Payload, &parsedPayload); err == nil {
 				return parsedPayload
 			}
 			return nil
@@ -2230,6 +3262,33 @@ func (c *Client) Rpc(session *Session, id string, input map[string]interface{})
 	return rpcResponse, nil
 }
 
+// RpcTyped executes an RPC function on the server with input encoded as JSON, and decodes the response
+// payload into a T. It is a function rather than a method of Client, as methods cannot be generic. A
+// nil session calls it with the ApiClient's AdminToken.
+func RpcTyped[T any](c *Client, session *Session, id string, input any) (*T, error) {
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
+	}
+
+	inputJson, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize input to JSON: %w", err)
+	}
+
+	apiResponse, err := c.ApiClient.RpcFunc(sessionToken(session), id, string(inputJson), nil, make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+	if apiResponse.Payload != nil {
+		if err := DecodeJSONField(*apiResponse.Payload, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode rpc payload: %w", err)
+		}
+	}
+	return &result, nil
+}
+
 // RpcHttpKey executes an RPC function on the server using an HTTP key.
 func (c *Client) RpcHttpKey(httpKey, id string, input map[string]interface{}) (*RpcResponse, error) {
 	// Serialize the input to JSON
@@ -2255,8 +3314,9 @@ func (c *Client) RpcHttpKey(httpKey, id string, input map[string]interface{}) (*
 			if apiResponse.Payload == nil {
 				return nil
 			}
+			// RPC payloads need not be JSON objects, so a payload that does not decode is dropped.
 			var parsedPayload map[string]interface{}
-			if err := json.Unmarshal([]byte(*apiResponse.Payload), &parsedPayload); err == nil {
+			if err := DecodeJSONField(*apiResponse.Payload, &parsedPayload); err == nil {
 				return parsedPayload
 			}
 			return nil
@@ -2267,22 +3327,18 @@ func (c *Client) RpcHttpKey(httpKey, id string, input map[string]interface{}) (*
 }
 
 // SessionLogout logs out a session, invalidates a refresh token, or logs out all sessions/refresh tokens for a user.
+// The session is never auto refreshed first, so no new token is issued right before it is invalidated,
+// not even to retry a request rejected with 401. Call EnsureValidSession beforehand if the session
+// token may have expired.
 func (c *Client) SessionLogout(session *Session, token, refreshToken string) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
-	}
-
 	// Create request payload for logout
 	logoutRequest := ApiSessionLogoutRequest{
 		Token:        &token,
 		RefreshToken: &refreshToken,
 	}
 
-	// Call the API client's session logout function
-	response, err := c.ApiClient.SessionLogout(session.Token, logoutRequest, make(map[string]string))
+	// Call the API client's session logout function, without refreshing the session on a 401
+	response, err := c.WithoutAutoRefresh().ApiClient.SessionLogout(session.token(), logoutRequest, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2290,43 +3346,305 @@ func (c *Client) SessionLogout(session *Session, token, refreshToken string) (bo
 	return response != nil, nil
 }
 
+// SessionFromToken returns a session for a token and refresh token minted elsewhere, for example by
+// a custom backend that authenticates users server-side and hands the tokens to the client, so that
+// the client can be used without an Authenticate call. The refresh token may be empty. Tokens that are
+// not well-formed fail with ErrInvalidToken. An expired token fails with ErrSessionExpired unless it
+// can be refreshed, which happens before the first request when auto refresh is on. The signatures
+// are not verified; the server rejects forged tokens on use.
+func (c *Client) SessionFromToken(token, refreshToken string) (*Session, error) {
+	if _, err := ParseToken(token); err != nil {
+		return nil, err
+	}
+	if refreshToken != "" {
+		if _, err := ParseToken(refreshToken); err != nil {
+			return nil, fmt.Errorf("refresh token: %w", err)
+		}
+	}
+
+	now := c.now().Unix()
+	session := &Session{CreatedAt: now}
+	if err := session.Update(token, refreshToken); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	refreshable := session.RefreshToken != "" && !session.IsRefreshExpired(now) && c.AutoRefreshSession()
+	if session.IsExpired(now) && !refreshable {
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
+
 // SessionRefresh refreshes a user's session using a refresh token retrieved from a previous authentication request.
 func (c *Client) SessionRefresh(session *Session, vars map[string]string) (*Session, error) {
 	if session == nil {
 		return nil, fmt.Errorf("cannot refresh a null session")
 	}
+	if c.ValidateVars {
+		if err := ValidateVars(vars); err != nil {
+			return nil, err
+		}
+	}
 
-	if session.ExpiresAt != nil && *session.ExpiresAt-session.CreatedAt < 70 {
-		log.Println("Session lifetime too short, please set '--session.token_expiry_sec' option. See the documentation for more info: https://heroiclabs.com/docs/nakama/getting-started/configuration/#session")
+	current := session.load()
+	if current.ExpiresAt != nil && *current.ExpiresAt-current.CreatedAt < 70 {
+		loggerOrNoop(c.Logger).Warn("Session lifetime too short, please set '--session.token_expiry_sec' option. See the documentation for more info: https://heroiclabs.com/docs/nakama/getting-started/configuration/#session")
 	}
 
-	if session.RefreshExpiresAt != nil && *session.RefreshExpiresAt-session.CreatedAt < 3700 {
-		log.Println("Session refresh lifetime too short, please set '--session.refresh_token_expiry_sec' option. See the documentation for more info: https://heroiclabs.com/docs/nakama/getting-started/configuration/#session")
+	if current.RefreshExpiresAt != nil && *current.RefreshExpiresAt-current.CreatedAt < 3700 {
+		loggerOrNoop(c.Logger).Warn("Session refresh lifetime too short, please set '--session.refresh_token_expiry_sec' option. See the documentation for more info: https://heroiclabs.com/docs/nakama/getting-started/configuration/#session")
 	}
 
-	apiSession, err := c.ApiClient.SessionRefresh(c.ServerKey, "", ApiSessionRefreshRequest{
-		Token: &session.RefreshToken,
+	request := ApiSessionRefreshRequest{
+		Token: &current.RefreshToken,
 		Vars:  vars,
-	}, make(map[string]string))
+	}
+	var apiSession *ApiSession
+	var err error
+	for attempt := 1; ; attempt++ {
+		apiSession, err = c.ApiClient.SessionRefresh(c.ServerKey, "", request, make(map[string]string))
+		if err == nil || attempt >= MaxRefreshAttempts || !refreshRetryable(err) {
+			break
+		}
+		loggerOrNoop(c.Logger).Debug("Session refresh failed, retrying", "attempt", attempt, "error", err)
+		if waitErr := c.ApiClient.wait(c.Backoff.Delay(attempt - 1)); waitErr != nil {
+			return nil, waitErr
+		}
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	session.Update(*apiSession.Token, *apiSession.RefreshToken)
+	if apiSession.Token == nil {
+		return nil, fmt.Errorf("session refresh response has no token")
+	}
+
+	// Apply the new tokens to a copy so a malformed response leaves the session untouched. The server
+	// omits the refresh token when rotation is disabled, and Update then keeps the current one.
+	updated := current
+	if err := updated.Update(*apiSession.Token, stringValue(apiSession.RefreshToken)); err != nil {
+		return nil, err
+	}
+	session.store(updated)
 	return session, nil
 }
 
+// refreshRetryable reports whether a failed refresh may be tried again: it failed with a 502, 503 or
+// 504, or without reaching the server, and was not aborted.
+func refreshRetryable(err error) bool {
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// UpdateSessionVars replaces the session's vars without re-authenticating, by refreshing the session
+// with vars that the server embeds in the new token. It requires a valid refresh token, and on failure
+// the session is left unchanged. The server keeps the current vars when vars is empty, so they cannot
+// be cleared this way.
+func (c *Client) UpdateSessionVars(session *Session, vars map[string]string) error {
+	if session == nil {
+		return fmt.Errorf("cannot update the vars of a null session")
+	}
+	if current := session.load(); current.RefreshToken == "" || current.IsRefreshExpired(time.Now().Unix()) {
+		return fmt.Errorf("cannot update session vars without a valid refresh token")
+	}
+
+	_, err := c.SessionRefresh(session, vars)
+	return err
+}
+
+// EnsureValidSession refreshes the session if it has expired or expires within ExpiredTimespanMs,
+// and is a no-op otherwise. Concurrent calls for the same session share a single refresh request.
+// The session is replaced under a lock, so the client's requests and sockets may use it meanwhile.
+// On failure the error is returned and the session is left unchanged.
+func (c *Client) EnsureValidSession(session *Session) error {
+	if session == nil {
+		return fmt.Errorf("cannot refresh a null session")
+	}
+	return c.refreshShared(session, c.needsRefresh)
+}
+
+// refreshShared refreshes the session if stale reports that it needs it, sharing a refresh that is
+// already in progress for the session.
+func (c *Client) refreshShared(session *Session, stale func(*Session) bool) error {
+	r := c.refresher
+	if r == nil {
+		if current := session.load(); !stale(&current) {
+			return nil
+		}
+		_, err := c.SessionRefresh(session, nil)
+		return err
+	}
+
+	r.mu.Lock()
+	if call, ok := r.inflight[session]; ok {
+		r.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+	updated := session.load()
+	if !stale(&updated) {
+		r.mu.Unlock()
+		return nil
+	}
+	if updated.RefreshToken == "" {
+		r.mu.Unlock()
+		return fmt.Errorf("cannot refresh a session without a refresh token")
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	r.inflight[session] = call
+	r.mu.Unlock()
+
+	// Refresh a copy so waiting callers never observe a partially updated session.
+	_, call.err = c.SessionRefresh(&updated, nil)
+
+	r.mu.Lock()
+	if call.err == nil {
+		if token := session.token(); r.sessions[token] == session {
+			delete(r.sessions, token)
+			r.sessions[updated.Token] = session
+		}
+		session.store(updated)
+	}
+	delete(r.inflight, session)
+	r.mu.Unlock()
+	close(call.done)
+
+	return call.err
+}
+
+// needsRefresh reports whether the session has expired or expires within ExpiredTimespanMs.
+func (c *Client) needsRefresh(session *Session) bool {
+	return session.IsExpired((c.now().UnixMilli() + c.ExpiredTimespanMs()) / 1000)
+}
+
+// Ping measures the HTTP round trip to the server's healthcheck endpoint, for example to show latency
+// or pick the nearest region. It needs no session. The first request to a server also opens the
+// connection, so ping again for the steady-state latency.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if _, err := c.WithContext(ctx).ApiClient.Healthcheck("", make(map[string]string)); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// ServerTime fetches the server's current time and records the skew between the server clock and
+// the local clock. When CorrectClockSkew is set, session expiry checks are offset by that skew.
+func (c *Client) ServerTime(ctx context.Context) (time.Time, error) {
+	start := time.Now()
+	serverTime, err := c.WithContext(ctx).ApiClient.ServerTime("", make(map[string]string))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Compare against the midpoint of the round trip to discount network latency.
+	local := start.Add(time.Since(start) / 2)
+	if c.clockSkew != nil {
+		c.clockSkew.Store(int64(serverTime.Sub(local)))
+	}
+	return serverTime, nil
+}
+
+// ClockSkew returns the skew between the server clock and the local clock last observed by ServerTime.
+// A positive value means the server clock is ahead.
+func (c *Client) ClockSkew() time.Duration {
+	if c.clockSkew == nil {
+		return 0
+	}
+	return time.Duration(c.clockSkew.Load())
+}
+
+// now returns the current time, corrected for clock skew when CorrectClockSkew is set.
+func (c *Client) now() time.Time {
+	if c.CorrectClockSkew {
+		return time.Now().Add(c.ClockSkew())
+	}
+	return time.Now()
+}
+
+// refreshIfNeeded refreshes the session before a request when auto refresh is enabled, and keeps the
+// session so that it can be refreshed again if the server rejects its token. An expired session that
+// cannot be refreshed fails with ErrSessionExpired without making the request.
+func (c *Client) refreshIfNeeded(session *Session) error {
+	if session == nil || !c.AutoRefreshSession() {
+		return nil
+	}
+	current := session.load()
+	if now := c.now().Unix(); current.RefreshToken == "" || current.IsRefreshExpired(now) {
+		if current.IsExpired(now) {
+			return ErrSessionExpired
+		}
+		return nil
+	}
+	if err := c.EnsureValidSession(session); err != nil {
+		return err
+	}
+	if c.refresher != nil {
+		c.refresher.track(session)
+	}
+	return nil
+}
+
+// sessionToken returns the token of a session, or "" for a nil session so that the ApiClient's
+// AdminToken is used.
+func sessionToken(session *Session) string {
+	if session == nil {
+		return ""
+	}
+	return session.token()
+}
+
+// sessionUserID returns the user ID of a session, read from its token if the session does not carry
+// it, as a session built by hand may not.
+func sessionUserID(session *Session) string {
+	current := session.load()
+	if current.UserID != nil {
+		return *current.UserID
+	}
+	if claims, err := ParseToken(current.Token); err == nil {
+		return claims.UserID
+	}
+	return ""
+}
+
+// refreshUnauthorized is the ApiClient's OnUnauthorized hook. The server can reject a token that
+// looked valid before the request, if it expired in flight or the clocks disagree, so this refreshes
+// the session the token belongs to, sharing the refresh with concurrent callers, and returns the new
+// token for a single retry.
+func (c *Client) refreshUnauthorized(token string) (string, error) {
+	r := c.refresher
+	if r == nil || !c.AutoRefreshSession() {
+		return "", errors.New("session auto refresh is disabled")
+	}
+
+	r.mu.Lock()
+	session := r.sessions[token]
+	r.mu.Unlock()
+	if session == nil {
+		return "", errors.New("no session for the rejected token")
+	}
+
+	if err := c.refreshShared(session, func(s *Session) bool { return s.Token == token }); err != nil {
+		return "", err
+	}
+	return session.token(), nil
+}
+
 // UnlinkApple removes the Apple ID from the social profiles on the current user's account.
 func (c *Client) UnlinkApple(session *Session, request *ApiAccountApple) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkApple(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkApple(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2336,14 +3654,11 @@ func (c *Client) UnlinkApple(session *Session, request *ApiAccountApple) (bool,
 
 // UnlinkCustom removes a custom ID from the social profiles on the current user's account.
 func (c *Client) UnlinkCustom(session *Session, request *ApiAccountCustom) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkCustom(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkCustom(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2352,15 +3667,13 @@ func (c *Client) UnlinkCustom(session *Session, request *ApiAccountCustom) (bool
 }
 
 // UnlinkDevice removes a device ID from the social profiles on the current user's account.
+// The server refuses to remove the last login method, and the returned error carries its message.
 func (c *Client) UnlinkDevice(session *Session, request *ApiAccountDevice) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkDevice(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkDevice(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2370,14 +3683,11 @@ func (c *Client) UnlinkDevice(session *Session, request *ApiAccountDevice) (bool
 
 // UnlinkEmail removes an email+password from the social profiles on the current user's account.
 func (c *Client) UnlinkEmail(session *Session, request *ApiAccountEmail) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkEmail(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkEmail(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2387,14 +3697,11 @@ func (c *Client) UnlinkEmail(session *Session, request *ApiAccountEmail) (bool,
 
 // UnlinkFacebook removes the Facebook ID from the social profiles on the current user's account.
 func (c *Client) UnlinkFacebook(session *Session, request *ApiAccountFacebook) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkFacebook(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkFacebook(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2404,14 +3711,11 @@ func (c *Client) UnlinkFacebook(session *Session, request *ApiAccountFacebook) (
 
 // UnlinkFacebookInstantGame removes Facebook Instant social profiles from the current user's account.
 func (c *Client) UnlinkFacebookInstantGame(session *Session, request *ApiAccountFacebookInstantGame) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkFacebookInstantGame(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkFacebookInstantGame(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2421,14 +3725,11 @@ func (c *Client) UnlinkFacebookInstantGame(session *Session, request *ApiAccount
 
 // UnlinkGoogle removes the Google ID from the social profiles on the current user's account.
 func (c *Client) UnlinkGoogle(session *Session, request *ApiAccountGoogle) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkGoogle(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkGoogle(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2438,14 +3739,11 @@ func (c *Client) UnlinkGoogle(session *Session, request *ApiAccountGoogle) (bool
 
 // UnlinkGameCenter removes GameCenter from the social profiles on the current user's account.
 func (c *Client) UnlinkGameCenter(session *Session, request *ApiAccountGameCenter) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkGameCenter(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkGameCenter(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2455,14 +3753,11 @@ func (c *Client) UnlinkGameCenter(session *Session, request *ApiAccountGameCente
 
 // UnlinkSteam removes Steam from the social profiles on the current user's account.
 func (c *Client) UnlinkSteam(session *Session, request *ApiAccountSteam) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UnlinkSteam(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UnlinkSteam(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2472,14 +3767,11 @@ func (c *Client) UnlinkSteam(session *Session, request *ApiAccountSteam) (bool,
 
 // UpdateAccount updates fields in the current user's account.
 func (c *Client) UpdateAccount(session *Session, request *ApiUpdateAccountRequest) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UpdateAccount(session.Token, *request, make(map[string]string))
+	response, err := c.ApiClient.UpdateAccount(session.token(), *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2489,14 +3781,11 @@ func (c *Client) UpdateAccount(session *Session, request *ApiUpdateAccountReques
 
 // UpdateGroup updates a group the user is part of and has permissions to update.
 func (c *Client) UpdateGroup(session *Session, groupId string, request *ApiUpdateGroupRequest) (bool, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return false, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return false, err
 	}
 
-	response, err := c.ApiClient.UpdateGroup(session.Token, groupId, *request, make(map[string]string))
+	response, err := c.ApiClient.UpdateGroup(session.token(), groupId, *request, make(map[string]string))
 	if err != nil {
 		return false, err
 	}
@@ -2505,15 +3794,13 @@ func (c *Client) UpdateGroup(session *Session, groupId string, request *ApiUpdat
 }
 
 // ValidatePurchaseApple validates an Apple IAP receipt.
+// Purchases validated before are flagged with SeenBefore; see ApiValidatePurchaseResponse.NewPurchases.
 func (c *Client) ValidatePurchaseApple(session *Session, receipt *string, persist bool) (*ApiValidatePurchaseResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ValidatePurchaseApple(session.Token, ApiValidatePurchaseAppleRequest{
+	response, err := c.ApiClient.ValidatePurchaseApple(session.token(), ApiValidatePurchaseAppleRequest{
 		Receipt: receipt,
 		Persist: &persist,
 	}, make(map[string]string))
@@ -2525,15 +3812,13 @@ func (c *Client) ValidatePurchaseApple(session *Session, receipt *string, persis
 }
 
 // ValidatePurchaseFacebookInstant validates a Facebook Instant IAP receipt.
+// Purchases validated before are flagged with SeenBefore; see ApiValidatePurchaseResponse.NewPurchases.
 func (c *Client) ValidatePurchaseFacebookInstant(session *Session, signedRequest *string, persist bool) (*ApiValidatePurchaseResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ValidatePurchaseFacebookInstant(session.Token, ApiValidatePurchaseFacebookInstantRequest{
+	response, err := c.ApiClient.ValidatePurchaseFacebookInstant(session.token(), ApiValidatePurchaseFacebookInstantRequest{
 		SignedRequest: signedRequest,
 		Persist:       &persist,
 	}, make(map[string]string))
@@ -2545,15 +3830,13 @@ func (c *Client) ValidatePurchaseFacebookInstant(session *Session, signedRequest
 }
 
 // ValidatePurchaseGoogle validates a Google IAP receipt.
+// Purchases validated before are flagged with SeenBefore; see ApiValidatePurchaseResponse.NewPurchases.
 func (c *Client) ValidatePurchaseGoogle(session *Session, purchase *string, persist bool) (*ApiValidatePurchaseResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ValidatePurchaseGoogle(session.Token, ApiValidatePurchaseGoogleRequest{
+	response, err := c.ApiClient.ValidatePurchaseGoogle(session.token(), ApiValidatePurchaseGoogleRequest{
 		Purchase: purchase,
 		Persist:  &persist,
 	}, make(map[string]string))
@@ -2565,15 +3848,13 @@ func (c *Client) ValidatePurchaseGoogle(session *Session, purchase *string, pers
 }
 
 // ValidatePurchaseHuawei validates a Huawei IAP receipt.
+// Purchases validated before are flagged with SeenBefore; see ApiValidatePurchaseResponse.NewPurchases.
 func (c *Client) ValidatePurchaseHuawei(session *Session, purchase *string, signature *string, persist bool) (*ApiValidatePurchaseResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ValidatePurchaseHuawei(session.Token, ApiValidatePurchaseHuaweiRequest{
+	response, err := c.ApiClient.ValidatePurchaseHuawei(session.token(), ApiValidatePurchaseHuaweiRequest{
 		Purchase:  purchase,
 		Signature: signature,
 		Persist:   &persist,
@@ -2587,14 +3868,11 @@ func (c *Client) ValidatePurchaseHuawei(session *Session, purchase *string, sign
 
 // ValidateSubscriptionApple validates an Apple subscription receipt.
 func (c *Client) ValidateSubscriptionApple(session *Session, receipt *string, persist bool) (*ApiValidateSubscriptionResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ValidateSubscriptionApple(session.Token, ApiValidateSubscriptionAppleRequest{
+	response, err := c.ApiClient.ValidateSubscriptionApple(session.token(), ApiValidateSubscriptionAppleRequest{
 		Receipt: receipt,
 		Persist: &persist,
 	}, make(map[string]string))
@@ -2607,14 +3885,11 @@ func (c *Client) ValidateSubscriptionApple(session *Session, receipt *string, pe
 
 // ValidateSubscriptionGoogle validates a Google subscription receipt.
 func (c *Client) ValidateSubscriptionGoogle(session *Session, receipt *string, persist bool) (*ApiValidateSubscriptionResponse, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
-	response, err := c.ApiClient.ValidateSubscriptionGoogle(session.Token, ApiValidateSubscriptionGoogleRequest{
+	response, err := c.ApiClient.ValidateSubscriptionGoogle(session.token(), ApiValidateSubscriptionGoogleRequest{
 		Receipt: receipt,
 		Persist: &persist,
 	}, make(map[string]string))
@@ -2627,15 +3902,12 @@ func (c *Client) ValidateSubscriptionGoogle(session *Session, receipt *string, p
 
 // WriteLeaderboardRecord writes a record to a leaderboard.
 func (c *Client) WriteLeaderboardRecord(session *Session, leaderboardId string, request *WriteLeaderboardRecord) (*LeaderboardRecord, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	response, err := c.ApiClient.WriteLeaderboardRecord(
-		session.Token,
+		session.token(),
 		leaderboardId,
 		WriteLeaderboardRecordRequestLeaderboardRecordWrite{
 			Metadata: func() *string {
@@ -2654,37 +3926,74 @@ func (c *Client) WriteLeaderboardRecord(session *Session, leaderboardId string,
 		return nil, err
 	}
 
-	leaderboardRecord := &LeaderboardRecord{
-		ExpiryTime:    timeToStringPointer(*response.ExpiryTime, time.RFC3339),
-		LeaderboardID: response.LeaderboardID,
-		Metadata: func() map[string]interface{} {
-			if response.Metadata != nil {
-				var metadata map[string]interface{}
-				json.Unmarshal([]byte(*response.Metadata), &metadata)
-				return metadata
+	return leaderboardRecordFromApi(response)
+}
+
+// CopyStorageCollection copies the session user's objects in fromCollection to toCollection, limit
+// objects per page, keeping their keys, values and permissions, and returns how many were copied. The
+// original objects are kept. Objects already in toCollection with the same key are overwritten, and
+// the copy fails at the first page the server rejects, for example because an object there is
+// read-only to the client. Only the server can copy other users' objects. Cancel it with WithContext;
+// the objects copied before it was cancelled are counted.
+func (c *Client) CopyStorageCollection(session *Session, fromCollection, toCollection string, limit int) (int, error) {
+	if fromCollection == toCollection {
+		return 0, fmt.Errorf("cannot copy collection %q onto itself", fromCollection)
+	}
+
+	userID := sessionUserID(session)
+	copied := 0
+	var cursor *string
+	for {
+		if ctx := c.ApiClient.callContext; ctx != nil && ctx.Err() != nil {
+			return copied, ctx.Err()
+		}
+
+		list, err := c.ListStorageObjects(session, fromCollection, &userID, &limit, cursor)
+		if err != nil {
+			return copied, err
+		}
+
+		writes := make([]WriteStorageObject, 0, len(list.Objects))
+		for _, o := range list.Objects {
+			writes = append(writes, WriteStorageObject{
+				Collection:      &toCollection,
+				Key:             o.Key,
+				PermissionRead:  o.PermissionRead,
+				PermissionWrite: o.PermissionWrite,
+				Value:           o.Value,
+				RawValue:        o.RawValue,
+			})
+		}
+		if len(writes) > 0 {
+			if _, err := c.WriteStorageObjects(session, writes); err != nil {
+				return copied, err
 			}
-			return nil
-		}(),
-		NumScore:    response.NumScore,
-		OwnerID:     response.OwnerID,
-		Score:       stringPointerToIntPointer(response.Score),
-		SubScore:    stringPointerToIntPointer(response.Subscore),
-		UpdateTime:  timeToStringPointer(*response.UpdateTime, time.RFC3339),
-		Username:    response.Username,
-		MaxNumScore: response.MaxNumScore,
-		Rank:        stringPointerToIntPointer(response.Rank),
-	}
+			copied += len(writes)
+		}
 
-	return leaderboardRecord, nil
+		next := stringValue(list.Cursor)
+		if next == "" || next == stringValue(cursor) {
+			return copied, nil
+		}
+		cursor = &next
+	}
 }
 
-// WriteStorageObjects writes storage objects.
+// WriteStorageObjects writes storage objects. The server writes a batch all-or-nothing: when it
+// rejects the batch, none of the objects are written and a *StorageWriteError lists the objects that
+// may have caused it. A version conflict also matches ErrVersionMismatch.
+//
+// Objects are always written as the session's user; only the server can write another user's objects.
+// Permissions are checked before the request: clients can make an object private, owner-readable or
+// public to read, but only owner-writable or read-only, never public to write, and other values fail
+// with ErrStoragePermission. PermissionWriteNoAccess makes the object read-only to the client after
+// the write, so later writes of it are rejected.
 func (c *Client) WriteStorageObjects(session *Session, objects []WriteStorageObject) (*ApiStorageObjectAcks, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := validateStoragePermissions(objects); err != nil {
+		return nil, err
+	}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	request := ApiWriteStorageObjectsRequest{Objects: &[]ApiWriteStorageObject{}}
@@ -2694,30 +4003,98 @@ func (c *Client) WriteStorageObjects(session *Session, objects []WriteStorageObj
 			Key:             o.Key,
 			PermissionRead:  o.PermissionRead,
 			PermissionWrite: o.PermissionWrite,
-			Value:           func() *string { v := string(ToJSON(o.Value)); return &v }(),
-			Version:         o.Version,
+			Value: func() *string {
+				if o.RawValue != "" {
+					return &o.RawValue
+				}
+				v := string(ToJSON(o.Value))
+				return &v
+			}(),
+			Version: o.Version,
 		})
 	}
 
-	storageObjects, err := c.ApiClient.WriteStorageObjects(session.Token, request, make(map[string]string))
+	storageObjects, err := c.ApiClient.WriteStorageObjects(session.token(), request, make(map[string]string))
 	if err != nil {
-		return nil, err
+		var apiErr *ApiError
+		if !errors.As(err, &apiErr) {
+			return nil, err
+		}
+
+		// The server does not say which object was rejected, so narrow it down where the cause allows.
+		writeErr := &StorageWriteError{Objects: objects, Err: err}
+		if strings.Contains(strings.ToLower(apiErr.Message), "version check failed") {
+			writeErr.Err = fmt.Errorf("%w: %w", ErrVersionMismatch, err)
+			for i, o := range objects {
+				if o.Version != nil && *o.Version != "" {
+					writeErr.Failed = append(writeErr.Failed, i)
+				}
+			}
+		}
+		if len(writeErr.Failed) == 0 {
+			for i := range objects {
+				writeErr.Failed = append(writeErr.Failed, i)
+			}
+		}
+		return nil, writeErr
 	}
 
 	return &storageObjects, nil
 }
 
+// UpdateStorageObject performs a read-modify-write of one of the user's storage objects. It reads the
+// current value and version, passes the value to update (nil if the object does not exist yet), and
+// writes the result only if the object is unchanged since it was read. When another write got there
+// first, the cycle is retried after the client's Backoff delay, up to MaxStorageUpdateAttempts times
+// before ErrVersionMismatch is returned. An error returned by update aborts without writing. Existing
+// permissions are kept.
+func (c *Client) UpdateStorageObject(session *Session, collection, key string, update func(current map[string]interface{}) (map[string]interface{}, error)) error {
+	userId := sessionUserID(session)
+
+	var err error
+	for attempt := 0; attempt < max(MaxStorageUpdateAttempts, 1); attempt++ {
+		if attempt > 0 {
+			if err := c.ApiClient.wait(c.Backoff.Delay(attempt - 1)); err != nil {
+				return err
+			}
+		}
+		write := WriteStorageObject{Collection: &collection, Key: &key}
+
+		var current map[string]interface{}
+		object, readErr := c.ReadStorageObject(session, collection, key, userId)
+		switch {
+		case readErr == nil:
+			current = object.Value
+			write.Version = object.Version
+			write.PermissionRead = object.PermissionRead
+			write.PermissionWrite = object.PermissionWrite
+		case errors.Is(readErr, ErrStorageObjectNotFound):
+			// A version of "*" only writes the object if it still does not exist.
+			notExists := "*"
+			write.Version = &notExists
+		default:
+			return readErr
+		}
+
+		if write.Value, err = update(current); err != nil {
+			return err
+		}
+
+		if _, err = c.WriteStorageObjects(session, []WriteStorageObject{write}); !errors.Is(err, ErrVersionMismatch) {
+			return err
+		}
+	}
+	return err
+}
+
 // WriteTournamentRecord writes a record to a tournament.
 func (c *Client) WriteTournamentRecord(session *Session, tournamentId string, request *WriteTournamentRecord) (*LeaderboardRecord, error) {
-	if c.AutoRefreshSession && session.RefreshToken != "" &&
-		session.IsExpired((time.Now().Unix()+c.ExpiredTimespanMs)/1000) {
-		if _, err := c.SessionRefresh(session, nil); err != nil {
-			return nil, err
-		}
+	if err := c.refreshIfNeeded(session); err != nil {
+		return nil, err
 	}
 
 	response, err := c.ApiClient.WriteTournamentRecord(
-		session.Token,
+		session.token(),
 		tournamentId,
 		WriteTournamentRecordRequestTournamentRecordWrite{
 			Metadata: func() *string {
@@ -2736,26 +4113,5 @@ func (c *Client) WriteTournamentRecord(session *Session, tournamentId string, re
 		return nil, err
 	}
 
-	tournamentRecord := &LeaderboardRecord{
-		ExpiryTime:    timeToStringPointer(*response.ExpiryTime, time.RFC3339),
-		LeaderboardID: response.LeaderboardID,
-		Metadata: func() map[string]interface{} {
-			if response.Metadata != nil {
-				var metadata map[string]interface{}
-				json.Unmarshal([]byte(*response.Metadata), &metadata)
-				return metadata
-			}
-			return nil
-		}(),
-		NumScore:    response.NumScore,
-		OwnerID:     response.OwnerID,
-		Score:       stringPointerToIntPointer(response.Score),
-		SubScore:    stringPointerToIntPointer(response.Subscore),
-		UpdateTime:  timeToStringPointer(*response.UpdateTime, time.RFC3339),
-		Username:    response.Username,
-		MaxNumScore: response.MaxNumScore,
-		Rank:        stringPointerToIntPointer(response.Rank),
-	}
-
-	return tournamentRecord, nil
+	return leaderboardRecordFromApi(response)
 }
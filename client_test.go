@@ -0,0 +1,2118 @@
+package nakama
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupMockServer starts an HTTP server with the given handler and returns a client pointed at it.
+func setupMockServer(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("defaultkey", "127.0.0.1", "7350", false, nil, nil)
+	client.ApiClient.BasePath = server.URL
+	return client
+}
+
+// writeSessionResponse writes a minimal authentication response.
+func writeSessionResponse(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         makeTestToken(time.Now().Add(time.Hour).Unix()),
+		"refresh_token": makeTestToken(time.Now().Add(2 * time.Hour).Unix()),
+		"created":       true,
+	})
+}
+
+func TestAuthenticate_BasicAuthHeader(t *testing.T) {
+	var authorization string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		writeSessionResponse(w)
+	})
+
+	_, err := client.AuthenticateCustom("custom-id", nil, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("defaultkey:")), authorization)
+}
+
+func TestSessionRefresh_BasicAuthHeader(t *testing.T) {
+	var authorization string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		writeSessionResponse(w)
+	})
+
+	_, err := client.SessionRefresh(&Session{RefreshToken: "refresh"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("defaultkey:")), authorization)
+}
+
+func TestAuthenticate_EmptyServerKey(t *testing.T) {
+	called := false
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		writeSessionResponse(w)
+	})
+	client.ServerKey = ""
+
+	session, err := client.AuthenticateDevice("device-id", nil, nil, nil)
+
+	assert.ErrorContains(t, err, "server key")
+	assert.Nil(t, session)
+	assert.False(t, called)
+}
+
+func TestAuthenticateFacebookInstantGame_SendsSignedPlayerInfo(t *testing.T) {
+	var path string
+	var account ApiAccountFacebookInstantGame
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&account)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"token","refresh_token":"refresh"}`))
+	})
+
+	session, err := client.AuthenticateFacebookInstantGame("signature.payload", nil, nil, map[string]string{"k": "v"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/account/authenticate/facebookinstantgame", path)
+	assert.Equal(t, "signature.payload", *account.SignedPlayerInfo)
+	assert.Equal(t, map[string]string{"k": "v"}, account.Vars)
+	assert.False(t, session.Created)
+}
+
+// makeTestToken builds an unsigned JWT with the given expiry for session tests.
+func makeTestToken(exp int64) string {
+	payload, _ := json.Marshal(map[string]interface{}{"exp": exp, "uid": "user-id", "usn": "user"})
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestEnsureValidSession_NoopWhenFresh(t *testing.T) {
+	var calls int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	})
+	session := NewSession(makeTestToken(time.Now().Add(time.Hour).Unix()), makeTestToken(time.Now().Add(2*time.Hour).Unix()), false)
+
+	err := client.EnsureValidSession(session)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+func TestEnsureValidSession_SingleFlight(t *testing.T) {
+	var calls int32
+	fresh := makeTestToken(time.Now().Add(time.Hour).Unix())
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": fresh, "refresh_token": ""})
+	})
+	session := NewSession(makeTestToken(time.Now().Add(time.Minute).Unix()), makeTestToken(time.Now().Add(time.Hour).Unix()), false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.EnsureValidSession(session))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, fresh, session.Token)
+}
+
+func TestEnsureValidSession_FailureLeavesSessionUnchanged(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	token := makeTestToken(time.Now().Add(-time.Minute).Unix())
+	session := NewSession(token, makeTestToken(time.Now().Add(time.Hour).Unix()), false)
+	before := *session
+
+	err := client.EnsureValidSession(session)
+
+	assert.Error(t, err)
+	assert.Equal(t, before, *session)
+}
+
+func TestEnsureValidSession_AuthenticatedSession(t *testing.T) {
+	expiring := makeTestToken(time.Now().Add(time.Minute).Unix())
+	fresh := makeTestToken(time.Now().Add(time.Hour).Unix())
+	var refreshes int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		token := expiring
+		if r.URL.Path == "/v2/account/session/refresh" {
+			atomic.AddInt32(&refreshes, 1)
+			token = fresh
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":         token,
+			"refresh_token": makeTestToken(time.Now().Add(2 * time.Hour).Unix()),
+		})
+	})
+
+	session, err := client.AuthenticateDevice("device-id", nil, nil, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, session.ExpiresAt)
+	assert.Equal(t, "user-id", *session.UserID)
+
+	assert.NoError(t, client.EnsureValidSession(session))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+	assert.Equal(t, fresh, session.Token)
+}
+
+func TestEnsureValidSession_ConcurrentRequests(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v2/account" {
+			_, _ = w.Write([]byte(`{"user":{"id":"user-id"}}`))
+			return
+		}
+		// Every token expires within ExpiredTimespanMs, so that each EnsureValidSession refreshes.
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":         makeTestToken(time.Now().Add(time.Minute).Unix()),
+			"refresh_token": makeTestToken(time.Now().Add(2 * time.Hour).Unix()),
+		})
+	}
+	client := setupMockServer(t, handler)
+	// Requests of a second client, over its own connections, share no locks with the refreshes.
+	reader := setupMockServer(t, handler)
+	reader.SetHTTPClient(&http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()})
+	reader.SetAutoRefreshSession(false)
+
+	session, err := client.AuthenticateDevice("device-id", nil, nil, nil)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			assert.NoError(t, client.EnsureValidSession(session))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			_, err := reader.GetAccount(session)
+			assert.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestIsSubscriptionActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected bool
+	}{
+		{"active", http.StatusOK, `{"active":true,"expiry_time":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`, true},
+		{"expired but marked active", http.StatusOK, `{"active":true,"expiry_time":"` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`, false},
+		{"inactive", http.StatusOK, `{"active":false}`, false},
+		{"not found", http.StatusNotFound, `{"error":"subscription not found","code":5}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v2/iap/subscription/product", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			active, err := client.IsSubscriptionActive(&Session{Token: "token"}, "product")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+		})
+	}
+}
+
+func TestGetGroupUserRole(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/group/group-id/user", r.URL.Path)
+		now := time.Now().Format(time.RFC3339)
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{"group_users":[{"user":{"id":"owner","create_time":"` + now + `","update_time":"` + now + `"}}],"cursor":"next"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"group_users":[{"user":{"id":"user-id","create_time":"` + now + `","update_time":"` + now + `"},"state":1}]}`))
+	})
+	session := &Session{Token: "token"}
+
+	role, err := client.GetGroupUserRole(session, "group-id", "user-id")
+	assert.NoError(t, err)
+	assert.Equal(t, GroupStateAdmin, role)
+
+	role, err = client.GetGroupUserRole(session, "group-id", "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, GroupStateSuperadmin, role)
+
+	_, err = client.GetGroupUserRole(session, "group-id", "missing")
+	assert.ErrorIs(t, err, ErrNotGroupMember)
+}
+
+func TestServerTime_ClockSkew(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	})
+	session := NewSession(makeTestToken(time.Now().Add(30*time.Minute).Unix()), "", false)
+
+	serverTime, err := client.ServerTime(context.Background())
+
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), serverTime, 2*time.Second)
+	assert.InDelta(t, time.Hour.Seconds(), client.ClockSkew().Seconds(), 2)
+	assert.False(t, client.needsRefresh(session))
+
+	client.CorrectClockSkew = true
+	assert.True(t, client.needsRefresh(session))
+}
+
+func TestServerTime_Errors(t *testing.T) {
+	block := make(chan struct{})
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Block") != "" {
+			<-block
+			return
+		}
+		w.Header().Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"Service unavailable","code":14}`))
+	})
+	defer close(block)
+
+	// An error page still carries a Date header, but is not a server time.
+	_, err := client.ServerTime(context.Background())
+	var apiErr *ApiError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, time.Duration(0), client.ClockSkew())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.WithContext(ctx).ApiClient.ServerTime("", map[string]string{"X-Block": "1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestListLinkedDevices(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/account", r.URL.Path)
+		_, _ = w.Write([]byte(`{"devices":[{"id":"device-1"},{"id":"device-2"}]}`))
+	})
+
+	devices, err := client.ListLinkedDevices(&Session{Token: "token"})
+
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+	assert.Equal(t, "device-2", *devices[1].ID)
+}
+
+func TestUnlinkDevice_LastLoginMethod(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/account/unlink/device", r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"Cannot unlink last account identifier. Check profile exists and is not last link.","code":3}`))
+	})
+	id := "device-1"
+
+	ok, err := client.UnlinkDevice(&Session{Token: "token"}, &ApiAccountDevice{ID: &id})
+
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "Cannot unlink last account identifier")
+}
+
+func TestValidateStorageObjects(t *testing.T) {
+	collection, key := "saves", "slot1"
+	invalidPermission := 3
+
+	assert.NoError(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, Value: map[string]interface{}{"level": 3}},
+	}))
+	assert.ErrorContains(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, Value: map[string]interface{}{"bad": math.NaN()}},
+	}), "not valid JSON")
+	assert.ErrorContains(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, PermissionRead: &invalidPermission},
+	}), "permission read")
+	publicWrite := 2
+	assert.ErrorContains(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, PermissionWrite: &publicWrite},
+	}), "permission write")
+	assert.ErrorContains(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, Value: map[string]interface{}{"blob": strings.Repeat("x", MaxStorageValueBytes)}},
+	}), "must be at most")
+	assert.NoError(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, RawValue: `{"level":3}`},
+	}))
+	assert.ErrorContains(t, ValidateStorageObjects([]WriteStorageObject{
+		{Collection: &collection, Key: &key, RawValue: `{"level":`},
+	}), "raw value is not valid JSON")
+}
+
+func TestWriteStorageObjects_PermissionPreflight(t *testing.T) {
+	var requests int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"acks":[]}`))
+	})
+	collection, key := "saves", "slot1"
+	publicWrite, publicRead := 2, PermissionReadPublic
+
+	_, err := client.WriteStorageObjects(&Session{Token: "token"}, []WriteStorageObject{
+		{Collection: &collection, Key: &key, PermissionRead: &publicRead},
+		{Collection: &collection, Key: &key, PermissionWrite: &publicWrite},
+	})
+	assert.ErrorIs(t, err, ErrStoragePermission)
+	assert.ErrorContains(t, err, "invalid storage object 1 (saves/slot1)")
+	assert.Zero(t, atomic.LoadInt32(&requests), "the write is rejected before the request")
+
+	_, err = client.WriteStorageObjects(&Session{Token: "token"}, []WriteStorageObject{
+		{Collection: &collection, Key: &key, PermissionRead: &publicRead},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestEvent(t *testing.T) {
+	var event ApiEvent
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/event", r.URL.Path)
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	err := client.Event(&Session{Token: "token"}, "level_complete", map[string]string{"level": "3"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "level_complete", *event.Name)
+	assert.Equal(t, map[string]string{"level": "3"}, event.Properties)
+	assert.True(t, *event.External)
+	assert.WithinDuration(t, time.Now(), *event.Timestamp, 5*time.Second)
+}
+
+func TestClientClose_AbortsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.GetAccount(&Session{Token: "token"})
+		result <- err
+	}()
+
+	<-started
+	client.Close()
+	client.Close()
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, ErrClientClosed)
+	case <-time.After(time.Second):
+		t.Fatal("request was not aborted by Close")
+	}
+
+	_, err := client.GetAccount(&Session{Token: "token"})
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
+
+func TestListLeaderboardRecords_RankCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *int
+	}{
+		{"populated", `{"rank_count":"42"}`, func() *int { v := 42; return &v }()},
+		{"empty", `{"rank_count":""}`, nil},
+		{"absent", `{}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			list, err := client.ListLeaderboardRecords(&Session{Token: "token"}, "leaderboard", nil, nil, nil, nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, list.RankCount)
+		})
+	}
+}
+
+func TestListTournamentRecords_RankCount(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"rank_count":"7"}`))
+	})
+
+	list, err := client.ListTournamentRecords(&Session{Token: "token"}, "tournament", nil, nil, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, *list.RankCount)
+}
+
+func TestListMatches_JSONLabel(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "+label.mode:ranked", r.URL.Query().Get("query"))
+		_, _ = w.Write([]byte(`{"matches":[{"match_id":"m1","authoritative":true,"label":"{\"mode\":\"ranked\",\"level\":3}"}]}`))
+	})
+	query := "+label.mode:ranked"
+
+	list, err := client.ListMatches(&Session{Token: "token"}, nil, nil, nil, nil, nil, &query)
+	assert.NoError(t, err)
+	assert.Len(t, list.Matches, 1)
+
+	var label struct {
+		Mode  string `json:"mode"`
+		Level int    `json:"level"`
+	}
+	assert.NoError(t, list.Matches[0].ParseLabel(&label))
+	assert.Equal(t, "ranked", label.Mode)
+	assert.Equal(t, 3, label.Level)
+}
+
+func TestListStorageObjects_CursorValidation(t *testing.T) {
+	var cursors []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		cursors = append(cursors, r.URL.Query().Get("cursor"))
+		_, _ = w.Write([]byte(`{}`))
+	})
+	session := &Session{Token: "token"}
+
+	cursor := "  Q1VSU09S\n"
+	_, err := client.ListStorageObjects(session, "saves", nil, nil, &cursor)
+	assert.NoError(t, err)
+
+	invalid := "not a cursor!"
+	_, err = client.ListStorageObjects(session, "saves", nil, nil, &invalid)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	assert.Equal(t, []string{"Q1VSU09S"}, cursors)
+}
+
+func TestClient_TLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("defaultkey", "127.0.0.1", "7350", true, nil, nil)
+	client.ApiClient.BasePath = server.URL
+	session := &Session{Token: "token"}
+
+	_, err := client.GetAccount(session)
+	assert.Error(t, err, "the test server's certificate is not trusted by default")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client.SetTLSConfig(&tls.Config{RootCAs: roots})
+	_, err = client.GetAccount(session)
+	assert.NoError(t, err)
+
+	insecure := NewClient("defaultkey", "127.0.0.1", "7350", true, nil, nil)
+	insecure.ApiClient.BasePath = server.URL
+	insecure.SetInsecureSkipVerify(true)
+	_, err = insecure.GetAccount(session)
+	assert.NoError(t, err)
+}
+
+func TestFetchUsers_ChunksLargeLookups(t *testing.T) {
+	var requests int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		ids := r.URL.Query()["ids"]
+		assert.LessOrEqual(t, len(ids), MaxUsersPerRequest)
+
+		// Every response also includes the same shared user, which must only be returned once.
+		users := []map[string]string{{"id": "shared"}}
+		for _, id := range ids {
+			users = append(users, map[string]string{"id": id})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"users": users})
+	})
+
+	ids := make([]string, 500)
+	for i := range ids {
+		ids[i] = "user-" + strconv.Itoa(i)
+	}
+
+	users, err := client.FetchUsers(&Session{Token: "token"}, ids, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+	assert.Len(t, users.Users, 501)
+	assert.Equal(t, "shared", *users.Users[0].ID)
+	assert.Equal(t, "user-499", *users.Users[500].ID)
+}
+
+func TestDeleteGroup_NotGroupAdmin(t *testing.T) {
+	deleted := false
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = true
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"Group not found or permission denied.","code":7}`))
+			return
+		}
+		now := time.Now().Format(time.RFC3339)
+		_, _ = w.Write([]byte(`{"group_users":[{"user":{"id":"member-id","create_time":"` + now + `","update_time":"` + now + `"},"state":2}]}`))
+	})
+	// The user ID is read from the token of a session that does not carry it.
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())}
+	userID := "member-id"
+
+	ok, err := client.DeleteGroup(&Session{Token: session.Token, UserID: &userID}, "group-id")
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, ErrNotGroupAdmin)
+	assert.False(t, deleted, "the delete must not be attempted by a non-admin")
+
+	ok, err = client.DeleteGroup(session, "group-id")
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, ErrNotGroupAdmin)
+	assert.ErrorIs(t, err, ErrNotGroupMember)
+	assert.False(t, deleted, "the delete must not be attempted by a non-member")
+
+	// Without a user ID the server's permission error is mapped instead.
+	ok, err = client.DeleteGroup(&Session{Token: "token"}, "group-id")
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, ErrNotGroupAdmin)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.True(t, deleted)
+}
+
+func TestDecodeJSONField(t *testing.T) {
+	metadata := map[string]interface{}{"stale": true}
+	assert.NoError(t, DecodeJSONField("", &metadata))
+	assert.Nil(t, metadata, "an empty field decodes to the zero value")
+
+	assert.NoError(t, DecodeJSONField(`{"level":3,"tags":["a"]}`, &metadata))
+	assert.Equal(t, map[string]interface{}{"level": float64(3), "tags": []interface{}{"a"}}, metadata)
+
+	var typed struct {
+		Level int `json:"level"`
+	}
+	assert.NoError(t, DecodeJSONField(`{"level":3}`, &typed))
+	assert.Equal(t, 3, typed.Level)
+
+	err := DecodeJSONField(`{"level":`, &metadata)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid JSON field")
+}
+
+func TestTournament_Schedule(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	base := time.Unix(1_700_000_000, 0)
+
+	// A daily tournament with an hour-long window that started ten minutes before base.
+	daily := &Tournament{
+		StartActive: intPtr(int(base.Unix()) - 600),
+		EndActive:   intPtr(int(base.Unix()) + 3000),
+		NextReset:   intPtr(int(base.Unix()) + 86400 - 600),
+		PrevReset:   intPtr(int(base.Unix()) - 600),
+		Duration:    intPtr(3600),
+	}
+	assert.True(t, daily.IsActive(base))
+	assert.Equal(t, 23*time.Hour+50*time.Minute, daily.TimeUntilReset(base))
+	start, end := daily.CurrentWindow(base)
+	assert.Equal(t, base.Add(-10*time.Minute), start)
+	assert.Equal(t, base.Add(50*time.Minute), end)
+
+	// Between windows the tournament is inactive, and past the reset the next window applies.
+	assert.False(t, daily.IsActive(base.Add(2*time.Hour)))
+	afterReset := base.Add(24 * time.Hour)
+	assert.True(t, daily.IsActive(afterReset))
+	start, end = daily.CurrentWindow(afterReset)
+	assert.Equal(t, time.Unix(int64(*daily.NextReset), 0), start)
+	assert.Equal(t, start.Add(time.Hour), end)
+	assert.Zero(t, daily.TimeUntilReset(afterReset))
+
+	// A one-off tournament without resets runs until its end time.
+	endTime := base.Add(time.Hour).UTC().Format(time.RFC3339)
+	oneOff := &Tournament{
+		StartActive: intPtr(int(base.Unix())),
+		NextReset:   intPtr(0),
+		EndTime:     &endTime,
+	}
+	assert.Zero(t, oneOff.TimeUntilReset(base))
+	assert.True(t, oneOff.IsActive(base.Add(30*time.Minute)))
+	assert.False(t, oneOff.IsActive(base.Add(2*time.Hour)))
+	_, end = oneOff.CurrentWindow(base)
+	assert.True(t, base.Add(time.Hour).Equal(end))
+
+	assert.False(t, (&Tournament{}).IsActive(base))
+}
+
+func TestSkipAutoRefresh(t *testing.T) {
+	var paths []string
+	var mu sync.Mutex
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	})
+	client.SetAutoRefreshSession(true)
+	session := NewSession(makeTestToken(time.Now().Add(time.Minute).Unix()), makeTestToken(time.Now().Add(time.Hour).Unix()), false)
+	token := session.Token
+
+	_, err := client.SessionLogout(session, session.Token, session.RefreshToken)
+	assert.NoError(t, err)
+	_, err = client.DeleteAccount(session)
+	assert.NoError(t, err)
+	_, err = client.WithoutAutoRefresh().ListNotifications(session, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"/v2/session/logout", "/v2/account", "/v2/notification"}, paths)
+	assert.Equal(t, token, session.Token)
+	assert.True(t, client.AutoRefreshSession(), "the original client keeps auto refresh")
+}
+
+func TestListGroups_Filters(t *testing.T) {
+	var query url.Values
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		now := time.Now().Format(time.RFC3339)
+		_, _ = w.Write([]byte(`{"groups":[{"id":"g1","metadata":"{\"region\":\"eu\"}","create_time":"` + now + `","update_time":"` + now + `"}]}`))
+	})
+	session := &Session{Token: "token"}
+	name, langTag, members, open, limit := "guild%", "en", 10, true, 20
+
+	tests := []struct {
+		name     string
+		list     func() (*GroupList, error)
+		expected url.Values
+	}{
+		{"none", func() (*GroupList, error) { return client.ListGroups(session, nil, nil, nil, nil, nil, nil) }, url.Values{}},
+		{"name prefix", func() (*GroupList, error) { return client.ListGroups(session, &name, nil, &limit, nil, nil, nil) },
+			url.Values{"name": {"guild%"}, "limit": {"20"}}},
+		{"open", func() (*GroupList, error) { return client.ListGroups(session, nil, nil, nil, nil, nil, &open) },
+			url.Values{"open": {"true"}}},
+		{"lang and members", func() (*GroupList, error) { return client.ListGroups(session, nil, nil, nil, &langTag, &members, nil) },
+			url.Values{"lang_tag": {"en"}, "members": {"10"}}},
+		{"all but name", func() (*GroupList, error) {
+			return client.ListGroups(session, nil, nil, &limit, &langTag, &members, &open)
+		},
+			url.Values{"limit": {"20"}, "lang_tag": {"en"}, "members": {"10"}, "open": {"true"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := tt.list()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, query)
+			assert.Equal(t, map[string]interface{}{"region": "eu"}, groups.Groups[0].Metadata)
+		})
+	}
+}
+
+func TestValidatePurchase_SeenBefore(t *testing.T) {
+	validated := map[string]bool{}
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ApiValidatePurchaseGoogleRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		seenBefore := validated[*request.Purchase]
+		validated[*request.Purchase] = true
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"validated_purchases": []map[string]interface{}{{"transaction_id": "tx-1", "seen_before": seenBefore}},
+		})
+	})
+	session := &Session{Token: "token"}
+	receipt := "receipt"
+
+	first, err := client.ValidatePurchaseGoogle(session, &receipt, true)
+	assert.NoError(t, err)
+	assert.False(t, (*first.ValidatedPurchases)[0].IsSeenBefore())
+	assert.Len(t, first.NewPurchases(), 1)
+
+	again, err := client.ValidatePurchaseGoogle(session, &receipt, true)
+	assert.NoError(t, err)
+	assert.True(t, (*again.ValidatedPurchases)[0].IsSeenBefore())
+	assert.Empty(t, again.NewPurchases())
+}
+
+func TestSessionRefresh_RefreshTokenRotation(t *testing.T) {
+	rotated := makeTestToken(time.Now().Add(3 * time.Hour).Unix())
+	rotate := true
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		response := map[string]string{"token": makeTestToken(time.Now().Add(time.Hour).Unix())}
+		if rotate {
+			response["refresh_token"] = rotated
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+	original := makeTestToken(time.Now().Add(2 * time.Hour).Unix())
+
+	session := NewSession(makeTestToken(time.Now().Unix()), original, false)
+	_, err := client.SessionRefresh(session, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rotated, session.RefreshToken)
+
+	// Without rotation the server omits the refresh token and the current one is kept.
+	rotate = false
+	session = NewSession(makeTestToken(time.Now().Unix()), original, false)
+	refreshExpiresAt := *session.RefreshExpiresAt
+	_, err = client.SessionRefresh(session, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, original, session.RefreshToken)
+	assert.Equal(t, refreshExpiresAt, *session.RefreshExpiresAt)
+}
+
+func TestUpdateSessionVars(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ApiSessionRefreshRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		payload, _ := json.Marshal(map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix(), "uid": "user-id", "vrs": request.Vars})
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"})
+	})
+
+	session := NewSession(makeTestToken(time.Now().Add(time.Hour).Unix()), makeTestToken(time.Now().Add(2*time.Hour).Unix()), false)
+	err := client.UpdateSessionVars(session, map[string]string{"region": "eu"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"region": "eu"}, session.Vars)
+
+	expired := NewSession(makeTestToken(time.Now().Add(time.Hour).Unix()), makeTestToken(time.Now().Add(-time.Hour).Unix()), false)
+	err = client.UpdateSessionVars(expired, map[string]string{"region": "eu"})
+	assert.ErrorContains(t, err, "valid refresh token")
+	assert.Nil(t, expired.Vars)
+}
+
+func TestValidateVars(t *testing.T) {
+	assert.NoError(t, ValidateVars(nil))
+	assert.NoError(t, ValidateVars(map[string]string{"region": "eu"}))
+	assert.ErrorContains(t, ValidateVars(map[string]string{"uid": "x"}), `key "uid" is reserved`)
+	assert.ErrorContains(t, ValidateVars(map[string]string{"": "x"}), "must not be empty")
+	assert.ErrorContains(t, ValidateVars(map[string]string{"blob": strings.Repeat("x", MaxVarsBytes)}), "byte limit")
+}
+
+func TestAuthenticate_ValidatesVars(t *testing.T) {
+	var calls int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writeSessionResponse(w)
+	})
+	vars := map[string]string{"usn": "spoofed"}
+
+	_, err := client.AuthenticateCustom("custom-id", nil, nil, vars)
+	assert.ErrorContains(t, err, "reserved")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	client.ValidateVars = false
+	_, err = client.AuthenticateCustom("custom-id", nil, nil, vars)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_ToggleAutoRefreshConcurrently(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/account/session/refresh" {
+			writeSessionResponse(w)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session := NewSession(makeTestToken(time.Now().Add(time.Minute).Unix()), makeTestToken(time.Now().Add(time.Hour).Unix()), false)
+			for j := 0; j < 10; j++ {
+				_, err := client.ListNotifications(session, nil, nil)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		client.SetAutoRefreshSession(i%2 == 0)
+		client.SetExpiredTimespanMs(int64(i) * 1000)
+	}
+	wg.Wait()
+}
+
+func TestExportAccount(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	times := `"create_time":"` + now + `","update_time":"` + now + `"`
+	var storageQueries []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/account":
+			_, _ = w.Write([]byte(`{"user":{"id":"user-id","username":"alice"},"wallet":"{\"gold\":5}"}`))
+		case "/v2/friend":
+			if r.URL.Query().Get("cursor") == "" {
+				_, _ = w.Write([]byte(`{"friends":[{"user":{"id":"f1",` + times + `},"state":0}],"cursor":"next"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"friends":[{"user":{"id":"f2",` + times + `},"state":3}]}`))
+		case "/v2/user/user-id/group":
+			_, _ = w.Write([]byte(`{"user_groups":[{"group":{"id":"g1",` + times + `},"state":2}]}`))
+		case "/v2/storage/saves":
+			storageQueries = append(storageQueries, r.URL.Query().Get("user_id"))
+			_, _ = w.Write([]byte(`{"objects":[{"collection":"saves","key":"slot1","value":"{\"level\":3}",` + times + `}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	data, err := client.ExportAccount(&Session{Token: "token"}, "saves")
+	assert.NoError(t, err)
+
+	var export AccountExport
+	assert.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, "user-id", *export.Account.User.ID)
+	assert.Equal(t, `{"gold":5}`, *export.Account.Wallet)
+	assert.Len(t, export.Friends, 2)
+	assert.Equal(t, "g1", *export.Groups[0].Group.ID)
+	assert.Equal(t, "slot1", *export.Storage[0].Key)
+	assert.Equal(t, []string{"user-id"}, storageQueries)
+	assert.NotEmpty(t, export.ExportTime)
+}
+
+func TestReadStorageObject(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ApiReadStorageObjectsRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		id := request.ObjectIDs[0]
+		assert.Equal(t, "user-id", *id.UserID)
+		if *id.Key == "missing" {
+			_, _ = w.Write([]byte(`{"objects":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"objects":[{"collection":"saves","key":"slot1","user_id":"user-id","version":"v1","value":"{\"level\":3}","create_time":"` + now + `","update_time":"` + now + `"}]}`))
+	})
+	session := &Session{Token: "token"}
+
+	object, err := client.ReadStorageObject(session, "saves", "slot1", "user-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", *object.Version)
+	assert.Equal(t, map[string]interface{}{"level": float64(3)}, object.Value)
+
+	object, err = client.ReadStorageObject(session, "saves", "missing", "user-id")
+	assert.Nil(t, object)
+	assert.ErrorIs(t, err, ErrStorageObjectNotFound)
+}
+
+func TestUpdateStorageObject_RetriesOnConflict(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	var mu sync.Mutex
+	coins, version, writes := 10, 1, 0
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			_, _ = fmt.Fprintf(w, `{"objects":[{"collection":"wallet","key":"coins","version":"v%d","value":"{\"coins\":%d}","permission_read":1,"permission_write":1,"create_time":"%s","update_time":"%s"}]}`, version, coins, now, now)
+			return
+		}
+
+		var request ApiWriteStorageObjectsRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		object := (*request.Objects)[0]
+		writes++
+		if writes == 1 {
+			// Another writer updates the object between our read and write.
+			coins, version = coins+5, version+1
+		}
+		if *object.Version != fmt.Sprintf("v%d", version) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":3,"message":"Storage write rejected - version check failed."}`))
+			return
+		}
+		var value map[string]int
+		_ = json.Unmarshal([]byte(*object.Value), &value)
+		coins, version = value["coins"], version+1
+		assert.Equal(t, 1, *object.PermissionRead)
+		_, _ = w.Write([]byte(`{"acks":[{"collection":"wallet","key":"coins"}]}`))
+	})
+	userID := "user-id"
+	session := &Session{Token: "token", UserID: &userID}
+
+	err := client.UpdateStorageObject(session, "wallet", "coins", func(current map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"coins": current["coins"].(float64) + 1}, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, writes)
+	assert.Equal(t, 16, coins, "the retry must apply the update to the concurrent writer's value")
+}
+
+func TestUpdateStorageObject_GivesUpAfterMaxAttempts(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	var writes int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"objects":[{"collection":"wallet","key":"coins","version":"v1","value":"{}","create_time":"` + now + `","update_time":"` + now + `"}]}`))
+			return
+		}
+		atomic.AddInt32(&writes, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":3,"message":"Storage write rejected - version check failed."}`))
+	})
+	client.Backoff = BackoffConfig{Base: time.Millisecond, Factor: 2, Jitter: JitterNone}
+
+	err := client.UpdateStorageObject(&Session{Token: "token"}, "wallet", "coins", func(current map[string]interface{}) (map[string]interface{}, error) {
+		return current, nil
+	})
+
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.Equal(t, int32(MaxStorageUpdateAttempts), atomic.LoadInt32(&writes))
+}
+
+func TestUpdateStorageObject_UserIDFromToken(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	var readUserIDs []string
+	var versions []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var request ApiReadStorageObjectsRequest
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			readUserIDs = append(readUserIDs, stringValue(request.ObjectIDs[0].UserID))
+			_, _ = w.Write([]byte(`{"objects":[{"collection":"wallet","key":"coins","user_id":"user-id","version":"v1","value":"{}","create_time":"` + now + `","update_time":"` + now + `"}]}`))
+			return
+		}
+		var request ApiWriteStorageObjectsRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		versions = append(versions, *(*request.Objects)[0].Version)
+		_, _ = w.Write([]byte(`{"acks":[{"collection":"wallet","key":"coins"}]}`))
+	})
+	previous := MaxStorageUpdateAttempts
+	MaxStorageUpdateAttempts = 0
+	t.Cleanup(func() { MaxStorageUpdateAttempts = previous })
+
+	// Sessions returned by the Authenticate methods carry only the token.
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())}
+	err := client.UpdateStorageObject(session, "wallet", "coins", func(current map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"coins": 1}, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-id"}, readUserIDs)
+	assert.Equal(t, []string{"v1"}, versions, "a non-positive MaxStorageUpdateAttempts still writes once")
+}
+
+func TestClient_TimeoutPrecedence(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	client.ApiClient.TimeoutMs = 50
+	session := &Session{Token: "token"}
+
+	t.Run("default timeout", func(t *testing.T) {
+		_, err := client.GetAccount(session)
+		assert.ErrorContains(t, err, "timed out")
+	})
+
+	t.Run("option overrides the default", func(t *testing.T) {
+		_, err := client.WithTimeout(time.Second).GetAccount(session)
+		assert.NoError(t, err)
+		assert.Equal(t, 50, client.ApiClient.TimeoutMs, "the original client keeps its timeout")
+	})
+
+	t.Run("context deadline before the timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := client.WithTimeout(time.Second).WithContext(ctx).GetAccount(session)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("timeout before the context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err := client.WithContext(ctx).GetAccount(session)
+		assert.ErrorContains(t, err, "timed out")
+		assert.Less(t, time.Since(start), 150*time.Millisecond)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.WithTimeout(time.Second).WithContext(ctx).GetAccount(session)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestAuthenticateDeviceAndFetch(t *testing.T) {
+	var paths []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v2/account" {
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "))
+			_, _ = w.Write([]byte(`{"user":{"id":"user-id","username":"alice"},"devices":[{"id":"device-id"}]}`))
+			return
+		}
+		writeSessionResponse(w)
+	})
+
+	session, account, err := client.AuthenticateDeviceAndFetch("device-id", nil, nil, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, session.Created)
+	assert.Equal(t, "alice", *account.User.Username)
+	assert.Equal(t, []string{"/v2/account/authenticate/device", "/v2/account"}, paths)
+}
+
+func TestNotification_DecodeContent(t *testing.T) {
+	code := func(c int) *int { return &c }
+
+	content, err := (&Notification{Code: code(SystemNotificationFriendRequest), Content: map[string]interface{}{"username": "alice"}}).DecodeContent()
+	assert.NoError(t, err)
+	assert.Equal(t, &FriendRequestNotification{Username: "alice"}, content)
+
+	content, err = (&Notification{Code: code(SystemNotificationGroupAccept), Content: map[string]interface{}{"name": "Guild"}}).DecodeContent()
+	assert.NoError(t, err)
+	assert.Equal(t, &GroupAcceptNotification{Name: "Guild"}, content)
+
+	raw := map[string]interface{}{"reward": float64(100)}
+	content, err = (&Notification{Code: code(42), Content: raw}).DecodeContent()
+	assert.NoError(t, err)
+	assert.Equal(t, raw, content)
+
+	_, err = (&Notification{Code: code(SystemNotificationFriendAccept), Content: map[string]interface{}{"username": 7}}).DecodeContent()
+	assert.Error(t, err)
+}
+
+func TestNotification_DecodeContentGroupRoleChange(t *testing.T) {
+	code := 110
+
+	content, err := (&Notification{Code: &code, Content: map[string]interface{}{"group_id": "g1", "state": float64(1)}}).DecodeContent()
+	assert.NoError(t, err)
+	assert.IsType(t, map[string]interface{}{}, content, "codes are only decoded when passed")
+
+	content, err = (&Notification{Code: &code, Content: map[string]interface{}{"group_id": "g1", "state": float64(GroupStateAdmin)}}).DecodeContent(code)
+	assert.NoError(t, err)
+	assert.Equal(t, &GroupRoleChangeNotification{GroupID: "g1", State: GroupStateAdmin}, content)
+}
+
+func TestListFriendsWithPresence(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	times := `"create_time":"` + now + `","update_time":"` + now + `"`
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "50", r.URL.Query().Get("limit"))
+		assert.False(t, r.URL.Query().Has("cursor"))
+		_, _ = w.Write([]byte(`{"friends":[` +
+			`{"user":{"id":"a","online":true,` + times + `}},` +
+			`{"user":{"id":"b",` + times + `},"state":2},` +
+			`{"user":{"id":"c",` + times + `}}]}`))
+	})
+
+	friends, err := client.ListFriendsWithPresence(&Session{Token: "token"}, 50, "")
+	assert.NoError(t, err)
+
+	assert.True(t, *friends.Friends[0].User.Online)
+	assert.False(t, *friends.Friends[1].User.Online)
+	assert.Equal(t, FriendStateMutual, *friends.Friends[2].State)
+
+	byState := friends.ByState()
+	assert.Len(t, byState[FriendStateMutual], 2)
+	assert.Equal(t, "b", *byState[FriendStateIncoming][0].User.ID)
+	assert.Empty(t, byState[FriendStateBlocked])
+}
+
+func TestGetUserByUsername(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("usernames") == "alice" {
+			_, _ = w.Write([]byte(`{"users":[{"id":"a","username":"alice","metadata":"{\"level\":3}","create_time":"` + now + `","update_time":"` + now + `"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	session := &Session{Token: "token"}
+
+	user, err := client.GetUserByUsername(session, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *user.ID)
+	assert.Equal(t, 3.0, user.Metadata["level"])
+
+	_, err = client.GetUserByUsername(session, "bob")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	_, err = client.GetUserById(session, "missing")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestListLeaderboardRecordsFromRank(t *testing.T) {
+	// A leaderboard of 25 records paged by offset cursors such as "o10".
+	var requests int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset := 0
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			offset, _ = strconv.Atoi(strings.TrimPrefix(cursor, "o"))
+		}
+		var records []string
+		for rank := offset + 1; rank <= min(offset+limit, 25); rank++ {
+			records = append(records, fmt.Sprintf(`{"owner_id":"u%d","rank":"%d","score":"%d"}`, rank, rank, 1000-rank))
+		}
+		next := ""
+		if offset+limit < 25 {
+			next = fmt.Sprintf(`,"next_cursor":"o%d"`, offset+limit)
+		}
+		_, _ = fmt.Fprintf(w, `{"records":[%s],"rank_count":"25"%s}`, strings.Join(records, ","), next)
+	})
+	session := &Session{Token: "token"}
+
+	t.Run("seeks to the page holding the rank", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		list, err := client.ListLeaderboardRecordsFromRank(session, "leaderboard", 17, 5, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+		assert.Len(t, list.Records, 4)
+		assert.Equal(t, int64(17), *list.Records[0].Rank)
+		assert.Equal(t, "o20", *list.NextCursor)
+	})
+
+	t.Run("first rank needs one request", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		list, err := client.ListLeaderboardRecordsFromRank(session, "leaderboard", 1, 10, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+		assert.Len(t, list.Records, 10)
+	})
+
+	t.Run("rank beyond the leaderboard", func(t *testing.T) {
+		list, err := client.ListLeaderboardRecordsFromRank(session, "leaderboard", 26, 10, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, list.Records)
+		assert.Nil(t, list.NextCursor)
+	})
+
+	t.Run("invalid rank", func(t *testing.T) {
+		_, err := client.ListLeaderboardRecordsFromRank(session, "leaderboard", 0, 10, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestStorageIdBuilders(t *testing.T) {
+	ids := ReadIds("saves", "slot1", "slot2")
+	assert.Len(t, ids, 2)
+	assert.Equal(t, "saves", *ids[1].Collection)
+	assert.Equal(t, "slot2", *ids[1].Key)
+	assert.Nil(t, ids[1].UserID)
+
+	ids = UserReadIds("user-id", "saves", "slot1")
+	assert.Equal(t, "user-id", *ids[0].UserID)
+
+	deletes := DeleteIds("saves", "slot1", "slot2")
+	assert.Equal(t, "slot1", *deletes[0].Key)
+	assert.Nil(t, deletes[0].Version)
+}
+
+func TestStorageWrites(t *testing.T) {
+	objects := NewStorageWrites("saves").
+		Put("slot1", map[string]interface{}{"level": 1}).Permissions(PermissionReadPublic, PermissionWriteOwner).
+		Put("slot2", map[string]interface{}{"level": 2}).Version("*").
+		Collection("settings").
+		Put("audio", map[string]interface{}{"volume": 5}).
+		Objects()
+
+	assert.Len(t, objects, 3)
+	assert.Equal(t, "slot1", *objects[0].Key)
+	assert.Equal(t, PermissionReadPublic, *objects[0].PermissionRead)
+	assert.Nil(t, objects[0].Version)
+	assert.Equal(t, "*", *objects[1].Version)
+	assert.Nil(t, objects[1].PermissionRead)
+	assert.Equal(t, "saves", *objects[1].Collection)
+	assert.Equal(t, "settings", *objects[2].Collection)
+	assert.NoError(t, ValidateStorageObjects(objects))
+}
+
+func TestWriteStorageObjects_RejectedBatch(t *testing.T) {
+	message := "Storage write rejected - version check failed."
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, `{"code":3,"message":%q}`, message)
+	})
+	session := &Session{Token: "token"}
+	objects := NewStorageWrites("saves").
+		Put("slot1", map[string]interface{}{"level": 1}).
+		Put("slot2", map[string]interface{}{"level": 2}).Version("v1").
+		Objects()
+
+	_, err := client.WriteStorageObjects(session, objects)
+	var writeErr *StorageWriteError
+	assert.ErrorAs(t, err, &writeErr)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.Len(t, writeErr.Objects, 2)
+	assert.Equal(t, []int{1}, writeErr.Failed, "only the versioned write can fail a version check")
+	assert.Equal(t, "slot2", *writeErr.FailedObjects()[0].Key)
+
+	// Without a narrower cause every object of the batch is a candidate.
+	message = "Storage write rejected - permission denied."
+	_, err = client.WriteStorageObjects(session, objects)
+	assert.ErrorAs(t, err, &writeErr)
+	assert.NotErrorIs(t, err, ErrVersionMismatch)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+	assert.Equal(t, []int{0, 1}, writeErr.Failed)
+}
+
+func TestCountNotifications(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	var requests int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "100", r.URL.Query().Get("limit"))
+		// 230 notifications, paged by offset cursors such as "o100".
+		offset, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("cacheable_cursor"), "o"))
+		var notifications []string
+		for i := offset; i < min(offset+100, 230); i++ {
+			notifications = append(notifications, fmt.Sprintf(`{"id":"n%d","create_time":"%s"}`, i, now))
+		}
+		_, _ = fmt.Fprintf(w, `{"notifications":[%s],"cacheable_cursor":"o%d"}`, strings.Join(notifications, ","), offset+len(notifications))
+	})
+
+	count, err := client.CountNotifications(&Session{Token: "token"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 230, count)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestListNewNotifications(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		switch r.URL.Query().Get("cacheable_cursor") {
+		case "":
+			_, _ = fmt.Fprintf(w, `{"notifications":[{"id":"n1","create_time":"%s"}],"cacheable_cursor":"c1"}`, now)
+		case "c1":
+			_, _ = w.Write([]byte(`{"notifications":[]}`))
+		}
+	})
+	session := &Session{Token: "token"}
+
+	list, err := client.ListNewNotifications(session, "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, list.Notifications, 1)
+	assert.Equal(t, "c1", *list.CacheableCursor)
+
+	list, err = client.ListNewNotifications(session, *list.CacheableCursor, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Notifications)
+	assert.Equal(t, "c1", *list.CacheableCursor, "an empty page keeps the cursor")
+}
+
+// setupSilentServer starts a TCP server that accepts connections and never responds.
+func setupSilentServer(t *testing.T) (string, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		_ = listener.Close()
+		<-done
+	})
+	go func() {
+		defer close(done)
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				for _, conn := range conns {
+					_ = conn.Close()
+				}
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port
+}
+
+func TestClient_ConnectTimeout(t *testing.T) {
+	host, port := setupSilentServer(t)
+	timeout := 5000
+	client := NewClient("defaultkey", host, port, true, &timeout, nil)
+	client.SetConnectTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	_, err := client.GetAccount(&Session{Token: "token"})
+
+	assert.ErrorContains(t, err, "TLS handshake timeout")
+	assert.Less(t, time.Since(start), 2*time.Second, "the connect timeout must apply before the request timeout")
+}
+
+func TestFilterActiveTournaments(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	now := time.Unix(1_700_000_000, 0)
+	id := func(s string) *string { return &s }
+
+	tournaments := []Tournament{
+		{ID: id("running"), StartActive: intPtr(int(now.Unix()) - 60), EndActive: intPtr(int(now.Unix()) + 60)},
+		{ID: id("between-windows"), StartActive: intPtr(int(now.Unix()) - 120), EndActive: intPtr(int(now.Unix()) - 60), NextReset: intPtr(int(now.Unix()) + 3600)},
+		{ID: id("not-started"), StartActive: intPtr(int(now.Unix()) + 60)},
+		{ID: id("open-ended"), StartActive: intPtr(int(now.Unix()) - 60)},
+		{ID: id("no-window")},
+	}
+
+	active := FilterActiveTournaments(tournaments, now)
+
+	var ids []string
+	for _, tournament := range active {
+		ids = append(ids, *tournament.ID)
+	}
+	assert.Equal(t, []string{"running", "open-ended"}, ids)
+	assert.Empty(t, FilterActiveTournaments(nil, now))
+}
+
+func TestListActiveTournaments(t *testing.T) {
+	now := time.Now()
+	times := fmt.Sprintf(`"create_time":"%s","start_time":"%s","end_time":"1970-01-01T00:00:00Z"`, now.Format(time.RFC3339), now.Format(time.RFC3339))
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"tournaments":[`+
+			`{"id":"full","can_enter":false,"start_active":%d,"end_active":%d,%s},`+
+			`{"id":"ended","start_active":%d,"end_active":%d,%s}],"cursor":"next"}`,
+			now.Unix()-60, now.Unix()+60, times, now.Unix()-120, now.Unix()-60, times)
+	})
+
+	list, err := client.ListActiveTournaments(&Session{Token: "token"}, nil, nil, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, list.Tournaments, 1)
+	assert.Equal(t, "full", *list.Tournaments[0].ID, "tournaments the user may have joined are kept even if full")
+	assert.Equal(t, "next", *list.Cursor)
+}
+
+func TestDeleteTournamentRecord(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		if r.URL.Path == "/v2/tournament/authoritative" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":7,"message":"Tournament only allows authoritative score deletions."}`))
+			return
+		}
+		assert.Equal(t, "/v2/tournament/weekly", r.URL.Path)
+		_, _ = w.Write([]byte(`{}`))
+	})
+	session := &Session{Token: "token"}
+
+	deleted, err := client.DeleteTournamentRecord(session, "weekly")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = client.DeleteTournamentRecord(session, "authoritative")
+	assert.ErrorIs(t, err, ErrRecordDeletionNotAllowed)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.False(t, deleted)
+}
+
+func TestLinkCustomWithConflictResolution(t *testing.T) {
+	var createParams []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/account/link/custom":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"Custom ID is already in use.","code":6,"message":"Custom ID is already in use."}`))
+		case "/v2/account/authenticate/custom":
+			createParams = append(createParams, r.URL.Query().Get("create"))
+			writeSessionResponse(w)
+		}
+	})
+	session := &Session{Token: makeTestToken(time.Now().Add(3 * time.Hour).Unix())}
+	id := "custom-id"
+
+	_, err := client.LinkCustom(session, &ApiAccountCustom{ID: &id})
+	assert.ErrorIs(t, err, ErrLinkConflict)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	var apiError *ApiError
+	assert.ErrorAs(t, err, &apiError)
+
+	_, err = client.LinkCustomWithConflictResolution(session, &ApiAccountCustom{ID: &id}, nil)
+	assert.ErrorIs(t, err, ErrLinkConflict)
+	assert.Empty(t, createParams)
+
+	resolved, err := client.LinkCustomWithConflictResolution(session, &ApiAccountCustom{ID: &id}, func(existing *Session) (*Session, error) {
+		assert.NotEqual(t, session.Token, existing.Token)
+		return existing, nil
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, session.Token, resolved.Token)
+	assert.Equal(t, []string{"false"}, createParams, "resolving a conflict must not create an account")
+}
+
+func TestRpcTyped(t *testing.T) {
+	type reward struct {
+		Item  string `json:"item"`
+		Count int    `json:"count"`
+	}
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/rpc/claim_reward", r.URL.Path)
+		var body string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.JSONEq(t, `{"day":3}`, body)
+		_, _ = w.Write([]byte(`{"id":"claim_reward","payload":"{\"item\":\"gem\",\"count\":5}"}`))
+	})
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())}
+
+	result, err := RpcTyped[reward](client, session, "claim_reward", struct {
+		Day int `json:"day"`
+	}{Day: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, &reward{Item: "gem", Count: 5}, result)
+
+	_, err = RpcTyped[[]reward](client, session, "claim_reward", map[string]int{"day": 3})
+	assert.ErrorContains(t, err, "failed to decode rpc payload")
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	revoked := makeTestToken(time.Now().Add(3 * time.Hour).Unix())
+	var refreshes, rejected int32
+	var failRefresh atomic.Bool
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/account/session/refresh" {
+			atomic.AddInt32(&refreshes, 1)
+			if failRefresh.Load() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+			writeSessionResponse(w)
+			return
+		}
+		if r.Header.Get("Authorization") == "Bearer "+revoked {
+			atomic.AddInt32(&rejected, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"Auth token invalid","code":16,"message":"Auth token invalid"}`))
+			return
+		}
+		var body string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.JSONEq(t, `{"n":1}`, body, "the retried request must carry the original body")
+		_, _ = w.Write([]byte(`{"id":"echo","payload":"{}"}`))
+	})
+
+	// The token looks valid locally, so it is not refreshed before the requests.
+	session := NewSession(revoked, makeTestToken(time.Now().Add(4*time.Hour).Unix()), false)
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Rpc(session, "echo", map[string]interface{}{"n": 1})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes), "concurrent rejections share one refresh")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&rejected))
+	assert.NotEqual(t, revoked, session.Token)
+
+	// A failed refresh gives up with the original error.
+	failRefresh.Store(true)
+	session = NewSession(revoked, makeTestToken(time.Now().Add(4*time.Hour).Unix()), false)
+	_, err := client.Rpc(session, "echo", map[string]interface{}{"n": 1})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, revoked, session.Token)
+
+	// Without auto refresh the rejection is returned as is.
+	failRefresh.Store(false)
+	_, err = client.WithoutAutoRefresh().Rpc(session, "echo", map[string]interface{}{"n": 1})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&refreshes))
+}
+
+func TestParseToken(t *testing.T) {
+	payload := `{"uid":"user-id","usn":"user","exp":1700000000,"vrs":{"region":"eu"}}`
+	token := "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+
+	claims, err := ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, TokenClaims{UserID: "user-id", Username: "user", ExpiresAt: 1700000000, Vars: map[string]string{"region": "eu"}}, claims)
+
+	padded := "e30." + base64.URLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	claims, err = ParseToken(padded)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-id", claims.UserID)
+
+	for name, malformed := range map[string]string{
+		"empty":          "",
+		"two segments":   "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)),
+		"four segments":  token + ".extra",
+		"not base64url":  "e30.a+b/c.sig",
+		"not json":       "e30." + base64.RawURLEncoding.EncodeToString([]byte("not json")) + ".sig",
+		"missing expiry": "e30." + base64.RawURLEncoding.EncodeToString([]byte(`{"uid":"user-id"}`)) + ".sig",
+	} {
+		_, err := ParseToken(malformed)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func TestStorageRawValueRoundTrip(t *testing.T) {
+	const raw = `{"zeta":1.50,"alpha":[1e3,2]}`
+	var written string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var request ApiWriteStorageObjectsRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			written = *(*request.Objects)[0].Value
+			_, _ = w.Write([]byte(`{"acks":[{"collection":"saves","key":"slot1","version":"v2"}]}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"objects": []map[string]interface{}{{
+			"collection": "saves", "key": "slot1", "user_id": "user-id", "version": "v1", "value": raw,
+			"create_time": "2024-01-01T00:00:00Z", "update_time": "2024-01-01T00:00:00Z",
+		}}})
+	})
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())}
+
+	objects, err := client.ReadStorageObjects(session, &ApiReadStorageObjectsRequest{})
+	assert.NoError(t, err)
+	object := objects.Objects[0]
+	assert.Equal(t, raw, object.RawValue)
+	assert.Equal(t, 1.5, object.Value["zeta"])
+
+	_, err = client.WriteStorageObjects(session, []WriteStorageObject{{
+		Collection: object.Collection, Key: object.Key, Version: object.Version, RawValue: object.RawValue,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, raw, written, "the raw value is written back unchanged")
+
+	_, err = client.WriteStorageObjects(session, []WriteStorageObject{{
+		Collection: object.Collection, Key: object.Key, Version: object.Version, Value: object.Value,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"alpha":[1000,2],"zeta":1.5}`, written, "re-encoding the decoded value is lossy")
+}
+
+func TestPing(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/healthcheck", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	rtt, err := client.Ping(context.Background())
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, rtt, 20*time.Millisecond)
+	assert.Less(t, rtt, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = client.Ping(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRefreshIfNeeded_ExpiredWithoutRefreshToken(t *testing.T) {
+	var calls int32
+	var token, refreshToken string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/account/authenticate/") {
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": token, "refresh_token": refreshToken})
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{}`))
+	})
+	authenticate := func() *Session {
+		session, err := client.AuthenticateCustom("custom-id", nil, nil, nil)
+		assert.NoError(t, err)
+		return session
+	}
+	expired := makeTestToken(time.Now().Add(-time.Minute).Unix())
+
+	token, refreshToken = expired, ""
+	_, err := client.GetAccount(authenticate())
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	token, refreshToken = expired, makeTestToken(time.Now().Add(-time.Second).Unix())
+	_, err = client.GetAccount(authenticate())
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.Zero(t, atomic.LoadInt32(&calls), "no request is made with an expired session")
+
+	// A live session without a refresh token is used as is.
+	token, refreshToken = makeTestToken(time.Now().Add(time.Hour).Unix()), ""
+	_, err = client.GetAccount(authenticate())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestBatch(t *testing.T) {
+	defer func(n int) { MaxBatchParallelism = n }(MaxBatchParallelism)
+	MaxBatchParallelism = 2
+
+	var refreshes, inFlight, maxInFlight int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/account/session/refresh" {
+			atomic.AddInt32(&refreshes, 1)
+			time.Sleep(20 * time.Millisecond)
+			writeSessionResponse(w)
+			return
+		}
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		if r.URL.Path == "/v2/rpc/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"id":"echo","payload":"{}"}`))
+	})
+	// The session expires soon, so the calls of the batch need a refresh.
+	session := NewSession(makeTestToken(time.Now().Add(time.Minute).Unix()), makeTestToken(time.Now().Add(time.Hour).Unix()), false)
+
+	var calls []func(c *Client) error
+	for range 5 {
+		calls = append(calls, func(c *Client) error {
+			_, err := c.Rpc(session, "echo", nil)
+			return err
+		})
+	}
+	assert.NoError(t, client.Batch(context.Background(), calls...))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes), "the calls share one refresh")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+
+	failure := errors.New("failed")
+	var skipped atomic.Bool
+	start := time.Now()
+	err := client.Batch(context.Background(),
+		func(c *Client) error {
+			_, err := c.Rpc(session, "slow", nil)
+			return err
+		},
+		func(c *Client) error { return failure },
+		func(c *Client) error {
+			skipped.Store(true)
+			return nil
+		},
+	)
+	assert.ErrorIs(t, err, failure)
+	assert.Less(t, time.Since(start), time.Second, "the first failure aborts the calls in flight")
+	assert.False(t, skipped.Load(), "calls not yet started are skipped")
+}
+
+func TestCopyStorageCollection(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	var written []ApiWriteStorageObject
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var request ApiWriteStorageObjectsRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			written = append(written, *request.Objects...)
+			_, _ = w.Write([]byte(`{"acks":[]}`))
+			return
+		}
+		assert.Equal(t, "/v2/storage/saves", r.URL.Path)
+		assert.Equal(t, "user-id", r.URL.Query().Get("user_id"))
+		object := func(key, value string) string {
+			return fmt.Sprintf(`{"collection":"saves","key":%q,"user_id":"user-id","value":%q,"version":"v","permission_read":2,"permission_write":1,"create_time":%q,"update_time":%q}`, key, value, now, now)
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = fmt.Fprintf(w, `{"objects":[%s,%s],"cursor":"page2"}`, object("a", `{"n":1.0}`), object("b", `{"n":2}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"objects":[%s]}`, object("c", `{"n":3}`))
+	})
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())} // As returned by the Authenticate methods, without a UserID.
+
+	copied, err := client.CopyStorageCollection(session, "saves", "saves_v2", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, copied)
+	if assert.Len(t, written, 3) {
+		assert.Equal(t, "saves_v2", *written[0].Collection)
+		assert.Equal(t, "a", *written[0].Key)
+		assert.Equal(t, `{"n":1.0}`, *written[0].Value, "values are copied unchanged")
+		assert.Equal(t, PermissionReadPublic, *written[0].PermissionRead)
+		assert.Nil(t, written[0].Version, "objects in the target collection are overwritten")
+		assert.Equal(t, "c", *written[2].Key)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	copied, err = client.WithContext(ctx).CopyStorageCollection(session, "saves", "saves_v2", 2)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, copied)
+}
+
+func TestIdempotencyKey_ReusedAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		attempt := len(keys)
+		mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), `"score":"10"`)
+		if r.Method == http.MethodPost && attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		_, _ = w.Write([]byte(`{"leaderboard_id":"board","score":"10","create_time":"` + now + `","update_time":"` + now + `"}`))
+	})
+	client.ApiClient.MaxRetries = 2
+	client.Backoff = BackoffConfig{}
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())}
+	score := "10"
+
+	_, err := client.WriteLeaderboardRecord(session, "board", &WriteLeaderboardRecord{Score: &score})
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, []string{keys[0], keys[0], keys[0]}, keys)
+
+	first := keys[0]
+	keys = nil
+	_, err = client.WriteLeaderboardRecord(session, "board", &WriteLeaderboardRecord{Score: &score})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, keys[0], "each write gets its own key")
+
+	keys = nil
+	client.ApiClient.MaxRetries = 0
+	_, err = client.WithIdempotencyKey("order-1").WriteLeaderboardRecord(session, "board", &WriteLeaderboardRecord{Score: &score})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"order-1"}, keys)
+}
+
+func TestServerInfo(t *testing.T) {
+	var requests atomic.Int32
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/v2/rpc/server_info", r.URL.Path)
+		assert.Equal(t, "httpkey", r.URL.Query().Get("http_key"))
+		_, _ = w.Write([]byte(`{"id":"server_info","payload":"{\"version\":\"3.21.0\",\"max_username_length\":8}"}`))
+	})
+
+	_, err := client.ServerInfo(context.Background())
+	assert.Error(t, err, "no HttpKey")
+
+	client.HttpKey = "httpkey"
+	info, err := client.ServerInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &ServerInfo{Version: "3.21.0", MaxUsernameLength: 8, MaxMessageSizeBytes: DefaultReadLimit}, info)
+
+	info, err = client.WithTimeout(time.Second).ServerInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "3.21.0", info.Version)
+	assert.Equal(t, int32(1), requests.Load(), "the info is cached")
+
+	username := "longer-than-eight"
+	_, err = client.AuthenticateCustom("custom-id-123", nil, &username, nil)
+	assert.ErrorContains(t, err, "must be at most 8 bytes")
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestAccessors_NilSafe(t *testing.T) {
+	var friend *Friend
+	assert.Nil(t, friend.GetUser())
+	assert.Equal(t, "", friend.GetUser().GetDisplayName())
+	assert.Equal(t, 0, (&Friend{}).GetState())
+
+	name := "Ada"
+	online := true
+	friend = &Friend{User: &User{DisplayName: &name, Online: &online}}
+	assert.Equal(t, "Ada", friend.GetUser().GetDisplayName())
+	assert.True(t, friend.GetUser().GetOnline())
+	assert.Equal(t, "", friend.GetUser().GetAvatarURL())
+
+	var record *LeaderboardRecord
+	assert.Equal(t, int64(0), record.GetScore())
+	rank := int64(3)
+	assert.Equal(t, int64(3), (&LeaderboardRecord{Rank: &rank}).GetRank())
+	assert.Equal(t, "", (&UserGroup{}).GetGroup().GetName())
+}
+
+func TestAdminToken(t *testing.T) {
+	var mu sync.Mutex
+	var auth []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":"admin_rpc","payload":"{}"}`))
+	})
+	client.ApiClient.AdminToken = "admin-token"
+
+	_, err := client.Rpc(nil, "admin_rpc", nil)
+	assert.NoError(t, err)
+	session := &Session{Token: makeTestToken(time.Now().Add(time.Hour).Unix())}
+	_, err = client.Rpc(session, "admin_rpc", nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Bearer admin-token", "Bearer " + session.Token}, auth)
+}
+
+func TestSessionFromToken(t *testing.T) {
+	client := NewClient("defaultkey", "127.0.0.1", "7350", false, nil, nil)
+	valid := makeTestToken(time.Now().Add(time.Hour).Unix())
+	expired := makeTestToken(time.Now().Add(-time.Hour).Unix())
+	refresh := makeTestToken(time.Now().Add(2 * time.Hour).Unix())
+
+	session, err := client.SessionFromToken(valid, refresh)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-id", *session.UserID)
+	assert.Equal(t, refresh, session.RefreshToken)
+	assert.False(t, session.Created)
+
+	_, err = client.SessionFromToken(expired, "")
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	_, err = client.SessionFromToken(expired, expired)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	session, err = client.SessionFromToken(expired, refresh)
+	assert.NoError(t, err, "an expired token is refreshed on first use")
+	assert.True(t, session.IsExpired(time.Now().Unix()))
+	_, err = client.WithoutAutoRefresh().SessionFromToken(expired, refresh)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	_, err = client.SessionFromToken("not-a-token", "")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = client.SessionFromToken(valid, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestSessionLogout_NoRefreshOnUnauthorized(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v2/account/session/refresh":
+			writeSessionResponse(w)
+		case "/v2/account":
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"user":{"id":"user-id"}}`))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	session := NewSession(makeTestToken(time.Now().Add(time.Hour).Unix()), makeTestToken(time.Now().Add(2*time.Hour).Unix()), false)
+	_, err := client.GetAccount(session)
+	assert.NoError(t, err)
+
+	_, err = client.SessionLogout(session, session.Token, session.RefreshToken)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	_, err = client.DeleteAccount(session)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	assert.Equal(t, []string{"/v2/account", "/v2/session/logout", "/v2/account"}, paths)
+}
+
+func TestDeleteLeaderboardRecordsForOwners(t *testing.T) {
+	sessions := []*Session{
+		{Token: makeTestToken(time.Now().Add(time.Hour).Unix())},
+		{Token: makeTestToken(time.Now().Add(time.Hour).Unix())},
+		{Token: makeTestToken(time.Now().Add(time.Hour).Unix())},
+	}
+
+	t.Run("one owner fails", func(t *testing.T) {
+		var requests int32
+		client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 2 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"code":5,"message":"Leaderboard not found."}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		})
+
+		err := client.DeleteLeaderboardRecordsForOwners(context.Background(), sessions, "board")
+		var deleteErr *LeaderboardDeleteError
+		assert.ErrorAs(t, err, &deleteErr)
+		assert.Equal(t, []int{1}, slices.Sorted(maps.Keys(deleteErr.Failures)))
+		var apiErr *ApiError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "the other owners must still be deleted")
+	})
+
+	t.Run("cancelled mid-run", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var requests int32
+		client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 2 {
+				// Cancel while the second call is in flight.
+				cancel()
+				<-r.Context().Done()
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		})
+
+		err := client.DeleteLeaderboardRecordsForOwners(ctx, sessions, "board")
+		var deleteErr *LeaderboardDeleteError
+		assert.ErrorAs(t, err, &deleteErr)
+		assert.Equal(t, []int{1, 2}, slices.Sorted(maps.Keys(deleteErr.Failures)))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests), "no call may be made after cancellation")
+	})
+}
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		valid    bool
+	}{
+		{"empty", "", true},
+		{"plain", "player_1", true},
+		{"inner space", "john doe", true},
+		{"multi-byte", "José", true},
+		{"at the byte limit", strings.Repeat("x", MaxUsernameLength), true},
+		{"multi-byte at the byte limit", strings.Repeat("é", MaxUsernameLength/2), true},
+		{"over the byte limit", strings.Repeat("x", MaxUsernameLength+1), false},
+		{"multi-byte over the byte limit", strings.Repeat("é", MaxUsernameLength/2+1), false},
+		{"tab", "john\tdoe", false},
+		{"newline", "john\n", false},
+		{"no-break space", "john\u00a0doe", true},
+		{"line separator", "john\u2028doe", true},
+		{"control", "john\x00", false},
+		{"delete", "john\x7f", false},
+	}
+	assert.NoError(t, ValidateUsername(nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUsername(&tt.username)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "invalid username")
+			}
+		})
+	}
+}
+
+func TestAuthenticate_ValidateUsernames(t *testing.T) {
+	var username string
+	called := false
+	invalid := "john\tdoe"
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		username = r.URL.Query().Get("username")
+		writeSessionResponse(w)
+	})
+
+	_, err := client.AuthenticateDevice("device-id", nil, &invalid, nil)
+	assert.ErrorContains(t, err, "invalid username")
+	assert.False(t, called)
+
+	// With validation off, the server has the final say.
+	client.ValidateUsernames = false
+	_, err = client.AuthenticateDevice("device-id", nil, &invalid, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, invalid, username)
+}
+
+func TestSessionRefresh_RetriesWithBackoff(t *testing.T) {
+	var requests int32
+	status := http.StatusServiceUnavailable
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(status)
+			return
+		}
+		writeSessionResponse(w)
+	})
+	client.Backoff = BackoffConfig{Base: time.Millisecond, Factor: 2, Jitter: JitterNone}
+	session := &Session{RefreshToken: makeTestToken(time.Now().Add(time.Hour).Unix())}
+
+	_, err := client.SessionRefresh(session, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// A rejected refresh token is not retried.
+	atomic.StoreInt32(&requests, 0)
+	status = http.StatusUnauthorized
+	_, err = client.SessionRefresh(session, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestCreateSocket_ReconnectsWithClientBackoff(t *testing.T) {
+	client := NewClient("defaultkey", "127.0.0.1", "1", false, nil, nil)
+	client.Backoff = BackoffConfig{Base: time.Minute, Jitter: JitterNone}
+	socket := client.CreateSocket(false, false, nil, nil)
+	assert.Equal(t, time.Minute, socket.Backoff.Base)
+
+	// With a zero backoff, the reconnect waits the client's delay rather than dialling at once.
+	result := make(chan error, 1)
+	go func() {
+		_, err := socket.Reconnect(Session{Token: "token"}, BackoffConfig{}, 1, nil)
+		result <- err
+	}()
+	assert.Eventually(t, func() bool {
+		return socket.Adapter.State() == ConnectionStateReconnecting
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	socket.Disconnect(false)
+	assert.EqualError(t, <-result, "socket reconnect stopped by disconnect")
+}
+
+func TestLeaderboardRecordFromApi_LargeScore(t *testing.T) {
+	score := strconv.FormatInt(math.MaxInt32+1, 10)
+	subscore := "9007199254740993"
+	rank := "1"
+
+	record, err := leaderboardRecordFromApi(ApiLeaderboardRecord{Score: &score, Subscore: &subscore, Rank: &rank})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt32+1), *record.Score)
+	assert.Equal(t, int64(9007199254740993), *record.SubScore)
+	assert.Equal(t, int64(1), *record.Rank)
+}
+
+func TestLeaderboardRecordFromApi_MalformedScore(t *testing.T) {
+	score := "12abc"
+
+	record, err := leaderboardRecordFromApi(ApiLeaderboardRecord{Score: &score})
+
+	assert.Error(t, err)
+	assert.Nil(t, record)
+}
@@ -0,0 +1,25 @@
+package nakama
+
+import "encoding/json"
+
+// Codec is the JSON encoding used by NakamaApi and WebSocketAdapter. Its method set matches the
+// Marshal and Unmarshal functions of encoding/json, so that a faster library such as jsoniter or
+// sonic can be plugged in through a small wrapper.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// StdCodec is a Codec that uses encoding/json. It is the default codec.
+type StdCodec struct{}
+
+func (StdCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (StdCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// Helper function to fall back to encoding/json when no codec is configured.
+func codecOrStd(codec Codec) Codec {
+	if codec == nil {
+		return StdCodec{}
+	}
+	return codec
+}
@@ -0,0 +1,230 @@
+package nakama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrClientClosed is returned by requests made through a Client after Close has been called.
+var ErrClientClosed = errors.New("client closed")
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed and cannot have been issued by the server.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrResponseTooLarge is returned when a response body exceeds NakamaApi.MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrStorageObjectNotFound is returned by ReadStorageObject when the object does not exist.
+var ErrStorageObjectNotFound = errors.New("storage object not found")
+
+// ErrSessionExpired is returned before a request when the session has expired and has no refresh
+// token, or its refresh token has expired too. Authenticate again to get a new session.
+var ErrSessionExpired = errors.New("session expired")
+
+// ErrInvalidToken is returned by ParseToken for a token that is not a well-formed session token.
+var ErrInvalidToken = errors.New("invalid session token")
+
+// ErrUserNotFound is returned by GetUserById and GetUserByUsername when there is no such user.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrStoragePermission is returned before a storage write that sets a permission clients may not set,
+// such as public write, which only the server can grant.
+var ErrStoragePermission = errors.New("storage permission not allowed for clients")
+
+// ErrVersionMismatch is returned when a storage write is rejected because the object's version has
+// changed since it was read.
+var ErrVersionMismatch = errors.New("storage object version mismatch")
+
+// ErrLinkConflict is returned when linking an ID that is already linked to another account. It
+// wraps the server's ErrAlreadyExists.
+var ErrLinkConflict = errors.New("already linked to another account")
+
+// ErrRecordDeletionNotAllowed is returned by DeleteTournamentRecord when the tournament only allows
+// the server to delete records.
+var ErrRecordDeletionNotAllowed = errors.New("record deletion not allowed")
+
+// ErrUnknownTicket is returned by RemoveMatchmaker for a ticket that is not outstanding on the socket.
+var ErrUnknownTicket = errors.New("unknown matchmaker ticket")
+
+// ErrMatchSignalUnsupported is returned by MatchSignal when the server or match does not handle
+// match signals from clients.
+var ErrMatchSignalUnsupported = errors.New("match signal not supported")
+
+// ErrStatusTooLong is returned by UpdateStatus and UpdateStatusJSON for a status longer than
+// MaxStatusLength, which the server would reject.
+var ErrStatusTooLong = errors.New("status too long")
+
+// ErrMessageTooLarge is reported when a WebSocket connection is closed because a message exceeded
+// the adapter's read limit or the server's message size limit.
+var ErrMessageTooLarge = errors.New("websocket message too large")
+
+// ErrNotGroupAdmin is returned when the user lacks the group role required for an operation.
+var ErrNotGroupAdmin = errors.New("user is not a group admin")
+
+// ErrNotGroupMember is returned by GetGroupUserRole when the user is not a member of the group.
+var ErrNotGroupMember = errors.New("user is not a group member")
+
+// ErrSequencedMatchPresences is returned by SendMatchState for data addressed to some presences while
+// SetMatchDataSequencing is on, as the other players would see a gap in the sequence.
+var ErrSequencedMatchPresences = errors.New("sequenced match data cannot be sent to some presences only")
+
+// StorageWriteError is returned by WriteStorageObjects when the server rejects a batch. The server
+// writes a batch all-or-nothing, so none of Objects were written.
+type StorageWriteError struct {
+	Objects []WriteStorageObject // The objects of the rejected batch.
+	Failed  []int                // The indices in Objects of the objects that may have caused the rejection.
+	Err     error                // The server's error.
+}
+
+// Error implements the error interface.
+func (e *StorageWriteError) Error() string {
+	return fmt.Sprintf("storage write of %d objects rejected: %v", len(e.Objects), e.Err)
+}
+
+// Unwrap returns the server's error, so that errors.Is works.
+func (e *StorageWriteError) Unwrap() error {
+	return e.Err
+}
+
+// FailedObjects returns the objects that may have caused the rejection.
+func (e *StorageWriteError) FailedObjects() []WriteStorageObject {
+	failed := make([]WriteStorageObject, len(e.Failed))
+	for i, index := range e.Failed {
+		failed[i] = e.Objects[index]
+	}
+	return failed
+}
+
+// Sentinel errors for the gRPC status codes returned by the server. Use errors.Is to test an
+// error returned by the client against them.
+var (
+	ErrCanceled           = errors.New("canceled")
+	ErrUnknown            = errors.New("unknown error")
+	ErrInvalidArgument    = errors.New("invalid argument")
+	ErrDeadlineExceeded   = errors.New("deadline exceeded")
+	ErrNotFound           = errors.New("not found")
+	ErrAlreadyExists      = errors.New("already exists")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrResourceExhausted  = errors.New("resource exhausted")
+	ErrFailedPrecondition = errors.New("failed precondition")
+	ErrAborted            = errors.New("aborted")
+	ErrOutOfRange         = errors.New("out of range")
+	ErrUnimplemented      = errors.New("unimplemented")
+	ErrInternal           = errors.New("internal error")
+	ErrUnavailable        = errors.New("unavailable")
+	ErrDataLoss           = errors.New("data loss")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+)
+
+// grpcCodeErrors maps gRPC status codes to their sentinel errors.
+var grpcCodeErrors = map[int]error{
+	1:  ErrCanceled,
+	2:  ErrUnknown,
+	3:  ErrInvalidArgument,
+	4:  ErrDeadlineExceeded,
+	5:  ErrNotFound,
+	6:  ErrAlreadyExists,
+	7:  ErrPermissionDenied,
+	8:  ErrResourceExhausted,
+	9:  ErrFailedPrecondition,
+	10: ErrAborted,
+	11: ErrOutOfRange,
+	12: ErrUnimplemented,
+	13: ErrInternal,
+	14: ErrUnavailable,
+	15: ErrDataLoss,
+	16: ErrUnauthenticated,
+}
+
+// httpStatusErrors maps HTTP status codes to sentinel errors when the body carries no gRPC code.
+var httpStatusErrors = map[int]error{
+	http.StatusBadRequest:          ErrInvalidArgument,
+	http.StatusUnauthorized:        ErrUnauthenticated,
+	http.StatusForbidden:           ErrPermissionDenied,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrAlreadyExists,
+	http.StatusTooManyRequests:     ErrResourceExhausted,
+	http.StatusInternalServerError: ErrInternal,
+	http.StatusNotImplemented:      ErrUnimplemented,
+	http.StatusBadGateway:          ErrUnavailable,
+	http.StatusServiceUnavailable:  ErrUnavailable,
+	http.StatusGatewayTimeout:      ErrDeadlineExceeded,
+}
+
+// ApiError is an error response from the server.
+type ApiError struct {
+	StatusCode  int    // The HTTP status code.
+	Status      string // The HTTP status line, e.g. "404 Not Found".
+	Code        int    // The gRPC status code, or 0 if the body did not carry one.
+	Message     string // The server's error message, or a snippet of the body if it is not JSON.
+	Err         error  // The sentinel error for the status, or nil if it is not recognised.
+	ContentType string // The media type of the body, e.g. "text/html" for a proxy's error page.
+}
+
+// Error implements the error interface.
+func (e *ApiError) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return e.Status + ": " + e.Message
+}
+
+// Unwrap returns the sentinel error for the status, so that errors.Is works.
+func (e *ApiError) Unwrap() error {
+	return e.Err
+}
+
+// maxBodySnippet is the length of the body snippet kept from a response that is not JSON.
+const maxBodySnippet = 256
+
+// newApiError decodes an error response. Nakama error bodies look like
+// {"error": "...", "code": 5, "message": "..."}. Other bodies, such as the HTML page of a proxy in
+// front of the server, are kept as a snippet in Message.
+func newApiError(resp *http.Response, body []byte) *ApiError {
+	mediaType, params := responseContentType(resp)
+	apiError := &ApiError{StatusCode: resp.StatusCode, Status: resp.Status, ContentType: mediaType}
+	if decoded, err := decodeCharset(body, params["charset"]); err == nil {
+		body = decoded
+	}
+
+	var payload struct {
+		Error   string `json:"error"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		apiError.Code = payload.Code
+		apiError.Message = payload.Message
+		if apiError.Message == "" {
+			apiError.Message = payload.Error
+		}
+	} else {
+		apiError.Message = bodySnippet(body)
+	}
+
+	if sentinel, ok := grpcCodeErrors[apiError.Code]; ok {
+		apiError.Err = sentinel
+	} else {
+		apiError.Err = httpStatusErrors[resp.StatusCode]
+	}
+	return apiError
+}
+
+// bodySnippet returns the start of a response body on a single line, for error messages.
+func bodySnippet(body []byte) string {
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > maxBodySnippet {
+		snippet = strings.ToValidUTF8(snippet[:maxBodySnippet], "") + "..."
+	}
+	return snippet
+}
+
+// responseError reads an unsuccessful response and returns it as an *ApiError.
+func (api *NakamaApi) responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return newApiError(resp, body)
+}
@@ -0,0 +1,100 @@
+package nakama
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiError_Sentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		sentinel error
+		message  string
+	}{
+		{"not found", http.StatusNotFound, `{"error":"Account not found.","code":5,"message":"Account not found."}`, ErrNotFound, "Account not found."},
+		{"unauthenticated", http.StatusUnauthorized, `{"error":"Auth token invalid","code":16,"message":"Auth token invalid"}`, ErrUnauthenticated, "Auth token invalid"},
+		{"already exists", http.StatusConflict, `{"error":"Username is already in use.","code":6,"message":"Username is already in use."}`, ErrAlreadyExists, "Username is already in use."},
+		{"permission denied", http.StatusForbidden, `{"error":"Not allowed.","code":7,"message":"Not allowed."}`, ErrPermissionDenied, "Not allowed."},
+		{"plain text body", http.StatusServiceUnavailable, "upstream unavailable", ErrUnavailable, "upstream unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			_, err := client.GetAccount(&Session{Token: "token"})
+
+			assert.ErrorIs(t, err, tt.sentinel)
+			var apiError *ApiError
+			if assert.True(t, errors.As(err, &apiError)) {
+				assert.Equal(t, tt.status, apiError.StatusCode)
+				assert.Equal(t, tt.message, apiError.Message)
+			}
+		})
+	}
+}
+
+func TestApiError_UnrecognisedCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	api := &NakamaApi{BasePath: server.URL, TimeoutMs: DefaultTimeoutMs}
+	_, err := api.GetAccount("token", map[string]string{})
+
+	var apiError *ApiError
+	assert.ErrorAs(t, err, &apiError)
+	assert.Nil(t, apiError.Err)
+	assert.Equal(t, "418 I'm a teapot", err.Error())
+}
+
+func TestApiError_HTMLGatewayPage(t *testing.T) {
+	page := `<html>
+<head><title>502 Bad Gateway</title></head>
+<body>
+<center><h1>502 Bad Gateway</h1></center>
+<hr><center>nginx</center>
+</body>
+</html>`
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(page))
+	})
+
+	_, err := client.GetAccount(&Session{Token: "token"})
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+	var apiError *ApiError
+	if assert.ErrorAs(t, err, &apiError) {
+		assert.Equal(t, "text/html", apiError.ContentType)
+		assert.Equal(t, "<html> <head><title>502 Bad Gateway</title></head> <body> <center><h1>502 Bad Gateway</h1></center> <hr><center>nginx</center> </body> </html>", apiError.Message)
+		assert.NotContains(t, err.Error(), "invalid character")
+	}
+}
+
+func TestApiError_TruncatesLongBodies(t *testing.T) {
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(strings.Repeat("x", 10*maxBodySnippet)))
+	})
+
+	_, err := client.GetAccount(&Session{Token: "token"})
+
+	var apiError *ApiError
+	if assert.ErrorAs(t, err, &apiError) {
+		assert.Equal(t, strings.Repeat("x", maxBodySnippet)+"...", apiError.Message)
+	}
+}
@@ -0,0 +1,144 @@
+// Command genaccessors generates the nil-safe Get methods of the high-level types in accessors.go.
+//
+// For each named struct type, it reads the type's fields from the package source and writes a Get
+// method for every exported field that is a pointer: a pointer to a basic type is returned by value,
+// or as its zero value if nil, and a pointer to a struct is returned as is. Run it with go generate.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// zeroValues are the zero values of the basic types a field may point to.
+var zeroValues = map[string]string{
+	"string":  `""`,
+	"bool":    "false",
+	"int":     "0",
+	"int32":   "0",
+	"int64":   "0",
+	"uint":    "0",
+	"uint32":  "0",
+	"uint64":  "0",
+	"float32": "0",
+	"float64": "0",
+}
+
+func main() {
+	types := flag.String("types", "", "comma-separated names of the struct types to generate accessors for, in output order")
+	output := flag.String("output", "accessors.go", "the file to write")
+	flag.Parse()
+	if *types == "" {
+		log.Fatal("genaccessors: -types is required")
+	}
+
+	structs, pkg, err := parseStructs(".")
+	if err != nil {
+		log.Fatalf("genaccessors: %v", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by genaccessors; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "// The Get methods of the high-level types return the value of a pointer field, or its zero value if\n")
+	fmt.Fprintf(&buf, "// the field or the receiver is nil, so that optional fields can be read without nil checks:\n")
+	fmt.Fprintf(&buf, "//\n//\tname := friend.GetUser().GetDisplayName()\n")
+	for _, name := range strings.Split(*types, ",") {
+		fields, ok := structs[name]
+		if !ok {
+			log.Fatalf("genaccessors: no struct type %s", name)
+		}
+		writeAccessors(&buf, name, fields, structs)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("genaccessors: formatting output: %v", err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatalf("genaccessors: %v", err)
+	}
+}
+
+// parseStructs returns the fields of the struct types declared in the package in dir, and the
+// package name. Test files and generated files are skipped.
+func parseStructs(dir string) (map[string]*ast.FieldList, string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	structs := make(map[string]*ast.FieldList)
+	pkg := ""
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, "", err
+		}
+		if ast.IsGenerated(file) {
+			continue
+		}
+		pkg = file.Name.Name
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if st, ok := typeSpec.Type.(*ast.StructType); ok {
+					structs[typeSpec.Name.Name] = st.Fields
+				}
+			}
+		}
+	}
+	return structs, pkg, nil
+}
+
+// writeAccessors writes the Get methods of the pointer fields of the named type. Pointers to types
+// other than basic types and the structs of the package are skipped.
+func writeAccessors(buf *bytes.Buffer, typeName string, fields *ast.FieldList, structs map[string]*ast.FieldList) {
+	receiver := strings.ToLower(typeName[:1])
+	for _, field := range fields.List {
+		star, ok := field.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		elem, ok := star.X.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if zero, basic := zeroValues[elem.Name]; basic {
+				fmt.Fprintf(buf, "\n// Get%[3]s returns %[3]s, or %[5]s if it is nil.\n", receiver, typeName, name.Name, elem.Name, zero)
+				fmt.Fprintf(buf, "func (%[1]s *%[2]s) Get%[3]s() %[4]s {\n", receiver, typeName, name.Name, elem.Name)
+				fmt.Fprintf(buf, "\tif %[1]s == nil || %[1]s.%[2]s == nil {\n\t\treturn %[3]s\n\t}\n", receiver, name.Name, zero)
+				fmt.Fprintf(buf, "\treturn *%s.%s\n}\n", receiver, name.Name)
+				continue
+			}
+			if _, isStruct := structs[elem.Name]; !isStruct {
+				continue
+			}
+			fmt.Fprintf(buf, "\n// Get%[3]s returns %[3]s, or nil if the %[2]s is nil.\n", receiver, typeName, name.Name)
+			fmt.Fprintf(buf, "func (%[1]s *%[2]s) Get%[3]s() *%[4]s {\n", receiver, typeName, name.Name, elem.Name)
+			fmt.Fprintf(buf, "\tif %s == nil {\n\t\treturn nil\n\t}\n", receiver)
+			fmt.Fprintf(buf, "\treturn %s.%s\n}\n", receiver, name.Name)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package nakama
+
+// Logger is the logging interface used by Client, NakamaApi and WebSocketAdapter.
+// Its method set matches *slog.Logger, so a slog logger can be used directly.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NoopLogger is a Logger that discards all messages. It is the default logger.
+type NoopLogger struct{}
+
+func (NoopLogger) Debug(msg string, args ...any) {}
+func (NoopLogger) Info(msg string, args ...any)  {}
+func (NoopLogger) Warn(msg string, args ...any)  {}
+func (NoopLogger) Error(msg string, args ...any) {}
+
+// Helper function to fall back to the no-op logger when none is configured.
+func loggerOrNoop(logger Logger) Logger {
+	if logger == nil {
+		return NoopLogger{}
+	}
+	return logger
+}
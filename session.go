@@ -4,10 +4,16 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
+// sessionMu guards the sessions that the client refreshes in place, so that requests and sockets can
+// read a session while another goroutine refreshes it.
+var sessionMu sync.RWMutex
+
 // ISession represents a session authenticated for a user with the Nakama server.
 type ISession interface {
 	IsExpired(currentTime int64) bool
@@ -56,6 +62,8 @@ func (s *Session) IsRefreshExpired(currentTime int64) bool {
 }
 
 // Update updates the session with a new token and refresh token.
+// An empty refresh token keeps the current one, as the server returns none when refresh token
+// rotation is disabled.
 func (s *Session) Update(token, refreshToken string) error {
 	tokenDecoded, err := s.decodeJWT(token)
 	if err != nil {
@@ -97,6 +105,27 @@ func (s *Session) Update(token, refreshToken string) error {
 	return nil
 }
 
+// load returns a copy of the session, read under sessionMu.
+func (s *Session) load() Session {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	return *s
+}
+
+// token returns the session token, read under sessionMu.
+func (s *Session) token() string {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	return s.Token
+}
+
+// store replaces the session with updated under sessionMu.
+func (s *Session) store(updated Session) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	*s = updated
+}
+
 // decodeJWT decodes a JWT token and returns its payload as a map.
 func (s *Session) decodeJWT(token string) (map[string]interface{}, error) {
 	parts := strings.Split(token, ".")
@@ -104,9 +133,8 @@ func (s *Session) decodeJWT(token string) (map[string]interface{}, error) {
 		return nil, errors.New("invalid token format")
 	}
 
-	base64Raw := parts[1]
-	base64Str := strings.ReplaceAll(strings.ReplaceAll(base64Raw, "-", "+"), "_", "/")
-	decoded, err := base64.StdEncoding.DecodeString(base64Str)
+	// JWT segments are unpadded base64url, so tolerate but do not require padding.
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +147,51 @@ func (s *Session) decodeJWT(token string) (map[string]interface{}, error) {
 	return payload, nil
 }
 
+// TokenClaims are the claims of a session token.
+type TokenClaims struct {
+	UserID    string
+	Username  string
+	ExpiresAt int64             // The expiry as a Unix time in seconds.
+	Vars      map[string]string // The session vars set at authentication or refresh.
+}
+
+// ParseToken decodes the claims of a session token without a Session, for example to read the user
+// ID of a token on a server or in a tool. The signature is not verified, as only the server holds the
+// key, so the claims must not be trusted for authorization. A token that is not a three-segment JWT
+// or lacks an expiry fails with ErrInvalidToken.
+func ParseToken(token string) (TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return TokenClaims{}, fmt.Errorf("%w: expected 3 segments, got %d", ErrInvalidToken, len(parts))
+	}
+
+	// JWT segments are unpadded base64url, so tolerate but do not require padding.
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return TokenClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	var payload struct {
+		UserID   string            `json:"uid"`
+		Username string            `json:"usn"`
+		Exp      *int64            `json:"exp"`
+		Vars     map[string]string `json:"vrs"`
+	}
+	if err := json.Unmarshal(decoded, &payload); err != nil {
+		return TokenClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if payload.Exp == nil {
+		return TokenClaims{}, fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	}
+
+	return TokenClaims{
+		UserID:    payload.UserID,
+		Username:  payload.Username,
+		ExpiresAt: *payload.Exp,
+		Vars:      payload.Vars,
+	}, nil
+}
+
 // parseInt64FromMap parses an int64 value from a map by key.
 func parseInt64FromMap(data map[string]interface{}, key string) (int64, error) {
 	value, ok := data[key]
@@ -1,10 +1,17 @@
 package nakama
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,10 +21,24 @@ type PromiseExecutor struct {
 }
 
 type Presence struct {
-	UserID    string `json:"user_id"`
-	SessionID string `json:"session_id"`
-	Username  string `json:"username"`
-	Node      string `json:"node"`
+	UserID      string `json:"user_id"`
+	SessionID   string `json:"session_id"`
+	Username    string `json:"username"`
+	Node        string `json:"node"`
+	Persistence bool   `json:"persistence,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// DecodeStatus decodes a status set with UpdateStatusJSON into out. An empty status leaves out at its
+// zero value.
+func (p Presence) DecodeStatus(out any) error {
+	return DecodeJSONField(p.Status, out)
+}
+
+// PresenceEvent describes presences that joined or left a match, party, channel, stream, or status feed.
+type PresenceEvent struct {
+	Joins  []Presence `json:"joins"`
+	Leaves []Presence `json:"leaves"`
 }
 
 type Channel struct {
@@ -35,6 +56,46 @@ type ChannelJoin struct {
 	} `json:"channel_join"`
 }
 
+// Chat types used when joining a chat channel.
+const (
+	ChatTypeRoom          = 1 // A named room that any user can join.
+	ChatTypeDirectMessage = 2 // A private conversation between two users.
+	ChatTypeGroup         = 3 // The channel of a group, open to its members.
+)
+
+// ChatTarget identifies a chat channel to join.
+type ChatTarget struct {
+	Target string
+	Type   int // One of the ChatType constants.
+}
+
+// RoomTarget returns the target of the named chat room.
+func RoomTarget(name string) ChatTarget {
+	return ChatTarget{Target: name, Type: ChatTypeRoom}
+}
+
+// DirectMessageTarget returns the target of a direct message conversation with another user.
+// Only the other user's ID is sent; the server derives the channel from both users' IDs.
+func DirectMessageTarget(userId string) ChatTarget {
+	return ChatTarget{Target: userId, Type: ChatTypeDirectMessage}
+}
+
+// GroupChannelTarget returns the target of a group's chat channel.
+func GroupChannelTarget(groupId string) ChatTarget {
+	return ChatTarget{Target: groupId, Type: ChatTypeGroup}
+}
+
+// Helper function to validate a chat target before it is sent.
+func validateChatTarget(target string, chatType int) error {
+	if chatType < ChatTypeRoom || chatType > ChatTypeGroup {
+		return fmt.Errorf("invalid chat type %d", chatType)
+	}
+	if target == "" {
+		return errors.New("chat target must not be empty")
+	}
+	return nil
+}
+
 type ChannelLeave struct {
 	ChannelLeave struct {
 		ChannelID string `json:"channel_id"`
@@ -74,9 +135,8 @@ type ChannelMessageRemove struct {
 }
 
 type ChannelPresenceEvent struct {
-	ChannelID string     `json:"channel_id"`
-	Joins     []Presence `json:"joins"`
-	Leaves    []Presence `json:"leaves"`
+	ChannelID string `json:"channel_id"`
+	PresenceEvent
 }
 
 type StreamId struct {
@@ -94,15 +154,13 @@ type StreamData struct {
 }
 
 type StreamPresenceEvent struct {
-	Stream StreamId   `json:"stream"`
-	Joins  []Presence `json:"joins"`
-	Leaves []Presence `json:"leaves"`
+	Stream StreamId `json:"stream"`
+	PresenceEvent
 }
 
 type MatchPresenceEvent struct {
-	MatchID string     `json:"match_id"`
-	Joins   []Presence `json:"joins"`
-	Leaves  []Presence `json:"leaves"`
+	MatchID string `json:"match_id"`
+	PresenceEvent
 }
 
 type MatchmakerAdd struct {
@@ -127,19 +185,48 @@ type MatchmakerRemove struct {
 
 type MatchmakerUser struct {
 	Presence          Presence           `json:"presence"`
-	PartyID           string             `json:"party_id"`
+	PartyID           string             `json:"party_id"` // Set when the user was matched as a member of a party.
 	StringProperties  map[string]string  `json:"string_properties,omitempty"`
 	NumericProperties map[string]float64 `json:"numeric_properties,omitempty"`
 }
 
 type MatchmakerMatched struct {
 	Ticket  string           `json:"ticket"`
-	MatchID string           `json:"match_id"`
-	Token   string           `json:"token"`
+	MatchID string           `json:"match_id"` // Set when the server created an authoritative match; join it with JoinMatch.
+	Token   string           `json:"token"`    // Set for relayed matches; join with JoinMatchByToken before it expires.
 	Users   []MatchmakerUser `json:"users"`
 	Self    MatchmakerUser   `json:"self"`
 }
 
+// MatchmakerJoinKind is how the match of a MatchmakerMatched is joined.
+type MatchmakerJoinKind int
+
+const (
+	MatchmakerJoinNone    MatchmakerJoinKind = iota // The event carries neither a match ID nor a token.
+	MatchmakerJoinByID                              // Join the authoritative match MatchID with JoinMatch.
+	MatchmakerJoinByToken                           // Join the relayed match with Token and JoinMatchByToken.
+)
+
+// JoinKind returns whether the matched users join by match ID or by token.
+func (m MatchmakerMatched) JoinKind() MatchmakerJoinKind {
+	switch {
+	case m.MatchID != "":
+		return MatchmakerJoinByID
+	case m.Token != "":
+		return MatchmakerJoinByToken
+	default:
+		return MatchmakerJoinNone
+	}
+}
+
+// SelfIndex returns the index of the current user's own entry in Users, found by session ID, or -1
+// if it is not there.
+func (m MatchmakerMatched) SelfIndex() int {
+	return slices.IndexFunc(m.Users, func(user MatchmakerUser) bool {
+		return user.Presence.SessionID == m.Self.Presence.SessionID
+	})
+}
+
 type Match struct {
 	MatchID       string     `json:"match_id"`
 	Authoritative bool       `json:"authoritative"`
@@ -149,6 +236,11 @@ type Match struct {
 	Self          Presence   `json:"self"`
 }
 
+// ParseLabel decodes a JSON match label into out.
+func (m *Match) ParseLabel(out interface{}) error {
+	return parseMatchLabel(m.Label, out)
+}
+
 type CreateMatch struct {
 	MatchCreate struct {
 		Name *string `json:"name,omitempty"`
@@ -175,6 +267,33 @@ type MatchData struct {
 	Data     []byte    `json:"data"`
 	Presence *Presence `json:"presence,omitempty"`
 	Reliable *bool     `json:"reliable,omitempty"`
+	Sequence uint64    `json:"-"` // The sender's sequence number, if SetMatchDataSequencing is on.
+}
+
+// MatchDataGap reports match data from a sender that arrived out of sequence, detected by
+// SetMatchDataSequencing.
+type MatchDataGap struct {
+	MatchID  string
+	Sender   *Presence // The sender, or nil for data sent by the match handler.
+	Expected uint64    // The sequence number that should have arrived next.
+	Received uint64    // The sequence number that arrived.
+}
+
+// Missing returns how many messages were skipped, or 0 if the data arrived late or twice.
+func (g MatchDataGap) Missing() uint64 {
+	if g.Received <= g.Expected {
+		return 0
+	}
+	return g.Received - g.Expected
+}
+
+// matchSequenceBytes is the length of the sequence number prefixed to match data by SetMatchDataSequencing.
+const matchSequenceBytes = 8
+
+// matchSequences holds the match data sequence numbers of SetMatchDataSequencing.
+type matchSequences struct {
+	sent     map[string]uint64            // The last sequence number sent to each match.
+	received map[string]map[string]uint64 // The last sequence number received in each match, by sender session ID.
 }
 
 type MatchDataSend struct {
@@ -222,9 +341,10 @@ type PartyPromote struct {
 	} `json:"party_promote"`
 }
 
+// PartyLeader announces the current leader of a party.
 type PartyLeader struct {
 	PartyID  string   `json:"party_id"`
-	Presence Presence `json:"presence"`
+	Presence Presence `json:"presence"` // The presence of the new leader.
 }
 
 type PartyAccept struct {
@@ -234,10 +354,9 @@ type PartyAccept struct {
 	} `json:"party_accept"`
 }
 
+// PartyClose announces that a party was closed by its leader.
 type PartyClose struct {
-	PartyClose struct {
-		PartyID string `json:"party_id"`
-	} `json:"party_close"`
+	PartyID string `json:"party_id"`
 }
 
 type PartyData struct {
@@ -255,15 +374,16 @@ type PartyDataSend struct {
 	} `json:"party_data_send"`
 }
 
+// PartyJoinRequest announces users asking to join a closed party. It is sent to the party leader.
 type PartyJoinRequest struct {
 	PartyID   string     `json:"party_id"`
 	Presences []Presence `json:"presences"`
 }
 
+// PartyJoinRequestList is the pending join requests of a closed party.
 type PartyJoinRequestList struct {
-	PartyJoinRequestList struct {
-		PartyID string `json:"party_id"`
-	} `json:"party_join_request_list"`
+	PartyID   string     `json:"party_id"`
+	Presences []Presence `json:"presences"` // The users waiting for the leader to accept or remove them.
 }
 
 type PartyMatchmakerAdd struct {
@@ -290,9 +410,8 @@ type PartyMatchmakerTicket struct {
 }
 
 type PartyPresenceEvent struct {
-	PartyID string     `json:"party_id"`
-	Joins   []Presence `json:"joins"`
-	Leaves  []Presence `json:"leaves"`
+	PartyID string `json:"party_id"`
+	PresenceEvent
 }
 
 type PartyRemove struct {
@@ -321,8 +440,7 @@ type StatusFollow struct {
 }
 
 type StatusPresenceEvent struct {
-	Joins  []Presence `json:"joins"`
-	Leaves []Presence `json:"leaves"`
+	PresenceEvent
 }
 
 type StatusUnfollow struct {
@@ -350,6 +468,40 @@ type SocketError struct {
 	Message string `json:"message"` // A message in English to help developers debug the response
 }
 
+// Error implements the error interface.
+func (e *SocketError) Error() string {
+	return fmt.Sprintf("socket error %d: %s", e.Code, e.Message)
+}
+
+// Error codes reported in SocketError.Code.
+const (
+	SocketErrorRuntimeException         = 0 // An unexpected result from the server.
+	SocketErrorUnrecognizedPayload      = 1 // The server received a message which is not recognised.
+	SocketErrorMissingPayload           = 2 // A message was expected but contains no content.
+	SocketErrorBadInput                 = 3 // Fields in the message have an invalid format.
+	SocketErrorMatchNotFound            = 4 // The match id was not found.
+	SocketErrorMatchJoinRejected        = 5 // The match join was rejected.
+	SocketErrorRuntimeFunctionNotFound  = 6 // The runtime function does not exist on the server.
+	SocketErrorRuntimeFunctionException = 7 // The runtime function executed with an error.
+)
+
+// MatchTokenError is returned by JoinMatchByToken when the server rejects a match token,
+// typically because it is stale or has expired.
+type MatchTokenError struct {
+	Token string
+	Cause *SocketError
+}
+
+// Error implements the error interface.
+func (e *MatchTokenError) Error() string {
+	return fmt.Sprintf("invalid or expired match token: %s", e.Cause.Message)
+}
+
+// Unwrap returns the underlying socket error.
+func (e *MatchTokenError) Unwrap() error {
+	return e.Cause
+}
+
 type Message struct {
 	Cid           *string         `json:"cid"`
 	Error         *error          `json:"error"`
@@ -362,6 +514,7 @@ const (
 	DefaultHeartbeatTimeoutMs = 10000
 	DefaultSendTimeoutMs      = 10000
 	DefaultConnectTimeoutMs   = 30000
+	DefaultReconnectAttempts  = 5
 )
 
 // DefaultSocket represents a WebSocket connection to the Nakama server
@@ -370,11 +523,87 @@ type DefaultSocket struct {
 	Port               string
 	UseSSL             bool
 	Verbose            bool
-	Adapter            WebSocketAdapter
+	Adapter            *WebSocketAdapter
 	SendTimeoutMs      int
 	HeartbeatTimeoutMs int
-	cIds               map[string]*PromiseExecutor
-	nextCid            int
+	Backoff            BackoffConfig // The reconnect delays used when Reconnect or SetAutoReconnect is given a zero BackoffConfig.
+	shared             *socketShared
+}
+
+// socketShared holds the mutable state of a DefaultSocket. It is referenced by pointer so that
+// copies of a DefaultSocket keep correlating responses and dispatching events consistently.
+type socketShared struct {
+	mu            sync.Mutex
+	cIds          map[string]*PromiseExecutor
+	nextCid       int
+	handlers      socketHandlers
+	connected     bool // Whether the socket has connected before, so the next Connect is a reconnect.
+	subscriptions map[subscriptionKey]*Subscription
+	tickets       map[string]bool                // The outstanding matchmaker tickets added through this connection.
+	followed      map[string]bool                // The users followed through this connection.
+	lostFollows   map[string]bool                // The users followed before the connection closed, to re-follow.
+	refollow      bool                           // Whether lost follows are re-issued after a reconnect.
+	autoReconnect *autoReconnect                 // How to reconnect after the connection drops, or nil to stay disconnected.
+	stopReconnect chan struct{}                  // Closed by Disconnect to stop a reconnect in progress.
+	disconnected  bool                           // Set by Disconnect and cleared by Connect, so that a drop is not reconnected after it.
+	createStatus  bool                           // The createStatus of the last connect, re-applied by Reconnect.
+	status        *string                        // The status of the last ConnectWithStatus, re-applied by Reconnect.
+	presences     map[string]map[string]Presence // The presences of each joined match, channel or party, by session ID.
+	stopHeartbeat chan struct{}                  // Closed to stop the heartbeat started by StartHeartbeat.
+	sequences     *matchSequences                // The match data sequence numbers, or nil if sequencing is off.
+	matchSend     sync.Mutex                     // Serialises sequenced match data sends, so each number is sent once.
+	removalCodes  []int                          // The notification codes reported through OnGroupRemoved.
+}
+
+// autoReconnect holds the settings of SetAutoReconnect.
+type autoReconnect struct {
+	session     *Session
+	backoff     BackoffConfig
+	maxAttempts int
+}
+
+// SubscriptionKind identifies the kind of realtime subscription tracked by a socket.
+type SubscriptionKind int
+
+const (
+	SubscriptionMatch SubscriptionKind = iota
+	SubscriptionChannel
+	SubscriptionParty
+)
+
+// Subscription is a match, chat channel or party joined through a socket.
+type Subscription struct {
+	Kind   SubscriptionKind
+	ID     string // The match, channel or party ID.
+	Rejoin bool   // Whether the join is re-issued after a reconnect. Enable it with SetRejoin.
+	join   map[string]interface{}
+}
+
+type subscriptionKey struct {
+	kind SubscriptionKind
+	id   string
+}
+
+// socketHandlers holds the typed callbacks for server-initiated socket events.
+type socketHandlers struct {
+	onChannelPresence   func(ChannelPresenceEvent)
+	onMatchPresence     func(MatchPresenceEvent)
+	onPartyPresence     func(PartyPresenceEvent)
+	onMatchmakerMatched func(MatchmakerMatched)
+	onPartyLeader       func(PartyLeader)
+	onPartyClose        func(PartyClose)
+	onPartyJoinRequest  func(PartyJoinRequest)
+	onStatusPresence    func(StatusPresenceEvent)
+	onStreamPresence    func(StreamPresenceEvent)
+	onRejoin            func(Subscription, error)
+	onNotification      func(Notification)
+	onGroupRemoved      func(groupID string, notification Notification)
+	onTicketsLost       func([]MatchmakerTicket)
+	onRefollow          func(*Status, error)
+	onHeartbeat         func(*ApiRpc, error)
+	onClose             func(*CloseEvent)
+	onMatchData         func(MatchData)
+	onMatchDataGap      func(MatchDataGap)
 }
 
 // NewDefaultSocket creates an instance of DefaultSocket.
@@ -392,22 +621,482 @@ func NewDefaultSocket(host, port string, useSSL, verbose bool, adapter *WebSocke
 		Port:               port,
 		UseSSL:             useSSL,
 		Verbose:            verbose,
-		Adapter:            *adapter,
+		Adapter:            adapter,
 		SendTimeoutMs:      *sendTimeoutMs,
 		HeartbeatTimeoutMs: DefaultHeartbeatTimeoutMs,
-		cIds:               make(map[string]*PromiseExecutor),
-		nextCid:            1,
+		Backoff:            DefaultBackoffConfig(),
+		shared: &socketShared{
+			cIds:          make(map[string]*PromiseExecutor),
+			nextCid:       1,
+			subscriptions: make(map[subscriptionKey]*Subscription),
+			tickets:       make(map[string]bool),
+			followed:      make(map[string]bool),
+			lostFollows:   make(map[string]bool),
+			presences:     make(map[string]map[string]Presence),
+		},
 	}
 }
 
 // GenerateCID generates a unique client ID for requests.
 func (socket *DefaultSocket) GenerateCID() string {
-	cid := fmt.Sprintf("%d", socket.nextCid)
-	socket.nextCid++
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	cid := fmt.Sprintf("%d", socket.shared.nextCid)
+	socket.shared.nextCid++
 	return cid
 }
 
-// Connect establishes the WebSocket connection with optional timeouts.
+// OnChannelPresence registers a callback for presences joining or leaving a chat channel.
+func (socket *DefaultSocket) OnChannelPresence(callback func(ChannelPresenceEvent)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onChannelPresence = callback
+}
+
+// OnMatchPresence registers a callback for presences joining or leaving a match.
+func (socket *DefaultSocket) OnMatchPresence(callback func(MatchPresenceEvent)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onMatchPresence = callback
+}
+
+// OnMatchData registers a callback for data sent to a joined match by other clients or the match handler.
+func (socket *DefaultSocket) OnMatchData(callback func(MatchData)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onMatchData = callback
+}
+
+// OnMatchDataGap registers a callback for match data that arrived out of sequence while
+// SetMatchDataSequencing is on. The data itself is still delivered to OnMatchData.
+func (socket *DefaultSocket) OnMatchDataGap(callback func(MatchDataGap)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onMatchDataGap = callback
+}
+
+// SetMatchDataSequencing turns on sequence tracking of match data, to detect data lost on unreliable
+// sends. SendMatchState prefixes its data with an 8-byte big-endian sequence number, counting from 1
+// per match, and received data has the prefix stripped into MatchData.Sequence. A sender whose
+// sequence number jumps, goes back or repeats is reported to OnMatchDataGap; the first data of each
+// sender only sets its starting point. The prefix is part of the data as the server sees it, so every
+// client of the match, and a match handler that reads or sends data, must use the same scheme.
+// Turning it off forgets the sequence numbers.
+func (socket *DefaultSocket) SetMatchDataSequencing(enabled bool) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	switch {
+	case !enabled:
+		socket.shared.sequences = nil
+	case socket.shared.sequences == nil:
+		socket.shared.sequences = &matchSequences{sent: make(map[string]uint64), received: make(map[string]map[string]uint64)}
+	}
+}
+
+// nextMatchSequence returns the next sequence number to send to a match, or 0 if sequencing is off.
+// The number is only taken by sentMatchSequence, once the data has been sent.
+func (socket *DefaultSocket) nextMatchSequence(matchID string) uint64 {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	if socket.shared.sequences == nil {
+		return 0
+	}
+	return socket.shared.sequences.sent[matchID] + 1
+}
+
+// sentMatchSequence records the sequence number of match data that was sent.
+func (socket *DefaultSocket) sentMatchSequence(matchID string, sequence uint64) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	if socket.shared.sequences != nil {
+		socket.shared.sequences.sent[matchID] = sequence
+	}
+}
+
+// receiveMatchSequence strips the sequence number from received match data if sequencing is on, and
+// returns the gap it reveals, if any.
+func (socket *DefaultSocket) receiveMatchSequence(data *MatchData) *MatchDataGap {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	sequences := socket.shared.sequences
+	if sequences == nil || len(data.Data) < matchSequenceBytes {
+		return nil
+	}
+	data.Sequence = binary.BigEndian.Uint64(data.Data)
+	data.Data = data.Data[matchSequenceBytes:]
+
+	sender := ""
+	if data.Presence != nil {
+		sender = data.Presence.SessionID
+	}
+	senders := sequences.received[data.MatchID]
+	if senders == nil {
+		senders = make(map[string]uint64)
+		sequences.received[data.MatchID] = senders
+	}
+	last, seen := senders[sender]
+	if !seen || data.Sequence > last {
+		senders[sender] = data.Sequence
+	}
+	if !seen || data.Sequence == last+1 {
+		return nil
+	}
+	return &MatchDataGap{MatchID: data.MatchID, Sender: data.Presence, Expected: last + 1, Received: data.Sequence}
+}
+
+// decodeMatchData decodes a match_data envelope field. The server sends the op code as a string, and
+// the adapter has already decoded the data from base64.
+func (socket *DefaultSocket) decodeMatchData(value interface{}) (MatchData, error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return MatchData{}, errors.New("invalid match data")
+	}
+	var data MatchData
+	data.MatchID, _ = fields["match_id"].(string)
+	switch opCode := fields["op_code"].(type) {
+	case string:
+		data.OpCode, _ = strconv.Atoi(opCode)
+	case float64:
+		data.OpCode = int(opCode)
+	}
+	switch raw := fields["data"].(type) {
+	case []byte:
+		data.Data = raw
+	case string:
+		decoded, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return MatchData{}, fmt.Errorf("invalid match data: %w", err)
+		}
+		data.Data = decoded
+	}
+	if fields["presence"] != nil {
+		data.Presence = &Presence{}
+		if err := socket.decodeField(fields["presence"], data.Presence); err != nil {
+			return MatchData{}, err
+		}
+	}
+	if reliable, ok := fields["reliable"].(bool); ok {
+		data.Reliable = &reliable
+	}
+	return data, nil
+}
+
+// OnMatchmakerMatched registers a callback for matchmaker results, for both solo and party tickets.
+func (socket *DefaultSocket) OnMatchmakerMatched(callback func(MatchmakerMatched)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onMatchmakerMatched = callback
+}
+
+// OnMatchmakerTicketsLost registers a callback for the matchmaker tickets that were outstanding when
+// the socket reconnected. The server drops a connection's tickets when it closes, so they are no
+// longer tracked and must be added again to keep matchmaking.
+func (socket *DefaultSocket) OnMatchmakerTicketsLost(callback func([]MatchmakerTicket)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onTicketsLost = callback
+}
+
+// MatchmakerTickets returns the outstanding matchmaker tickets added through this connection with
+// AddMatchmaker, that have neither matched nor been removed.
+func (socket *DefaultSocket) MatchmakerTickets() []MatchmakerTicket {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	return socket.matchmakerTickets()
+}
+
+// matchmakerTickets returns the outstanding tickets. The caller must hold the lock.
+func (socket *DefaultSocket) matchmakerTickets() []MatchmakerTicket {
+	tickets := make([]MatchmakerTicket, 0, len(socket.shared.tickets))
+	for ticket := range socket.shared.tickets {
+		tickets = append(tickets, MatchmakerTicket{Ticket: ticket})
+	}
+	return tickets
+}
+
+// FollowedUsers returns the IDs of the users followed through the current connection.
+func (socket *DefaultSocket) FollowedUsers() []string {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	return slices.Sorted(maps.Keys(socket.shared.followed))
+}
+
+// SetRefollowUsers sets whether the users followed when the connection closed are followed again after
+// a reconnect. The followed set is cleared whenever the connection closes, as the server drops it.
+func (socket *DefaultSocket) SetRefollowUsers(enabled bool) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.refollow = enabled
+}
+
+// OnRefollow registers a callback invoked with the result of following users again after a reconnect.
+func (socket *DefaultSocket) OnRefollow(callback func(*Status, error)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onRefollow = callback
+}
+
+// dropFollows clears the followed set when the connection closes, keeping it to re-follow.
+func (socket *DefaultSocket) dropFollows() {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	for userID := range socket.shared.followed {
+		socket.shared.lostFollows[userID] = true
+	}
+	clear(socket.shared.followed)
+}
+
+// Presences returns the presences currently in the joined match, chat channel or party with the
+// given ID, including the user's own, ordered by user ID. It is nil if the ID has not been joined.
+// The set is taken from the join response and kept up to date by the presence events that follow.
+func (socket *DefaultSocket) Presences(id string) []Presence {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	set, ok := socket.shared.presences[id]
+	if !ok {
+		return nil
+	}
+	presences := slices.Collect(maps.Values(set))
+	slices.SortFunc(presences, func(a, b Presence) int {
+		if a.UserID != b.UserID {
+			return strings.Compare(a.UserID, b.UserID)
+		}
+		return strings.Compare(a.SessionID, b.SessionID)
+	})
+	return presences
+}
+
+// setPresences replaces the presence set of a joined match, channel or party.
+func (socket *DefaultSocket) setPresences(id string, self Presence, presences []Presence) {
+	set := make(map[string]Presence, len(presences)+1)
+	for _, presence := range presences {
+		set[presence.SessionID] = presence
+	}
+	if self.SessionID != "" {
+		set[self.SessionID] = self
+	}
+
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.presences[id] = set
+}
+
+// updatePresences applies a presence event to the presence set of a joined match, channel or party.
+// Events for IDs that are not joined are ignored.
+func (socket *DefaultSocket) updatePresences(id string, event PresenceEvent) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	set, ok := socket.shared.presences[id]
+	if !ok {
+		return
+	}
+	for _, presence := range event.Leaves {
+		delete(set, presence.SessionID)
+	}
+	for _, presence := range event.Joins {
+		set[presence.SessionID] = presence
+	}
+}
+
+// seedPresences sets the presence set from the response to a match, channel or party join.
+func (socket *DefaultSocket) seedPresences(response map[string]interface{}) error {
+	switch {
+	case response["channel"] != nil:
+		var channel Channel
+		if err := socket.decodeField(response["channel"], &channel); err != nil {
+			return err
+		}
+		socket.setPresences(channel.ID, channel.Self, channel.Presences)
+	case response["match"] != nil:
+		var match Match
+		if err := socket.decodeField(response["match"], &match); err != nil {
+			return err
+		}
+		socket.setPresences(match.MatchID, match.Self, match.Presences)
+	case response["party"] != nil:
+		var party Party
+		if err := socket.decodeField(response["party"], &party); err != nil {
+			return err
+		}
+		socket.setPresences(party.PartyID, party.Self, party.Presences)
+	}
+	return nil
+}
+
+// dropPresences clears the presence sets when the connection closes, as they can no longer be kept
+// up to date. Re-joined subscriptions are seeded again from their join responses.
+func (socket *DefaultSocket) dropPresences() {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	clear(socket.shared.presences)
+}
+
+// refollowUsers follows the given users again after a reconnect and reports the result.
+func (socket *DefaultSocket) refollowUsers(userIDs []string) {
+	status, err := socket.FollowUsers(userIDs)
+
+	socket.shared.mu.Lock()
+	onRefollow := socket.shared.handlers.onRefollow
+	socket.shared.mu.Unlock()
+	if onRefollow != nil {
+		onRefollow(status, err)
+	}
+}
+
+// OnPartyPresence registers a callback for presences joining or leaving a party.
+func (socket *DefaultSocket) OnPartyPresence(callback func(PartyPresenceEvent)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onPartyPresence = callback
+}
+
+// OnPartyLeader registers a callback for party leader changes, including promotions made with
+// PromotePartyMember.
+func (socket *DefaultSocket) OnPartyLeader(callback func(PartyLeader)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onPartyLeader = callback
+}
+
+// OnPartyClose registers a callback for parties closed by their leader. A closed party is no longer
+// tracked as a subscription.
+func (socket *DefaultSocket) OnPartyClose(callback func(PartyClose)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onPartyClose = callback
+}
+
+// OnPartyJoinRequest registers a callback for users asking to join a closed party led by the user.
+// Accept them with AcceptPartyMember or reject them with RemovePartyMember.
+func (socket *DefaultSocket) OnPartyJoinRequest(callback func(PartyJoinRequest)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onPartyJoinRequest = callback
+}
+
+// OnStatusPresence registers a callback for status updates of followed users.
+func (socket *DefaultSocket) OnStatusPresence(callback func(StatusPresenceEvent)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onStatusPresence = callback
+}
+
+// OnStreamPresence registers a callback for presences joining or leaving a stream.
+func (socket *DefaultSocket) OnStreamPresence(callback func(StreamPresenceEvent)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onStreamPresence = callback
+}
+
+// OnNotification registers a callback for notifications received while the socket is connected.
+func (socket *DefaultSocket) OnNotification(callback func(Notification)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onNotification = callback
+}
+
+// OnGroupRemoved registers a callback for notifications that the user was removed from a group, so
+// that the app can leave the group's chat channel and drop other state for it. It is invoked after
+// OnNotification for notifications with one of the codes set with SetGroupRemovalNotificationCodes,
+// and the group's chat channel is no longer re-joined after a reconnect.
+func (socket *DefaultSocket) OnGroupRemoved(callback func(groupID string, notification Notification)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onGroupRemoved = callback
+}
+
+// SetGroupRemovalNotificationCodes sets the notification codes reported through OnGroupRemoved, with
+// the ID of the group in the "group_id" field of their content. The server sends no notification when
+// a user is kicked or banned from a group, so there are none by default: send one from a runtime after
+// hook on KickGroupUsers or BanGroupUsers and set its code here.
+func (socket *DefaultSocket) SetGroupRemovalNotificationCodes(codes ...int) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.removalCodes = slices.Clone(codes)
+}
+
+// OnRejoin registers a callback invoked for every subscription re-joined after a reconnect, with the
+// error if the join failed. Failed subscriptions are no longer tracked.
+func (socket *DefaultSocket) OnRejoin(callback func(Subscription, error)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onRejoin = callback
+}
+
+// Subscriptions returns the matches, chat channels and parties currently joined through the socket.
+func (socket *DefaultSocket) Subscriptions() []Subscription {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	subscriptions := make([]Subscription, 0, len(socket.shared.subscriptions))
+	for _, subscription := range socket.shared.subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions
+}
+
+// SetRejoin opts a joined match, chat channel or party in or out of being re-joined after a reconnect.
+func (socket *DefaultSocket) SetRejoin(kind SubscriptionKind, id string, rejoin bool) error {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	subscription, ok := socket.shared.subscriptions[subscriptionKey{kind, id}]
+	if !ok {
+		return fmt.Errorf("no subscription with id %s", id)
+	}
+	subscription.Rejoin = rejoin
+	return nil
+}
+
+// track records a successful join so it can be re-issued after a reconnect.
+func (socket *DefaultSocket) track(kind SubscriptionKind, id string, join map[string]interface{}) {
+	delete(join, "cid")
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	key := subscriptionKey{kind, id}
+	if existing, ok := socket.shared.subscriptions[key]; ok {
+		existing.join = join
+		return
+	}
+	socket.shared.subscriptions[key] = &Subscription{Kind: kind, ID: id, join: join}
+}
+
+// untrack forgets a subscription after it was left.
+func (socket *DefaultSocket) untrack(kind SubscriptionKind, id string) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	delete(socket.shared.subscriptions, subscriptionKey{kind, id})
+	delete(socket.shared.presences, id)
+	if socket.shared.sequences != nil {
+		delete(socket.shared.sequences.sent, id)
+		delete(socket.shared.sequences.received, id)
+	}
+}
+
+// rejoin re-issues the joins of the given subscriptions and reports each result.
+func (socket *DefaultSocket) rejoin(subscriptions []Subscription) {
+	for _, subscription := range subscriptions {
+		join := make(map[string]interface{}, len(subscription.join))
+		for key, value := range subscription.join {
+			join[key] = value
+		}
+
+		response, err := socket.sendAndWait(join, nil)
+		if err != nil {
+			socket.untrack(subscription.Kind, subscription.ID)
+		} else if err := socket.seedPresences(response); err != nil {
+			socket.OnError(fmt.Errorf("failed to read presences of rejoined subscription: %w", err))
+		}
+
+		socket.shared.mu.Lock()
+		onRejoin := socket.shared.handlers.onRejoin
+		socket.shared.mu.Unlock()
+		if onRejoin != nil {
+			onRejoin(subscription, err)
+		}
+	}
+}
+
+// Connect establishes the WebSocket connection with optional timeouts. timeoutMs bounds the dial and
+// the WebSocket handshake and defaults to DefaultConnectTimeoutMs.
 func (socket *DefaultSocket) Connect(session Session, createStatus *bool, timeoutMs *int) (*Session, error) {
 	if createStatus == nil {
 		defaultStatus := false
@@ -423,101 +1112,263 @@ func (socket *DefaultSocket) Connect(session Session, createStatus *bool, timeou
 		return &session, nil
 	}
 
+	socket.shared.mu.Lock()
+	socket.shared.disconnected = false
+	socket.shared.mu.Unlock()
+
 	scheme := "ws://"
 	if socket.UseSSL {
 		scheme = "wss://"
 	}
 
-	err := socket.Adapter.Connect(scheme, socket.Host, socket.Port, *createStatus, session.Token)
-	if err != nil {
-		return nil, err
-	}
+	socket.Adapter.mu.Lock()
+	socket.Adapter.onClose = func(event *CloseEvent) {
+		socket.dropFollows()
+		socket.dropPresences()
+		socket.StopHeartbeat()
+		socket.OnDisconnect(event)
+		socket.shared.mu.Lock()
+		onClose := socket.shared.handlers.onClose
+		socket.shared.mu.Unlock()
+		if onClose != nil {
+			onClose(event)
+		}
 
-	socket.Adapter.onClose = func(err error) {
-		socket.OnDisconnect(err)
+		// The stop channel is created before the reconnect starts, so that a Disconnect from here on
+		// stops it, and no reconnect starts once Disconnect has been called.
+		socket.shared.mu.Lock()
+		auto := socket.shared.autoReconnect
+		if socket.shared.disconnected {
+			auto = nil
+		}
+		stop := socket.reconnectStop()
+		socket.shared.mu.Unlock()
+		if auto != nil {
+			go func() {
+				if _, err := socket.reconnect(auto.session.load(), auto.backoff, auto.maxAttempts, event, stop); err != nil {
+					socket.OnError(err)
+				}
+			}()
+		}
 	}
 
 	socket.Adapter.onError = func(err error) {
 		socket.OnError(err)
 	}
 
-	socket.Adapter.onMessage = func(message []byte) {
-		if socket.Verbose == true {
-			fmt.Println("Received message:", string(message))
+	socket.Adapter.onMessage = func(message map[string]interface{}) {
+		if socket.Verbose {
+			socket.logger().Debug("Received message", "message", message)
 		}
+		socket.handleEnvelope(message)
+	}
+	socket.Adapter.mu.Unlock()
 
-		var messageObject *Message
-		if err := json.Unmarshal(message, &messageObject); err != nil {
-			if socket.Verbose {
-				fmt.Println("Failed to unmarshal message into custom object:", err)
-			}
-			return
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeoutMs)*time.Millisecond)
+	defer cancel()
+	err := socket.Adapter.ConnectContext(ctx, scheme, socket.Host, socket.Port, *createStatus, session.Token)
+	if err != nil {
+		return nil, err
+	}
 
-		if messageObject == nil {
-			if socket.Verbose {
-				fmt.Println("Received empty message")
+	socket.shared.mu.Lock()
+	reconnect := socket.shared.connected
+	socket.shared.connected = true
+	socket.shared.createStatus = *createStatus
+	socket.shared.status = nil
+	var rejoins []Subscription
+	var lostTickets []MatchmakerTicket
+	if reconnect {
+		for _, subscription := range socket.shared.subscriptions {
+			if subscription.Rejoin {
+				rejoins = append(rejoins, *subscription)
 			}
 		}
+		lostTickets = socket.matchmakerTickets()
+		clear(socket.shared.tickets)
+	}
+	var refollows []string
+	if reconnect && socket.shared.refollow {
+		refollows = slices.Sorted(maps.Keys(socket.shared.lostFollows))
+	}
+	clear(socket.shared.lostFollows)
+	onTicketsLost := socket.shared.handlers.onTicketsLost
+	socket.shared.mu.Unlock()
+	if len(lostTickets) > 0 && onTicketsLost != nil {
+		onTicketsLost(lostTickets)
+	}
+	if len(refollows) > 0 {
+		go socket.refollowUsers(refollows)
+	}
+	if len(rejoins) > 0 {
+		go socket.rejoin(rejoins)
+	}
 
-		if messageObject.Cid == nil {
-			if messageObject.Notifications != nil {
+	socket.Adapter.mu.Lock()
+	socket.Adapter.onOpen = func(event interface{}) error {
+		socket.logger().Info("Socket opened", "event", event)
 
-			}
-		} else {
-			executor := socket.cIds[*messageObject.Cid]
-			if executor == nil {
-				if socket.Verbose {
-					log.Printf("No promise executor for message: %v\n", messageObject)
-				}
-				return
-			}
+		socket.pingPong()
 
-			delete(socket.cIds, *messageObject.Cid)
+		// Set a timeout for the connection process
+		resChan := make(chan error, 1)
+		go func() {
+			time.Sleep(time.Duration(*timeoutMs) * time.Millisecond)
+			resChan <- errors.New("socket connection timed out")
+		}()
 
-			if messageObject.Error != nil {
-				executor.Reject(*messageObject.Error)
-			} else {
-				executor.Resolve(messageObject)
+		select {
+		case err := <-resChan:
+			if err != nil {
+				socket.Adapter.Close()
+				return err
 			}
 		}
+
+		return nil
 	}
+	socket.Adapter.mu.Unlock()
 
-	go func() {
-		socket.Adapter.onOpen = func(event interface{}) error {
-			log.Printf("Socket opened: %v\n", event)
+	return &session, nil
+}
 
-			socket.pingPong()
+// SetAutoReconnect makes the socket reconnect with Reconnect whenever the connection drops, rather than
+// being closed with Disconnect. The session is read at each reconnect, so refreshing it in place keeps
+// reconnects authenticated. Errors of a failed reconnect are reported to OnError. A nil session
+// disables reconnecting. A zero backoff uses the socket's Backoff.
+func (socket *DefaultSocket) SetAutoReconnect(session *Session, backoff BackoffConfig, maxAttempts int) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	if session == nil {
+		socket.shared.autoReconnect = nil
+		return
+	}
+	socket.shared.autoReconnect = &autoReconnect{session: session, backoff: backoff, maxAttempts: maxAttempts}
+}
 
-			// Set a timeout for the connection process
-			resChan := make(chan error, 1)
-			go func() {
-				time.Sleep(time.Duration(*timeoutMs) * time.Millisecond)
-				resChan <- errors.New("socket connection timed out")
-			}()
+// Reconnect connects the socket again after its connection dropped, waiting the backoff delay before
+// each of up to maxAttempts attempts (DefaultReconnectAttempts if not positive). A zero backoff uses
+// the socket's Backoff. cause is the error that closed the connection. The createStatus of the last
+// Connect, or the status of the last ConnectWithStatus, is applied again. Subscriptions opted in with
+// SetRejoin are re-joined afterwards. It stops early if Disconnect is called, including during a
+// backoff delay.
+//
+// Each attempt is logged at debug level with its number, delay and the error that triggered it, and
+// a successful reconnect with the number of attempts and the downtime.
+func (socket *DefaultSocket) Reconnect(session Session, backoff BackoffConfig, maxAttempts int, cause error) (*Session, error) {
+	socket.shared.mu.Lock()
+	stop := socket.reconnectStop()
+	socket.shared.mu.Unlock()
+	return socket.reconnect(session, backoff, maxAttempts, cause, stop)
+}
 
-			select {
-			case err := <-resChan:
-				if err != nil {
-					socket.Adapter.Close()
-					return err
-				}
-			}
+// reconnectStop returns the channel closed by Disconnect to stop a reconnect, creating it if needed.
+// The caller must hold shared.mu.
+func (socket *DefaultSocket) reconnectStop() <-chan struct{} {
+	if socket.shared.stopReconnect == nil {
+		socket.shared.stopReconnect = make(chan struct{})
+	}
+	return socket.shared.stopReconnect
+}
+
+// reconnect is Reconnect, stopped when stop is closed.
+func (socket *DefaultSocket) reconnect(session Session, backoff BackoffConfig, maxAttempts int, cause error, stop <-chan struct{}) (*Session, error) {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultReconnectAttempts
+	}
+	if backoff.isZero() {
+		backoff = socket.Backoff
+	}
+
+	socket.shared.mu.Lock()
+	createStatus := socket.shared.createStatus
+	status := socket.shared.status
+	socket.shared.mu.Unlock()
 
-			return nil
+	start := time.Now()
+	err := cause
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		delay := backoff.Delay(attempt - 1)
+		socket.logger().Debug("Socket reconnect attempt", "attempt", attempt, "delay", delay, "error", err)
+		socket.Adapter.setState(ConnectionStateReconnecting)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			socket.Adapter.setState(ConnectionStateDisconnected)
+			return nil, errors.New("socket reconnect stopped by disconnect")
 		}
-	}()
 
-	return &session, nil
+		var connected *Session
+		var connectErr error
+		if status != nil {
+			connected, connectErr = socket.ConnectWithStatus(session, status, nil)
+			if connectErr != nil && socket.Adapter.IsOpen() {
+				// Only setting the status failed; the socket is connected again.
+				socket.OnError(connectErr)
+				connectErr = nil
+			}
+		} else {
+			connected, connectErr = socket.Connect(session, &createStatus, nil)
+		}
+		if connectErr == nil {
+			socket.Adapter.stats.reconnects.Add(1)
+			socket.logger().Debug("Socket reconnected", "attempts", attempt, "downtime", time.Since(start))
+			return connected, nil
+		}
+		err = connectErr
+	}
+
+	socket.logger().Debug("Socket reconnect failed", "attempts", maxAttempts, "downtime", time.Since(start), "error", err)
+	return nil, fmt.Errorf("socket reconnect failed after %d attempts: %w", maxAttempts, err)
+}
+
+// ConnectWithStatus connects like Connect and then sets the user's initial status, so that followers
+// first see the user online with that status rather than online with an empty one. A nil status
+// connects with the user appearing offline, like Connect with createStatus false. If setting the
+// status fails, the socket stays connected and the error is returned with the session.
+func (socket *DefaultSocket) ConnectWithStatus(session Session, status *string, timeoutMs *int) (*Session, error) {
+	appearOffline := false
+	connected, err := socket.Connect(session, &appearOffline, timeoutMs)
+	if err != nil || status == nil {
+		return connected, err
+	}
+
+	socket.shared.mu.Lock()
+	socket.shared.status = status
+	socket.shared.mu.Unlock()
+	if err := socket.UpdateStatus(status); err != nil {
+		return connected, fmt.Errorf("failed to set initial status: %w", err)
+	}
+	return connected, nil
 }
 
 // Disconnect terminates the WebSocket connection.
 func (socket *DefaultSocket) Disconnect(fireDisconnectEvent bool) {
+	socket.shared.mu.Lock()
+	socket.shared.disconnected = true
+	if socket.shared.stopReconnect != nil {
+		close(socket.shared.stopReconnect)
+		socket.shared.stopReconnect = nil
+	}
+	socket.shared.mu.Unlock()
+
 	if socket.Adapter.IsOpen() {
 		socket.Adapter.Close()
 	}
+	socket.dropFollows()
+	socket.dropPresences()
+	socket.StopHeartbeat()
 	if fireDisconnectEvent {
-		socket.OnDisconnect(fmt.Errorf("socket disconnected"))
+		event := localCloseEvent()
+		socket.OnDisconnect(event)
+		socket.shared.mu.Lock()
+		onClose := socket.shared.handlers.onClose
+		socket.shared.mu.Unlock()
+		if onClose != nil {
+			onClose(event)
+		}
 	}
 }
 
@@ -531,50 +1382,310 @@ func (socket *DefaultSocket) GetHeartbeatTimeoutMs() int {
 	return socket.HeartbeatTimeoutMs
 }
 
-// OnDisconnect handles WebSocket disconnections.
+// StartHeartbeat calls the RPC rpcID with payload every interval, for servers with an application-level
+// liveness handler. It is separate from the protocol-level ping, which keeps running. The result of
+// each call is passed to OnHeartbeat. The heartbeat stops when the connection closes, including before
+// a reconnect, or when StopHeartbeat is called; starting it again replaces the running one.
+func (socket *DefaultSocket) StartHeartbeat(interval time.Duration, rpcID string, payload map[string]interface{}) error {
+	if interval <= 0 {
+		return errors.New("heartbeat interval must be positive")
+	}
+	var encoded string
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to serialize heartbeat payload: %w", err)
+		}
+		encoded = string(data)
+	}
+
+	stop := make(chan struct{})
+	socket.shared.mu.Lock()
+	if socket.shared.stopHeartbeat != nil {
+		close(socket.shared.stopHeartbeat)
+	}
+	socket.shared.stopHeartbeat = stop
+	socket.shared.mu.Unlock()
+
+	go socket.heartbeat(stop, interval, rpcID, encoded)
+	return nil
+}
+
+// StopHeartbeat stops the heartbeat started by StartHeartbeat, if any.
+func (socket *DefaultSocket) StopHeartbeat() {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	if socket.shared.stopHeartbeat != nil {
+		close(socket.shared.stopHeartbeat)
+		socket.shared.stopHeartbeat = nil
+	}
+}
+
+// OnHeartbeat registers a callback invoked with the result of each heartbeat RPC.
+func (socket *DefaultSocket) OnHeartbeat(callback func(*ApiRpc, error)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onHeartbeat = callback
+}
+
+// heartbeat calls the heartbeat RPC until stop is closed. Calls do not overlap: a tick that passes
+// while a call is in flight is skipped.
+func (socket *DefaultSocket) heartbeat(stop chan struct{}, interval time.Duration, rpcID, payload string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		rpc, err := socket.Rpc(rpcID, payload, "")
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		socket.shared.mu.Lock()
+		onHeartbeat := socket.shared.handlers.onHeartbeat
+		socket.shared.mu.Unlock()
+		if onHeartbeat != nil {
+			onHeartbeat(rpc, err)
+		}
+	}
+}
+
+// OnClose sets the callback invoked when the connection closes, with the close code and reason sent
+// by the server, so that a server-forced disconnect, such as an expired token or a kicked session, can
+// be told from a logout. It is invoked by Disconnect only when fireDisconnectEvent is set.
+func (socket *DefaultSocket) OnClose(callback func(*CloseEvent)) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	socket.shared.handlers.onClose = callback
+}
+
+// OnDisconnect handles WebSocket disconnections. The error is a *CloseEvent.
 func (socket *DefaultSocket) OnDisconnect(evt error) {
 	if socket.Verbose {
-		fmt.Println("OnDisconnect:", evt)
+		socket.logger().Debug("OnDisconnect", "error", evt)
 	}
 }
 
 // OnError handles WebSocket errors.
 func (socket *DefaultSocket) OnError(evt error) {
 	if socket.Verbose {
-		fmt.Println("OnError:", evt)
+		socket.logger().Debug("OnError", "error", evt)
 	}
 }
 
 // HandleMessage processes incoming WebSocket messages.
 func (socket *DefaultSocket) HandleMessage(message []byte) {
 	var msg map[string]interface{}
-	if err := json.Unmarshal(message, &msg); err != nil {
+	if err := codecOrStd(socket.Adapter.Codec).Unmarshal(message, &msg); err != nil {
 		if socket.Verbose {
-			fmt.Println("Failed to parse message:", err)
+			socket.logger().Debug("Failed to parse message", "error", err)
 		}
 		return
 	}
 
+	decodeReceivedData(msg, "match_data")
+	decodeReceivedData(msg, "party_data")
+	socket.handleEnvelope(msg)
+}
+
+// handleEnvelope resolves a pending request or dispatches an event for a decoded message.
+func (socket *DefaultSocket) handleEnvelope(msg map[string]interface{}) {
 	if cid, ok := msg["cid"].(string); ok {
-		executor, exists := socket.cIds[cid]
+		socket.shared.mu.Lock()
+		executor, exists := socket.shared.cIds[cid]
+		delete(socket.shared.cIds, cid)
+		socket.shared.mu.Unlock()
+
 		if exists {
-			delete(socket.cIds, cid)
-			if _, hasError := msg["error"]; hasError {
-				executor.Reject(errors.New(msg["error"].(string)))
+			if rawError, hasError := msg["error"]; hasError {
+				var socketError SocketError
+				if err := socket.decodeField(rawError, &socketError); err != nil {
+					executor.Reject(fmt.Errorf("socket error: %v", rawError))
+				} else {
+					executor.Reject(&socketError)
+				}
 			} else {
+				socket.trackTicket(msg)
 				executor.Resolve(msg)
 			}
 		} else {
 			if socket.Verbose {
-				fmt.Println("No promise executor for message CID:", cid)
+				socket.logger().Debug("No promise executor for message", "cid", cid)
 			}
 		}
 	} else {
-		// Handle different message types here (notifications, match data, etc.)
+		socket.dispatchEvent(msg)
+	}
+}
+
+// logger returns the logger of the underlying adapter.
+func (socket *DefaultSocket) logger() Logger {
+	if socket.Adapter == nil {
+		return NoopLogger{}
+	}
+	return socket.Adapter.logger()
+}
+
+// dispatchEvent routes a server-initiated message to the registered typed callback.
+func (socket *DefaultSocket) dispatchEvent(msg map[string]interface{}) {
+	socket.shared.mu.Lock()
+	handlers := socket.shared.handlers
+	socket.shared.mu.Unlock()
+
+	var err error
+	switch {
+	case msg["channel_presence_event"] != nil:
+		var event ChannelPresenceEvent
+		if err = socket.decodeField(msg["channel_presence_event"], &event); err == nil {
+			socket.updatePresences(event.ChannelID, event.PresenceEvent)
+			if handlers.onChannelPresence != nil {
+				handlers.onChannelPresence(event)
+			}
+		}
+	case msg["match_presence_event"] != nil:
+		var event MatchPresenceEvent
+		if err = socket.decodeField(msg["match_presence_event"], &event); err == nil {
+			socket.updatePresences(event.MatchID, event.PresenceEvent)
+			if handlers.onMatchPresence != nil {
+				handlers.onMatchPresence(event)
+			}
+		}
+	case msg["match_data"] != nil:
+		var data MatchData
+		if data, err = socket.decodeMatchData(msg["match_data"]); err == nil {
+			if gap := socket.receiveMatchSequence(&data); gap != nil && handlers.onMatchDataGap != nil {
+				handlers.onMatchDataGap(*gap)
+			}
+			if handlers.onMatchData != nil {
+				handlers.onMatchData(data)
+			}
+		}
+	case msg["matchmaker_matched"] != nil:
+		var event MatchmakerMatched
+		if err = socket.decodeField(msg["matchmaker_matched"], &event); err == nil {
+			socket.shared.mu.Lock()
+			delete(socket.shared.tickets, event.Ticket)
+			socket.shared.mu.Unlock()
+			if handlers.onMatchmakerMatched != nil {
+				handlers.onMatchmakerMatched(event)
+			}
+		}
+	case msg["notifications"] != nil:
+		var event struct {
+			Notifications []ApiNotification `json:"notifications"`
+		}
+		if err = socket.decodeField(msg["notifications"], &event); err == nil {
+			err = socket.dispatchNotifications(handlers, event.Notifications)
+		}
+	case msg["party_presence_event"] != nil:
+		var event PartyPresenceEvent
+		if err = socket.decodeField(msg["party_presence_event"], &event); err == nil {
+			socket.updatePresences(event.PartyID, event.PresenceEvent)
+			if handlers.onPartyPresence != nil {
+				handlers.onPartyPresence(event)
+			}
+		}
+	case msg["party_leader"] != nil && handlers.onPartyLeader != nil:
+		var event PartyLeader
+		if err = socket.decodeField(msg["party_leader"], &event); err == nil {
+			handlers.onPartyLeader(event)
+		}
+	case msg["party_close"] != nil:
+		var event PartyClose
+		if err = socket.decodeField(msg["party_close"], &event); err == nil {
+			socket.untrack(SubscriptionParty, event.PartyID)
+			if handlers.onPartyClose != nil {
+				handlers.onPartyClose(event)
+			}
+		}
+	case msg["party_join_request"] != nil && handlers.onPartyJoinRequest != nil:
+		var event PartyJoinRequest
+		if err = socket.decodeField(msg["party_join_request"], &event); err == nil {
+			handlers.onPartyJoinRequest(event)
+		}
+	case msg["status_presence_event"] != nil && handlers.onStatusPresence != nil:
+		var event StatusPresenceEvent
+		if err = socket.decodeField(msg["status_presence_event"], &event); err == nil {
+			handlers.onStatusPresence(event)
+		}
+	case msg["stream_presence_event"] != nil && handlers.onStreamPresence != nil:
+		var event StreamPresenceEvent
+		if err = socket.decodeField(msg["stream_presence_event"], &event); err == nil {
+			handlers.onStreamPresence(event)
+		}
+	default:
 		if socket.Verbose {
-			fmt.Println("Message received:", string(message))
+			socket.logger().Debug("Message received", "message", msg)
 		}
 	}
+
+	if err != nil {
+		socket.OnError(fmt.Errorf("failed to decode socket event: %w", err))
+	}
+}
+
+// dispatchNotifications delivers notifications to the notification and group removal callbacks.
+func (socket *DefaultSocket) dispatchNotifications(handlers socketHandlers, notifications []ApiNotification) error {
+	socket.shared.mu.Lock()
+	removalCodes := socket.shared.removalCodes
+	socket.shared.mu.Unlock()
+	for _, n := range notifications {
+		notification, err := notificationFromApi(n, socket.Adapter.Codec)
+		if err != nil {
+			return err
+		}
+		if handlers.onNotification != nil {
+			handlers.onNotification(*notification)
+		}
+
+		if !slices.Contains(removalCodes, intValue(notification.Code)) {
+			continue
+		}
+		groupID, _ := notification.Content["group_id"].(string)
+		if groupID == "" {
+			continue
+		}
+		socket.untrackGroupChannels(groupID)
+		if handlers.onGroupRemoved != nil {
+			handlers.onGroupRemoved(groupID, *notification)
+		}
+	}
+	return nil
+}
+
+// untrackGroupChannels stops re-joining the chat channels of a group the user was removed from.
+func (socket *DefaultSocket) untrackGroupChannels(groupID string) {
+	socket.shared.mu.Lock()
+	defer socket.shared.mu.Unlock()
+	for key, subscription := range socket.shared.subscriptions {
+		join, _ := subscription.join["channel_join"].(map[string]interface{})
+		if key.kind == SubscriptionChannel && join["target"] == groupID && join["type"] == ChatTypeGroup {
+			delete(socket.shared.subscriptions, key)
+		}
+	}
+}
+
+// decodeEnvelopeField converts a decoded envelope field into the given typed value with codec, or
+// encoding/json if codec is nil.
+func decodeEnvelopeField(codec Codec, value interface{}, out interface{}) error {
+	codec = codecOrStd(codec)
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(data, out)
+}
+
+// decodeField converts a decoded envelope field into the given typed value with the adapter's codec.
+func (socket *DefaultSocket) decodeField(value interface{}, out interface{}) error {
+	return decodeEnvelopeField(socket.Adapter.Codec, value, out)
 }
 
 // Send sends a message to the WebSocket server with optional timeout.
@@ -589,35 +1700,95 @@ func (socket *DefaultSocket) Send(message interface{}, sendTimeout *int) error {
 	}
 
 	cid := socket.GenerateCID()
-	socket.cIds[cid] = &PromiseExecutor{
+	socket.shared.mu.Lock()
+	socket.shared.cIds[cid] = &PromiseExecutor{
 		Resolve: func(result interface{}) {
 			if socket.Verbose {
-				fmt.Println("Message sent successfully")
+				socket.logger().Debug("Message sent successfully")
 			}
 		},
 		Reject: func(e error) {
 			if socket.Verbose {
-				fmt.Println("Message failed:", e)
+				socket.logger().Debug("Message failed", "error", e)
 			}
 		},
 	}
+	socket.shared.mu.Unlock()
 
 	err := socket.Adapter.Send(message)
 	if err != nil {
-		log.Print(err)
+		socket.logger().Error("Failed to send message", "error", err)
 		return err
 	}
 
 	// Set a timeout for the send operation
 	go func(cid string) {
 		time.Sleep(time.Duration(*sendTimeout) * time.Millisecond)
-		delete(socket.cIds, cid)
+		socket.shared.mu.Lock()
+		delete(socket.shared.cIds, cid)
+		socket.shared.mu.Unlock()
 	}(cid)
 
 	return nil
 }
 
-// ReadResponse reads and parses the next response from the WebSocket connection.
+// sendAndWait sends a message tagged with a fresh cid and waits for the server's correlated
+// response, or for the timeout to elapse. A nil timeout uses the socket's SendTimeoutMs.
+func (socket *DefaultSocket) sendAndWait(message map[string]interface{}, timeoutMs *int) (map[string]interface{}, error) {
+	if timeoutMs == nil {
+		timeoutMs = &socket.SendTimeoutMs
+	}
+
+	if !socket.Adapter.IsOpen() {
+		return nil, errors.New("socket connection is not established")
+	}
+
+	responseChan := make(chan map[string]interface{}, 1)
+	errorChan := make(chan error, 1)
+
+	cid := socket.GenerateCID()
+	socket.shared.mu.Lock()
+	socket.shared.cIds[cid] = &PromiseExecutor{
+		Resolve: func(value interface{}) {
+			response, _ := value.(map[string]interface{})
+			responseChan <- response
+		},
+		Reject: func(reason error) {
+			errorChan <- reason
+		},
+	}
+	socket.shared.mu.Unlock()
+
+	removeExecutor := func() {
+		socket.shared.mu.Lock()
+		delete(socket.shared.cIds, cid)
+		socket.shared.mu.Unlock()
+	}
+
+	message["cid"] = cid
+	if err := socket.Adapter.Send(message); err != nil {
+		removeExecutor()
+		return nil, err
+	}
+
+	timer := time.NewTimer(time.Duration(*timeoutMs) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case response := <-responseChan:
+		return response, nil
+	case err := <-errorChan:
+		return nil, err
+	case <-timer.C:
+		removeExecutor()
+		return nil, errors.New("socket request timed out")
+	}
+}
+
+// Read reads and parses the next response from the WebSocket connection.
+//
+// Deprecated: once connected, messages are consumed by the adapter's receive loop and delivered
+// through correlated responses and event callbacks. Read competes with that loop for messages.
 func (socket *DefaultSocket) Read() (map[string]interface{}, error) {
 	if !socket.Adapter.IsOpen() {
 		return nil, errors.New("socket connection is not established")
@@ -629,7 +1800,7 @@ func (socket *DefaultSocket) Read() (map[string]interface{}, error) {
 	}
 
 	var response map[string]interface{}
-	if err := json.Unmarshal(message, &response); err != nil {
+	if err := codecOrStd(socket.Adapter.Codec).Unmarshal(message, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse socket response: %w", err)
 	}
 
@@ -638,34 +1809,26 @@ func (socket *DefaultSocket) Read() (map[string]interface{}, error) {
 
 // CreateMatch sends a request to create a match and returns the created Match.
 func (socket *DefaultSocket) CreateMatch(name *string) (*Match, error) {
-	request := CreateMatch{
-		MatchCreate: struct {
-			Name *string `json:"name,omitempty"`
-		}{Name: name},
+	matchCreate := map[string]interface{}{}
+	if name != nil {
+		matchCreate["name"] = *name
 	}
-
-	err := socket.Send(request, nil)
-	if err != nil {
-		return nil, err
+	request := map[string]interface{}{
+		"match_create": matchCreate,
 	}
 
-	response, err := socket.Read()
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
-		log.Printf("Failed to read response: %v\n", err)
 		return nil, err
 	}
 
 	if matchData, ok := response["match"]; ok {
-		matchBytes, err := json.Marshal(matchData)
-		if err != nil {
-			return nil, fmt.Errorf("failed to serialize match data: %w", err)
-		}
-
 		var match Match
-		if err := json.Unmarshal(matchBytes, &match); err != nil {
+		if err := socket.decodeField(matchData, &match); err != nil {
 			return nil, fmt.Errorf("failed to deserialize match data into Match struct: %w", err)
 		}
 
+		socket.trackMatch(&match, nil)
 		return &match, nil
 	}
 
@@ -681,15 +1844,29 @@ func (socket *DefaultSocket) CreateParty(open bool, maxSize int) (*Party, error)
 		},
 	}
 
-	err := socket.Send(request, nil)
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Party{Open: open, MaxSize: maxSize}, nil
+	if response["party"] == nil {
+		return nil, fmt.Errorf("invalid response format: missing or invalid party field")
+	}
+	var party Party
+	if err := socket.decodeField(response["party"], &party); err != nil {
+		return nil, fmt.Errorf("failed to deserialize party data into Party struct: %w", err)
+	}
+
+	socket.track(SubscriptionParty, party.PartyID, map[string]interface{}{
+		"party_join": map[string]interface{}{"party_id": party.PartyID},
+	})
+	socket.setPresences(party.PartyID, party.Self, party.Presences)
+	return &party, nil
 }
 
-// FollowUsers sends a request to follow a list of user IDs and returns the status.
+// FollowUsers sends a request to follow a list of user IDs and returns the status of those online.
+// Followed users are tracked until they are unfollowed or the connection closes; see FollowedUsers
+// and SetRefollowUsers.
 func (socket *DefaultSocket) FollowUsers(userIds []string) (*Status, error) {
 	request := map[string]interface{}{
 		"status_follow": map[string]interface{}{
@@ -697,21 +1874,33 @@ func (socket *DefaultSocket) FollowUsers(userIds []string) (*Status, error) {
 		},
 	}
 
-	var response map[string]interface{}
-	err := socket.Send(request, nil)
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if respStatus, ok := response["status"].(*Status); ok {
-		return respStatus, nil
+	var status Status
+	if response["status"] != nil {
+		if err := socket.decodeField(response["status"], &status); err != nil {
+			return nil, fmt.Errorf("failed to deserialize status: %w", err)
+		}
 	}
 
-	return nil, fmt.Errorf("invalid response format")
+	socket.shared.mu.Lock()
+	for _, userID := range userIds {
+		socket.shared.followed[userID] = true
+	}
+	socket.shared.mu.Unlock()
+	return &status, nil
 }
 
 // JoinChat sends a request to join a chat and returns the joined Channel.
+// The chat type is one of the ChatType constants; see also JoinChatTarget.
 func (socket *DefaultSocket) JoinChat(target string, chatType int, persistence, hidden bool) (*Channel, error) {
+	if err := validateChatTarget(target, chatType); err != nil {
+		return nil, err
+	}
+
 	request := map[string]interface{}{
 		"channel_join": map[string]interface{}{
 			"target":      target,
@@ -721,61 +1910,125 @@ func (socket *DefaultSocket) JoinChat(target string, chatType int, persistence,
 		},
 	}
 
-	var response map[string]interface{}
-	err := socket.Send(request, nil)
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if channel, ok := response["channel"].(*Channel); ok {
-		return channel, nil
+	if response["channel"] == nil {
+		return nil, fmt.Errorf("invalid response format: missing or invalid channel field")
+	}
+	var channel Channel
+	if err := socket.decodeField(response["channel"], &channel); err != nil {
+		return nil, fmt.Errorf("failed to deserialize channel data into Channel struct: %w", err)
 	}
 
-	return nil, fmt.Errorf("invalid response format: missing or invalid channel field")
+	socket.track(SubscriptionChannel, channel.ID, request)
+	socket.setPresences(channel.ID, channel.Self, channel.Presences)
+	return &channel, nil
+}
+
+// JoinChatTarget joins the chat channel identified by target, as built by RoomTarget,
+// DirectMessageTarget or GroupChannelTarget.
+func (socket *DefaultSocket) JoinChatTarget(target ChatTarget, persistence, hidden bool) (*Channel, error) {
+	return socket.JoinChat(target.Target, target.Type, persistence, hidden)
 }
 
-// JoinMatch sends a request to join a match and returns the joined Match.
+// JoinMatch sends a request to join a match and returns the joined Match. The metadata, such as a
+// team or role hint, is passed to the match handler's join attempt; a nil or empty map is not sent.
+// The server only accepts string values, so other values are sent JSON-encoded. Nakama does not attach
+// the metadata to the joining presence, so other clients only see it if the match handler broadcasts it.
 func (socket *DefaultSocket) JoinMatch(matchID, token *string, metadata *map[string]interface{}) (*Match, error) {
-	request := map[string]interface{}{
-		"match_join": map[string]interface{}{
-			"metadata": metadata,
-		},
+	join := map[string]interface{}{}
+	if encoded := matchJoinMetadata(metadata); encoded != nil {
+		join["metadata"] = encoded
 	}
+	request := map[string]interface{}{"match_join": join}
 
 	if token != nil && *token != "" {
-		request["match_join"].(map[string]interface{})["token"] = token
+		join["token"] = token
 	} else {
-		request["match_join"].(map[string]interface{})["match_id"] = matchID
+		join["match_id"] = matchID
 	}
 
-	err := socket.Send(request, nil)
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := socket.Read()
-	if err != nil {
-		log.Printf("Failed to read response: %v\n", err)
-		return nil, err
-	}
-
 	if matchData, ok := response["match"]; ok {
-		matchBytes, err := json.Marshal(matchData)
-		if err != nil {
-			return nil, fmt.Errorf("failed to serialize match data: %w", err)
-		}
-
 		var match Match
-		if err := json.Unmarshal(matchBytes, &match); err != nil {
+		if err := socket.decodeField(matchData, &match); err != nil {
 			return nil, fmt.Errorf("failed to deserialize match data into Match struct: %w", err)
 		}
 
+		socket.trackMatch(&match, metadata)
+		socket.setPresences(match.MatchID, match.Self, match.Presences)
 		return &match, nil
 	}
 
 	return nil, fmt.Errorf("invalid response format: missing or invalid match field")
 }
 
+// trackMatch records a joined match. It is re-joined by ID, since match tokens expire.
+func (socket *DefaultSocket) trackMatch(match *Match, metadata *map[string]interface{}) {
+	join := map[string]interface{}{"match_id": match.MatchID}
+	if encoded := matchJoinMetadata(metadata); encoded != nil {
+		join["metadata"] = encoded
+	}
+	socket.track(SubscriptionMatch, match.MatchID, map[string]interface{}{"match_join": join})
+}
+
+// matchJoinMetadata encodes match join metadata as the string map the server expects, or returns nil
+// if there is none.
+func matchJoinMetadata(metadata *map[string]interface{}) map[string]string {
+	if metadata == nil || len(*metadata) == 0 {
+		return nil
+	}
+	encoded := make(map[string]string, len(*metadata))
+	for key, value := range *metadata {
+		if s, ok := value.(string); ok {
+			encoded[key] = s
+			continue
+		}
+		bytes, err := json.Marshal(value)
+		if err != nil {
+			bytes = []byte(fmt.Sprint(value))
+		}
+		encoded[key] = string(bytes)
+	}
+	return encoded
+}
+
+// JoinMatched joins the match of a MatchmakerMatched event, by match ID or by token as its JoinKind
+// requires.
+func (socket *DefaultSocket) JoinMatched(matched MatchmakerMatched) (*Match, error) {
+	switch matched.JoinKind() {
+	case MatchmakerJoinByID:
+		return socket.JoinMatch(&matched.MatchID, nil, nil)
+	case MatchmakerJoinByToken:
+		return socket.JoinMatchByToken(matched.Token)
+	default:
+		return nil, errors.New("matchmaker result has neither a match ID nor a token")
+	}
+}
+
+// JoinMatchByToken joins a match using the token from a MatchmakerMatched event.
+// A stale or expired token yields a *MatchTokenError.
+func (socket *DefaultSocket) JoinMatchByToken(token string) (*Match, error) {
+	if token == "" {
+		return nil, errors.New("match token is required")
+	}
+
+	match, err := socket.JoinMatch(nil, &token, nil)
+	var socketError *SocketError
+	if errors.As(err, &socketError) &&
+		(socketError.Code == SocketErrorBadInput || socketError.Code == SocketErrorMatchNotFound) {
+		return nil, &MatchTokenError{Token: token, Cause: socketError}
+	}
+	return match, err
+}
+
 // JoinParty sends a request to join a party.
 func (socket *DefaultSocket) JoinParty(partyID string) error {
 	request := map[string]interface{}{
@@ -784,10 +2037,19 @@ func (socket *DefaultSocket) JoinParty(partyID string) error {
 		},
 	}
 
-	if err := socket.Send(request, nil); err != nil {
+	response, err := socket.sendAndWait(request, nil)
+	if err != nil {
 		return err
 	}
 
+	socket.track(SubscriptionParty, partyID, request)
+	if response["party"] != nil {
+		if err := socket.seedPresences(response); err != nil {
+			return fmt.Errorf("failed to deserialize party data into Party struct: %w", err)
+		}
+	} else {
+		socket.setPresences(partyID, Presence{}, nil)
+	}
 	return nil
 }
 
@@ -803,6 +2065,7 @@ func (socket *DefaultSocket) LeaveChat(channelID string) error {
 		return err
 	}
 
+	socket.untrack(SubscriptionChannel, channelID)
 	return nil
 }
 
@@ -818,6 +2081,7 @@ func (socket *DefaultSocket) LeaveMatch(matchID string) error {
 		return err
 	}
 
+	socket.untrack(SubscriptionMatch, matchID)
 	return nil
 }
 
@@ -833,28 +2097,45 @@ func (socket *DefaultSocket) LeaveParty(partyID string) error {
 		return err
 	}
 
+	socket.untrack(SubscriptionParty, partyID)
 	return nil
 }
 
-// ListPartyJoinRequests fetches the list of join requests for a given party ID.
-func (socket *DefaultSocket) ListPartyJoinRequests(partyID string) (*PartyJoinRequest, error) {
+// ListPartyJoinRequests fetches the pending join requests of a closed party. Only the leader can list them.
+func (socket *DefaultSocket) ListPartyJoinRequests(partyID string) (*PartyJoinRequestList, error) {
 	request := map[string]interface{}{
 		"party_join_request_list": map[string]interface{}{
 			"party_id": partyID,
 		},
 	}
 
-	var response map[string]interface{}
-	err := socket.Send(request, nil)
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if joinRequest, ok := response["party_join_request"].(*PartyJoinRequest); ok {
-		return joinRequest, nil
+	if response["party_join_request"] == nil {
+		return nil, fmt.Errorf("invalid response format: missing or invalid party_join_request field")
+	}
+	var list PartyJoinRequestList
+	if err := socket.decodeField(response["party_join_request"], &list); err != nil {
+		return nil, fmt.Errorf("failed to deserialize party join requests: %w", err)
+	}
+	return &list, nil
+}
+
+// AcceptPartyMember accepts a pending request to join a closed party. Only the leader can accept
+// requests; reject one by removing the user with RemovePartyMember.
+func (socket *DefaultSocket) AcceptPartyMember(partyID string, presence Presence) error {
+	request := map[string]interface{}{
+		"party_accept": map[string]interface{}{
+			"party_id": partyID,
+			"presence": presence,
+		},
 	}
 
-	return nil, fmt.Errorf("invalid response format: missing or invalid party_join_request field")
+	_, err := socket.sendAndWait(request, nil)
+	return err
 }
 
 // RemoveChatMessage sends a request to remove a chat message and returns the ChannelMessageAck.
@@ -879,8 +2160,9 @@ func (socket *DefaultSocket) RemoveChatMessage(channelID, messageID string) (*Ch
 	return nil, fmt.Errorf("invalid response format: missing or invalid channel_message_ack field")
 }
 
-// PromotePartyMember promotes a party member to party leader and returns the new PartyLeader.
-func (socket *DefaultSocket) PromotePartyMember(partyID string, partyMember Presence) (*PartyLeader, error) {
+// PromotePartyMember promotes a party member to party leader. Only the current leader can promote.
+// Every member, including the caller, is told of the new leader through OnPartyLeader.
+func (socket *DefaultSocket) PromotePartyMember(partyID string, partyMember Presence) error {
 	request := map[string]interface{}{
 		"party_promote": map[string]interface{}{
 			"party_id": partyID,
@@ -888,35 +2170,111 @@ func (socket *DefaultSocket) PromotePartyMember(partyID string, partyMember Pres
 		},
 	}
 
-	var response map[string]interface{}
-	err := socket.Send(request, nil)
+	_, err := socket.sendAndWait(request, nil)
+	return err
+}
+
+// AddMatchmaker enters the user into matchmaking and returns the ticket, which is tracked until it
+// matches or is removed with RemoveMatchmaker. The result is delivered through OnMatchmakerMatched.
+func (socket *DefaultSocket) AddMatchmaker(query string, minCount, maxCount int, stringProperties map[string]string, numericProperties map[string]float64) (*MatchmakerTicket, error) {
+	request := map[string]interface{}{
+		"matchmaker_add": map[string]interface{}{
+			"query":              query,
+			"min_count":          minCount,
+			"max_count":          maxCount,
+			"string_properties":  stringProperties,
+			"numeric_properties": numericProperties,
+		},
+	}
+
+	response, err := socket.sendAndWait(request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if partyLeader, ok := response["party_leader"].(*PartyLeader); ok {
-		return partyLeader, nil
+	if response["matchmaker_ticket"] == nil {
+		return nil, fmt.Errorf("invalid response format: missing or invalid matchmaker_ticket field")
 	}
+	var ticket MatchmakerTicket
+	if err := socket.decodeField(response["matchmaker_ticket"], &ticket); err != nil {
+		return nil, fmt.Errorf("failed to deserialize matchmaker ticket: %w", err)
+	}
+	return &ticket, nil
+}
 
-	return nil, fmt.Errorf("invalid response format: missing or invalid party_leader field")
+// trackTicket records the ticket of a matchmaker_add response as outstanding. It runs before the
+// response is resolved, so that a matchmaker_matched event read right after it finds the ticket.
+func (socket *DefaultSocket) trackTicket(msg map[string]interface{}) {
+	if msg["matchmaker_ticket"] == nil {
+		return
+	}
+	var ticket MatchmakerTicket
+	if err := socket.decodeField(msg["matchmaker_ticket"], &ticket); err != nil {
+		return
+	}
+	socket.shared.mu.Lock()
+	socket.shared.tickets[ticket.Ticket] = true
+	socket.shared.mu.Unlock()
 }
 
-// RemoveMatchmaker sends a request to remove a matchmaker ticket.
+// RemoveMatchmaker removes a matchmaker ticket added through this connection. It returns
+// ErrUnknownTicket without contacting the server if the ticket is not outstanding, for example
+// because it already matched or was lost on a reconnect.
 func (socket *DefaultSocket) RemoveMatchmaker(ticket string) error {
+	socket.shared.mu.Lock()
+	outstanding := socket.shared.tickets[ticket]
+	socket.shared.mu.Unlock()
+	if !outstanding {
+		return fmt.Errorf("%w: %s", ErrUnknownTicket, ticket)
+	}
+
 	request := map[string]interface{}{
 		"matchmaker_remove": map[string]interface{}{
 			"ticket": ticket,
 		},
 	}
 
-	if err := socket.Send(request, nil); err != nil {
+	if _, err := socket.sendAndWait(request, nil); err != nil {
 		return err
 	}
 
+	socket.shared.mu.Lock()
+	delete(socket.shared.tickets, ticket)
+	socket.shared.mu.Unlock()
 	return nil
 }
 
-// RemoveMatchmakerParty sends a request to remove a matchmaker ticket from a party.
+// AddMatchmakerParty enters a party into matchmaking as a group and returns its ticket. Only the
+// leader can add the party. Every member receives the result through OnMatchmakerMatched, with the
+// party ID set on the matched users that belong to the party.
+func (socket *DefaultSocket) AddMatchmakerParty(partyID, query string, minCount, maxCount int, stringProperties map[string]string, numericProperties map[string]float64) (*MatchmakerTicket, error) {
+	request := map[string]interface{}{
+		"party_matchmaker_add": map[string]interface{}{
+			"party_id":           partyID,
+			"query":              query,
+			"min_count":          minCount,
+			"max_count":          maxCount,
+			"string_properties":  stringProperties,
+			"numeric_properties": numericProperties,
+		},
+	}
+
+	response, err := socket.sendAndWait(request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response["party_matchmaker_ticket"] == nil {
+		return nil, fmt.Errorf("invalid response format: missing or invalid party_matchmaker_ticket field")
+	}
+	var ticket PartyMatchmakerTicket
+	if err := socket.decodeField(response["party_matchmaker_ticket"], &ticket); err != nil {
+		return nil, fmt.Errorf("failed to deserialize party matchmaker ticket: %w", err)
+	}
+	return &MatchmakerTicket{Ticket: ticket.Ticket}, nil
+}
+
+// RemoveMatchmakerParty removes a party's matchmaker ticket. Only the leader can remove it.
 func (socket *DefaultSocket) RemoveMatchmakerParty(partyID, ticket string) error {
 	request := map[string]interface{}{
 		"party_matchmaker_remove": map[string]interface{}{
@@ -925,14 +2283,12 @@ func (socket *DefaultSocket) RemoveMatchmakerParty(partyID, ticket string) error
 		},
 	}
 
-	if err := socket.Send(request, nil); err != nil {
-		return err
-	}
-
-	return nil
+	_, err := socket.sendAndWait(request, nil)
+	return err
 }
 
-// RemovePartyMember sends a request to remove a member from a party.
+// RemovePartyMember removes a member from a party, or rejects a pending join request. Only the
+// leader can remove members.
 func (socket *DefaultSocket) RemovePartyMember(partyID string, member Presence) error {
 	request := map[string]interface{}{
 		"party_remove": map[string]interface{}{
@@ -941,11 +2297,18 @@ func (socket *DefaultSocket) RemovePartyMember(partyID string, member Presence)
 		},
 	}
 
-	if err := socket.Send(request, nil); err != nil {
-		return err
-	}
+	_, err := socket.sendAndWait(request, nil)
+	return err
+}
 
-	return nil
+// Ping sends a ping and returns the round-trip time of the server's correlated pong.
+// It fails if no reply arrives within the socket's send timeout.
+func (socket *DefaultSocket) Ping() (time.Duration, error) {
+	start := time.Now()
+	if _, err := socket.sendAndWait(map[string]interface{}{"ping": map[string]interface{}{}}, nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
 }
 
 // Rpc sends an RPC request and returns an ApiRpc response.
@@ -958,20 +2321,98 @@ func (socket *DefaultSocket) Rpc(id, payload, httpKey string) (*ApiRpc, error) {
 		},
 	}
 
-	var response map[string]interface{}
-	if err := socket.Send(request, nil); err != nil {
+	response, err := socket.sendAndWait(request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response["rpc"] == nil {
+		return nil, fmt.Errorf("invalid response format: missing or invalid rpc field")
+	}
+	var rpc ApiRpc
+	if err := socket.decodeField(response["rpc"], &rpc); err != nil {
+		return nil, fmt.Errorf("failed to deserialize rpc data into ApiRpc struct: %w", err)
+	}
+	return &rpc, nil
+}
+
+// SocketRpcTyped executes an RPC function through the socket with input encoded as JSON, and decodes
+// the response payload into a T. It is the socket counterpart of RpcTyped.
+func SocketRpcTyped[T any](socket *DefaultSocket, id string, input any) (*T, error) {
+	inputJson, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize input to JSON: %w", err)
+	}
+
+	rpc, err := socket.Rpc(id, string(inputJson), "")
+	if err != nil {
 		return nil, err
 	}
 
-	if rpc, ok := response["rpc"].(*ApiRpc); ok {
-		return rpc, nil
+	var result T
+	if rpc.Payload != nil {
+		if err := DecodeJSONField(*rpc.Payload, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode rpc payload: %w", err)
+		}
+	}
+	return &result, nil
+}
+
+// MatchSignal sends data to the signal handler of an authoritative match and returns the handler's
+// response data. Stock Nakama servers only expose match signals to server runtime code, through
+// nk.MatchSignal, so against them this fails with ErrMatchSignalUnsupported; route the signal
+// through an RPC instead. The same error is returned when the match has no signal handler.
+func (socket *DefaultSocket) MatchSignal(matchID, data string) (string, error) {
+	request := map[string]interface{}{
+		"match_signal": map[string]interface{}{
+			"match_id": matchID,
+			"data":     data,
+		},
 	}
 
-	return nil, fmt.Errorf("invalid response format: missing or invalid rpc field")
+	response, err := socket.sendAndWait(request, nil)
+	if err != nil {
+		var socketErr *SocketError
+		if errors.As(err, &socketErr) && (socketErr.Code == SocketErrorUnrecognizedPayload || socketErr.Code == SocketErrorRuntimeFunctionNotFound) {
+			return "", fmt.Errorf("%w: %w", ErrMatchSignalUnsupported, err)
+		}
+		return "", err
+	}
+
+	signal, ok := response["match_signal"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid response format: missing or invalid match_signal field")
+	}
+	result, _ := signal["data"].(string)
+	return result, nil
 }
 
-// SendMatchState sends match state updates to the server.
+// SendMatchState sends match state updates to the server. While SetMatchDataSequencing is on, data
+// must be a []byte or a string, and is sent with its sequence number prefixed. A match has a single
+// sequence for all its players, so sequenced data is always sent to every presence, and a non-empty
+// presences is rejected with ErrSequencedMatchPresences.
 func (socket *DefaultSocket) SendMatchState(matchID string, opCode int, data interface{}, presences []Presence, reliable bool) error {
+	socket.shared.matchSend.Lock()
+	defer socket.shared.matchSend.Unlock()
+
+	sequence := socket.nextMatchSequence(matchID)
+	if sequence != 0 {
+		if len(presences) > 0 {
+			return ErrSequencedMatchPresences
+		}
+		var payload []byte
+		switch v := data.(type) {
+		case []byte:
+			payload = v
+		case string:
+			payload = []byte(v)
+		default:
+			return fmt.Errorf("sequenced match data must be []byte or string, got %T", data)
+		}
+		sequenced := binary.BigEndian.AppendUint64(make([]byte, 0, matchSequenceBytes+len(payload)), sequence)
+		data = append(sequenced, payload...)
+	}
+
 	request := map[string]interface{}{
 		"match_data_send": map[string]interface{}{
 			"match_id":  matchID,
@@ -985,6 +2426,9 @@ func (socket *DefaultSocket) SendMatchState(matchID string, opCode int, data int
 	if err := socket.Send(request, nil); err != nil {
 		return err
 	}
+	if sequence != 0 {
+		socket.sentMatchSequence(matchID, sequence)
+	}
 
 	return nil
 }
@@ -1014,10 +2458,15 @@ func (socket *DefaultSocket) UnfollowUsers(userIDs []string) error {
 		},
 	}
 
-	if err := socket.Send(request, nil); err != nil {
+	if _, err := socket.sendAndWait(request, nil); err != nil {
 		return err
 	}
 
+	socket.shared.mu.Lock()
+	for _, userID := range userIDs {
+		delete(socket.shared.followed, userID)
+	}
+	socket.shared.mu.Unlock()
 	return nil
 }
 
@@ -1043,19 +2492,36 @@ func (socket *DefaultSocket) UpdateChatMessage(channelID, messageID string, cont
 	return nil, fmt.Errorf("invalid response format: missing or invalid channel_message_ack field")
 }
 
-// UpdateStatus sends a status update to the server.
+// UpdateStatus sets the user's status, making them appear online to followers. A nil status makes
+// the user appear offline. A status longer than MaxStatusLength fails with ErrStatusTooLong.
 func (socket *DefaultSocket) UpdateStatus(status *string) error {
+	if status != nil && len(*status) > MaxStatusLength {
+		return fmt.Errorf("%w: %d bytes, must be at most %d", ErrStatusTooLong, len(*status), MaxStatusLength)
+	}
+
 	request := map[string]interface{}{
 		"status_update": map[string]interface{}{
 			"status": status,
 		},
 	}
 
-	if err := socket.Send(request, nil); err != nil {
-		return err
-	}
+	_, err := socket.sendAndWait(request, nil)
+	return err
+}
 
-	return nil
+// UpdateStatusJSON sets the user's status to status encoded as JSON, for structured "rich presence"
+// such as the current mode or level. Followers read it back with Presence.DecodeStatus. A nil status
+// makes the user appear offline.
+func (socket *DefaultSocket) UpdateStatusJSON(status any) error {
+	if status == nil {
+		return socket.UpdateStatus(nil)
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to serialize status to JSON: %w", err)
+	}
+	encoded := string(data)
+	return socket.UpdateStatus(&encoded)
 }
 
 // WriteChatMessage sends a chat message and returns the ChannelMessageAck.
@@ -1083,15 +2549,13 @@ func (socket *DefaultSocket) WriteChatMessage(channelID string, content interfac
 func (socket *DefaultSocket) pingPong() {
 	ticker := time.NewTicker(time.Duration(socket.HeartbeatTimeoutMs) * time.Millisecond)
 	defer ticker.Stop()
-	log.Println("before pingpong socket is nil:", socket.Adapter.socket == nil)
 
 	for {
 		select {
 		case <-ticker.C:
 			ping := map[string]interface{}{"ping": struct{}{}}
 			if err := socket.Send(ping, &socket.HeartbeatTimeoutMs); err != nil {
-				log.Println("after pingpong socket is nil:", socket.Adapter.socket == nil)
-				log.Println("Failed to send ping:", err)
+				socket.logger().Warn("Failed to send ping", "error", err)
 				if socket.Adapter.IsOpen() {
 					socket.OnHeartbeatTimeout()
 					socket.Adapter.Close()
@@ -1105,6 +2569,6 @@ func (socket *DefaultSocket) pingPong() {
 // OnHeartbeatTimeout handles heartbeat timeouts.
 func (socket *DefaultSocket) OnHeartbeatTimeout() {
 	if socket.Verbose {
-		fmt.Println("Heartbeat timeout")
+		socket.logger().Debug("Heartbeat timeout")
 	}
 }
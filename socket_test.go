@@ -1,8 +1,20 @@
 package nakama
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,3 +70,1301 @@ func createSocket(t *testing.T, client Client, session Session) (DefaultSocket,
 
 	return socket, *connect
 }
+
+func TestSocket_MatchPresenceEvent(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		event := `{"match_presence_event":{"match_id":"match1","joins":[{"user_id":"u1","session_id":"s1","username":"alice","node":"n1","status":"ready"}],"leaves":[{"user_id":"u2","session_id":"s2","username":"bob","node":"n1"}]}}`
+		_ = conn.Write(context.Background(), websocket.MessageText, []byte(event))
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	events := make(chan MatchPresenceEvent, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnMatchPresence(func(event MatchPresenceEvent) {
+		events <- event
+	})
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "match1", event.MatchID)
+		assert.Equal(t, []Presence{{UserID: "u1", SessionID: "s1", Username: "alice", Node: "n1", Status: "ready"}}, event.Joins)
+		assert.Equal(t, "bob", event.Leaves[0].Username)
+	case <-time.After(time.Second):
+		t.Fatal("match presence event was not dispatched")
+	}
+}
+
+func TestSocket_CreateMatchCorrelatesResponse(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		var request map[string]interface{}
+		if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+			return
+		}
+		_ = wsjson.Write(context.Background(), conn, map[string]interface{}{
+			"cid":   request["cid"],
+			"match": map[string]interface{}{"match_id": "match1", "size": 1},
+		})
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	match, err := socket.CreateMatch(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "match1", match.MatchID)
+	assert.Equal(t, 1, match.Size)
+}
+
+func TestSocket_JoinMatchByTokenExpired(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		var request map[string]interface{}
+		if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+			return
+		}
+		assert.Equal(t, "stale-token", request["match_join"].(map[string]interface{})["token"])
+		_ = wsjson.Write(context.Background(), conn, map[string]interface{}{
+			"cid":   request["cid"],
+			"error": map[string]interface{}{"code": SocketErrorBadInput, "message": "Invalid match token"},
+		})
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	match, err := socket.JoinMatchByToken("stale-token")
+
+	assert.Nil(t, match)
+	var tokenError *MatchTokenError
+	assert.ErrorAs(t, err, &tokenError)
+	assert.Equal(t, "stale-token", tokenError.Token)
+}
+
+func TestSocket_RejoinAfterReconnect(t *testing.T) {
+	joins := make(chan map[string]interface{}, 4)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+				return
+			}
+			if join, ok := request["match_join"].(map[string]interface{}); ok {
+				joins <- join
+				_ = wsjson.Write(context.Background(), conn, map[string]interface{}{
+					"cid":   request["cid"],
+					"match": map[string]interface{}{"match_id": "match1", "size": 1},
+				})
+			}
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	rejoined := make(chan Subscription, 1)
+	socket.OnRejoin(func(subscription Subscription, err error) {
+		assert.NoError(t, err)
+		rejoined <- subscription
+	})
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	token := "match-token"
+	_, err = socket.JoinMatch(nil, &token, nil)
+	assert.NoError(t, err)
+	<-joins
+	assert.NoError(t, socket.SetRejoin(SubscriptionMatch, "match1", true))
+
+	socket.Disconnect(false)
+	_, err = socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	select {
+	case join := <-joins:
+		assert.Equal(t, "match1", join["match_id"])
+		assert.Nil(t, join["token"])
+	case <-time.After(time.Second):
+		t.Fatal("match was not re-joined")
+	}
+	select {
+	case subscription := <-rejoined:
+		assert.Equal(t, SubscriptionMatch, subscription.Kind)
+		assert.Equal(t, "match1", subscription.ID)
+	case <-time.After(time.Second):
+		t.Fatal("rejoin callback was not fired")
+	}
+}
+
+func TestSocket_Ping(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+				return
+			}
+			if request["ping"] != nil && request["cid"] == "1" {
+				_ = wsjson.Write(context.Background(), conn, map[string]interface{}{"cid": request["cid"], "pong": map[string]interface{}{}})
+			}
+		}
+	})
+
+	sendTimeoutMs := 100
+	socket := NewDefaultSocket(host, port, false, false, nil, &sendTimeoutMs)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	rtt, err := socket.Ping()
+	assert.NoError(t, err)
+	assert.Greater(t, rtt, time.Duration(0))
+
+	// The server only answers the first ping, so the second one times out.
+	_, err = socket.Ping()
+	assert.Error(t, err)
+}
+
+func TestChatTargets(t *testing.T) {
+	assert.Equal(t, ChatTarget{Target: "lobby", Type: ChatTypeRoom}, RoomTarget("lobby"))
+	assert.Equal(t, ChatTarget{Target: "user-id", Type: ChatTypeDirectMessage}, DirectMessageTarget("user-id"))
+	assert.Equal(t, ChatTarget{Target: "group-id", Type: ChatTypeGroup}, GroupChannelTarget("group-id"))
+
+	socket := NewDefaultSocket("127.0.0.1", "7350", false, false, nil, nil)
+	_, err := socket.JoinChat("lobby", 0, false, false)
+	assert.ErrorContains(t, err, "invalid chat type")
+	_, err = socket.JoinChatTarget(DirectMessageTarget(""), false, false)
+	assert.ErrorContains(t, err, "must not be empty")
+}
+
+func TestSocket_JoinChatTarget(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		var request map[string]interface{}
+		if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+			return
+		}
+		join := request["channel_join"].(map[string]interface{})
+		assert.Equal(t, "group-id", join["target"])
+		assert.Equal(t, float64(ChatTypeGroup), join["type"])
+		_ = wsjson.Write(context.Background(), conn, map[string]interface{}{
+			"cid":     request["cid"],
+			"channel": map[string]interface{}{"id": "3.group-id.."},
+		})
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	channel, err := socket.JoinChatTarget(GroupChannelTarget("group-id"), true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "3.group-id..", channel.ID)
+}
+
+func TestSocket_PartyLeaderAndClose(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{"cid": request["cid"]})
+			if promote, ok := request["party_promote"].(map[string]interface{}); ok {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"party_leader": map[string]interface{}{"party_id": promote["party_id"], "presence": promote["presence"]},
+				})
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"party_close": map[string]interface{}{"party_id": promote["party_id"]},
+				})
+			}
+		}
+	})
+
+	leaders := make(chan PartyLeader, 1)
+	closes := make(chan PartyClose, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnPartyLeader(func(leader PartyLeader) { leaders <- leader })
+	socket.OnPartyClose(func(event PartyClose) { closes <- event })
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	member := Presence{UserID: "u2", SessionID: "s2", Username: "bob"}
+	assert.NoError(t, socket.RemovePartyMember("party1", Presence{UserID: "u3", SessionID: "s3"}))
+	assert.NoError(t, socket.PromotePartyMember("party1", member))
+
+	select {
+	case leader := <-leaders:
+		assert.Equal(t, PartyLeader{PartyID: "party1", Presence: member}, leader)
+	case <-time.After(time.Second):
+		t.Fatal("party leader event was not dispatched")
+	}
+	select {
+	case closed := <-closes:
+		assert.Equal(t, "party1", closed.PartyID)
+	case <-time.After(time.Second):
+		t.Fatal("party close event was not dispatched")
+	}
+}
+
+func TestSocket_PartyJoinRequests(t *testing.T) {
+	alice := Presence{UserID: "u2", SessionID: "s2", Username: "alice"}
+	bob := Presence{UserID: "u3", SessionID: "s3", Username: "bob"}
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		pending := []Presence{alice, bob}
+		_ = wsjson.Write(ctx, conn, map[string]interface{}{
+			"party_join_request": PartyJoinRequest{PartyID: "party1", Presences: pending},
+		})
+		for {
+			var request struct {
+				Cid    string                       `json:"cid"`
+				List   *struct{}                    `json:"party_join_request_list"`
+				Accept *struct{ Presence Presence } `json:"party_accept"`
+				Remove *struct{ Presence Presence } `json:"party_remove"`
+			}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			response := map[string]interface{}{"cid": request.Cid}
+			switch {
+			case request.List != nil:
+				response["party_join_request"] = PartyJoinRequest{PartyID: "party1", Presences: pending}
+			case request.Accept != nil:
+				pending = slices.DeleteFunc(pending, func(p Presence) bool { return p == request.Accept.Presence })
+			case request.Remove != nil:
+				pending = slices.DeleteFunc(pending, func(p Presence) bool { return p == request.Remove.Presence })
+			}
+			_ = wsjson.Write(ctx, conn, response)
+		}
+	})
+
+	joinRequests := make(chan PartyJoinRequest, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnPartyJoinRequest(func(request PartyJoinRequest) { joinRequests <- request })
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	select {
+	case request := <-joinRequests:
+		assert.Equal(t, []Presence{alice, bob}, request.Presences)
+	case <-time.After(time.Second):
+		t.Fatal("party join request event was not dispatched")
+	}
+
+	list, err := socket.ListPartyJoinRequests("party1")
+	assert.NoError(t, err)
+	assert.Equal(t, []Presence{alice, bob}, list.Presences)
+
+	// Accept alice and implicitly reject bob by removing him.
+	assert.NoError(t, socket.AcceptPartyMember("party1", alice))
+	assert.NoError(t, socket.RemovePartyMember("party1", bob))
+
+	list, err = socket.ListPartyJoinRequests("party1")
+	assert.NoError(t, err)
+	assert.Empty(t, list.Presences)
+}
+
+func TestSocket_PartyMatchmaker(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			response := map[string]interface{}{"cid": request["cid"]}
+			if add, ok := request["party_matchmaker_add"].(map[string]interface{}); ok {
+				assert.Equal(t, "+properties.mode:ranked", add["query"])
+				assert.Equal(t, float64(4), add["max_count"])
+				response["party_matchmaker_ticket"] = map[string]interface{}{"party_id": add["party_id"], "ticket": "ticket1"}
+			}
+			_ = wsjson.Write(ctx, conn, response)
+			if _, ok := request["party_matchmaker_add"]; ok {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"matchmaker_matched": map[string]interface{}{
+						"ticket": "ticket1",
+						"token":  "match-token",
+						"self":   map[string]interface{}{"presence": map[string]interface{}{"user_id": "u1"}, "party_id": "party1"},
+					},
+				})
+			}
+		}
+	})
+
+	matched := make(chan MatchmakerMatched, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnMatchmakerMatched(func(event MatchmakerMatched) { matched <- event })
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	ticket, err := socket.AddMatchmakerParty("party1", "+properties.mode:ranked", 2, 4, map[string]string{"mode": "ranked"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ticket1", ticket.Ticket)
+
+	select {
+	case event := <-matched:
+		assert.Equal(t, "ticket1", event.Ticket)
+		assert.Equal(t, "party1", event.Self.PartyID)
+	case <-time.After(time.Second):
+		t.Fatal("matchmaker matched event was not dispatched")
+	}
+
+	assert.NoError(t, socket.RemoveMatchmakerParty("party1", ticket.Ticket))
+}
+
+func TestSocket_MatchSignal(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			signal := request["match_signal"].(map[string]interface{})
+			if signal["match_id"] == "relayed" {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"cid":   request["cid"],
+					"error": map[string]interface{}{"code": SocketErrorUnrecognizedPayload, "message": "Unrecognized message."},
+				})
+				continue
+			}
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid":          request["cid"],
+				"match_signal": map[string]interface{}{"match_id": signal["match_id"], "data": "ack:" + signal["data"].(string)},
+			})
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	result, err := socket.MatchSignal("authoritative", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "ack:hello", result)
+
+	_, err = socket.MatchSignal("relayed", "hello")
+	assert.ErrorIs(t, err, ErrMatchSignalUnsupported)
+}
+
+func TestSocket_ConnectWithStatus(t *testing.T) {
+	type connection struct {
+		status   string
+		messages []map[string]interface{}
+	}
+	connections := make(chan connection, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := connection{status: r.URL.Query().Get("status")}
+		defer func() { connections <- c }()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+				return
+			}
+			c.messages = append(c.messages, request)
+			_ = wsjson.Write(context.Background(), conn, map[string]interface{}{"cid": request["cid"]})
+		}
+	}))
+	defer server.Close()
+	host, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+
+	t.Run("initial status", func(t *testing.T) {
+		socket := NewDefaultSocket(host, port, false, false, nil, nil)
+		status := "in lobby"
+		_, err := socket.ConnectWithStatus(Session{Token: "token"}, &status, nil)
+		assert.NoError(t, err)
+		socket.Disconnect(false)
+
+		c := <-connections
+		assert.Equal(t, "false", c.status, "the user must not appear online before the status is set")
+		assert.Len(t, c.messages, 1)
+		assert.Equal(t, map[string]interface{}{"status": "in lobby"}, c.messages[0]["status_update"])
+	})
+
+	t.Run("appear offline", func(t *testing.T) {
+		socket := NewDefaultSocket(host, port, false, false, nil, nil)
+		_, err := socket.ConnectWithStatus(Session{Token: "token"}, nil, nil)
+		assert.NoError(t, err)
+		socket.Disconnect(false)
+
+		c := <-connections
+		assert.Equal(t, "false", c.status)
+		assert.Empty(t, c.messages)
+	})
+}
+
+func TestSocket_GroupRemovedNotification(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			if request["channel_join"] != nil {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"cid":     request["cid"],
+					"channel": map[string]interface{}{"id": "3.group-id.."},
+				})
+				continue
+			}
+			// The user is kicked once the channel join has been tracked.
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{"cid": request["cid"]})
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"notifications": map[string]interface{}{"notifications": []map[string]interface{}{
+					{"id": "n1", "code": 1, "subject": "hello", "content": `{}`},
+					{"id": "n2", "code": 100, "subject": "kicked", "content": `{"group_id":"group-id"}`},
+				}},
+			})
+		}
+	})
+
+	notifications := make(chan Notification, 2)
+	removed := make(chan string, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.SetGroupRemovalNotificationCodes(100)
+	socket.OnNotification(func(notification Notification) { notifications <- notification })
+	socket.OnGroupRemoved(func(groupID string, notification Notification) {
+		assert.Equal(t, "n2", *notification.ID)
+		removed <- groupID
+	})
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	_, err = socket.JoinChatTarget(GroupChannelTarget("group-id"), true, false)
+	assert.NoError(t, err)
+	assert.Len(t, socket.Subscriptions(), 1)
+	assert.NoError(t, socket.UpdateStatus(nil))
+
+	select {
+	case groupID := <-removed:
+		assert.Equal(t, "group-id", groupID)
+	case <-time.After(time.Second):
+		t.Fatal("group removal was not dispatched")
+	}
+	assert.Len(t, notifications, 2)
+	assert.Empty(t, socket.Subscriptions(), "the group channel must no longer be re-joined")
+}
+
+func TestSocket_ConnectTimeout(t *testing.T) {
+	host, port := setupSilentServer(t)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+
+	timeoutMs := 100
+	start := time.Now()
+	_, err := socket.Connect(Session{Token: "token"}, nil, &timeoutMs)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, ConnectionStateDisconnected, socket.Adapter.State())
+}
+
+func TestSocket_MatchmakerTickets(t *testing.T) {
+	var tickets int32
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			if request["matchmaker_add"] == nil {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{"cid": request["cid"]})
+				continue
+			}
+			ticket := fmt.Sprintf("t%d", atomic.AddInt32(&tickets, 1))
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid":               request["cid"],
+				"matchmaker_ticket": map[string]interface{}{"ticket": ticket},
+			})
+			if ticket == "t2" {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"matchmaker_matched": map[string]interface{}{"ticket": ticket, "token": "match-token"},
+				})
+			}
+		}
+	})
+
+	matched := make(chan MatchmakerMatched, 1)
+	lost := make(chan []MatchmakerTicket, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnMatchmakerMatched(func(event MatchmakerMatched) { matched <- event })
+	socket.OnMatchmakerTicketsLost(func(tickets []MatchmakerTicket) { lost <- tickets })
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	ticket, err := socket.AddMatchmaker("*", 2, 4, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []MatchmakerTicket{{Ticket: "t1"}}, socket.MatchmakerTickets())
+
+	assert.ErrorIs(t, socket.RemoveMatchmaker("other"), ErrUnknownTicket)
+	assert.NoError(t, socket.RemoveMatchmaker(ticket.Ticket))
+	assert.Empty(t, socket.MatchmakerTickets())
+
+	// A matched ticket is no longer outstanding.
+	_, err = socket.AddMatchmaker("*", 2, 4, nil, nil)
+	assert.NoError(t, err)
+	select {
+	case event := <-matched:
+		assert.Equal(t, "t2", event.Ticket)
+	case <-time.After(time.Second):
+		t.Fatal("matchmaker matched event was not dispatched")
+	}
+	assert.Empty(t, socket.MatchmakerTickets())
+
+	// Outstanding tickets are lost on a reconnect.
+	_, err = socket.AddMatchmaker("*", 2, 4, nil, nil)
+	assert.NoError(t, err)
+	socket.Disconnect(false)
+	_, err = socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	select {
+	case tickets := <-lost:
+		assert.Equal(t, []MatchmakerTicket{{Ticket: "t3"}}, tickets)
+	case <-time.After(time.Second):
+		t.Fatal("lost tickets were not reported")
+	}
+	assert.Empty(t, socket.MatchmakerTickets())
+	assert.ErrorIs(t, socket.RemoveMatchmaker("t3"), ErrUnknownTicket)
+}
+
+func TestSocket_RefollowAfterReconnect(t *testing.T) {
+	follows := make(chan []interface{}, 4)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			response := map[string]interface{}{"cid": request["cid"]}
+			if follow, ok := request["status_follow"].(map[string]interface{}); ok {
+				follows <- follow["user_ids"].([]interface{})
+				response["status"] = map[string]interface{}{"presences": []map[string]interface{}{{"user_id": "u1", "session_id": "s1"}}}
+			}
+			_ = wsjson.Write(ctx, conn, response)
+		}
+	})
+
+	refollowed := make(chan *Status, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.SetRefollowUsers(true)
+	socket.OnRefollow(func(status *Status, err error) {
+		assert.NoError(t, err)
+		refollowed <- status
+	})
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	status, err := socket.FollowUsers([]string{"u1", "u2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", status.Presences[0].UserID)
+	assert.NoError(t, socket.UnfollowUsers([]string{"u2"}))
+	assert.Equal(t, []string{"u1"}, socket.FollowedUsers())
+	<-follows
+
+	socket.Disconnect(false)
+	assert.Empty(t, socket.FollowedUsers(), "the followed set is cleared when the connection closes")
+
+	_, err = socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	select {
+	case userIDs := <-follows:
+		assert.Equal(t, []interface{}{"u1"}, userIDs)
+	case <-time.After(time.Second):
+		t.Fatal("followed users were not re-followed")
+	}
+	select {
+	case status := <-refollowed:
+		assert.Len(t, status.Presences, 1)
+	case <-time.After(time.Second):
+		t.Fatal("re-follow was not reported")
+	}
+	assert.Equal(t, []string{"u1"}, socket.FollowedUsers())
+}
+
+func TestSocket_AutoReconnectLogsAttempts(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			// The first connection is dropped by the server.
+			conn, err := websocket.Accept(w, r, nil)
+			if err == nil {
+				_ = conn.Close(websocket.StatusGoingAway, "restarting")
+			}
+		case 2:
+			// The first reconnect attempt fails.
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			conn, err := websocket.Accept(w, r, nil)
+			if err == nil {
+				_, _, _ = conn.Read(context.Background())
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	host, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+
+	logger := &recordingLogger{}
+	adapter := NewWebSocketAdapterText()
+	adapter.Logger = logger
+	socket := NewDefaultSocket(host, port, false, false, adapter, nil)
+	session := &Session{Token: "token"}
+	socket.SetAutoReconnect(session, BackoffConfig{Base: 10 * time.Millisecond, Factor: 2, Jitter: JitterNone}, 3)
+
+	_, err := socket.Connect(*session, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	assert.Eventually(t, func() bool {
+		return slices.Contains(logger.Messages(), "debug: Socket reconnected")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ConnectionStateConnected, socket.Adapter.State())
+
+	var attempts []any
+	for i, message := range logger.Messages() {
+		switch message {
+		case "debug: Socket reconnect attempt":
+			attempts = append(attempts, logger.Attr(i, "attempt"))
+			assert.NotNil(t, logger.Attr(i, "error"))
+			assert.Equal(t, 10*time.Millisecond<<(len(attempts)-1), logger.Attr(i, "delay"))
+		case "debug: Socket reconnected":
+			assert.Equal(t, 2, logger.Attr(i, "attempts"))
+			assert.Greater(t, logger.Attr(i, "downtime"), time.Duration(0))
+		}
+	}
+	assert.Equal(t, []any{1, 2}, attempts)
+}
+
+func TestSocket_Presences(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			switch {
+			case request["match_join"] != nil:
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"cid": request["cid"],
+					"match": map[string]interface{}{
+						"match_id":  "m1",
+						"self":      map[string]interface{}{"user_id": "u1", "session_id": "s1"},
+						"presences": []map[string]interface{}{{"user_id": "u2", "session_id": "s2"}},
+					},
+				})
+			case request["status_update"] != nil:
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{"cid": request["cid"]})
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"match_presence_event": map[string]interface{}{
+						"match_id": "m2",
+						"joins":    []map[string]interface{}{{"user_id": "u9", "session_id": "s9"}},
+					},
+				})
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"match_presence_event": map[string]interface{}{
+						"match_id": "m1",
+						"joins": []map[string]interface{}{
+							{"user_id": "u3", "session_id": "s3"},
+							{"user_id": "u2", "session_id": "s4"},
+						},
+						"leaves": []map[string]interface{}{{"user_id": "u2", "session_id": "s2"}},
+					},
+				})
+			}
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	matchID := "m1"
+	_, err = socket.JoinMatch(&matchID, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Presence{
+		{UserID: "u1", SessionID: "s1"},
+		{UserID: "u2", SessionID: "s2"},
+	}, socket.Presences("m1"))
+
+	// No handler is registered, the presence set is kept up to date regardless.
+	assert.NoError(t, socket.UpdateStatus(nil))
+	assert.Eventually(t, func() bool {
+		return slices.Equal([]Presence{
+			{UserID: "u1", SessionID: "s1"},
+			{UserID: "u2", SessionID: "s4"},
+			{UserID: "u3", SessionID: "s3"},
+		}, socket.Presences("m1"))
+	}, time.Second, 10*time.Millisecond)
+	assert.Nil(t, socket.Presences("m2"), "events for matches that were not joined are ignored")
+
+	assert.NoError(t, socket.LeaveMatch("m1"))
+	assert.Nil(t, socket.Presences("m1"))
+}
+
+func TestSocket_Heartbeat(t *testing.T) {
+	var calls int32
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			rpc, ok := request["rpc"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			n := atomic.AddInt32(&calls, 1)
+			if n == 2 {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"cid":   request["cid"],
+					"error": map[string]interface{}{"code": SocketErrorRuntimeException, "message": "not alive"},
+				})
+				continue
+			}
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid": request["cid"],
+				"rpc": map[string]interface{}{"id": rpc["id"], "payload": rpc["payload"]},
+			})
+		}
+	})
+
+	results := make(chan error, 10)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnHeartbeat(func(rpc *ApiRpc, err error) {
+		if err == nil {
+			assert.Equal(t, "alive", *rpc.ID)
+			assert.JSONEq(t, `{"client":"test"}`, *rpc.Payload)
+		}
+		results <- err
+	})
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Error(t, socket.StartHeartbeat(0, "alive", nil))
+	assert.NoError(t, socket.StartHeartbeat(10*time.Millisecond, "alive", map[string]interface{}{"client": "test"}))
+	for i, wantErr := range []bool{false, true, false} {
+		select {
+		case err := <-results:
+			assert.Equal(t, wantErr, err != nil, "heartbeat %d", i)
+		case <-time.After(time.Second):
+			t.Fatal("heartbeat was not reported")
+		}
+	}
+
+	socket.Disconnect(false)
+	stopped := atomic.LoadInt32(&calls)
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, atomic.LoadInt32(&calls), stopped+1, "the heartbeat stops with the connection")
+}
+
+func TestSocket_RpcTyped(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			rpc := request["rpc"].(map[string]interface{})
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid": request["cid"],
+				"rpc": map[string]interface{}{"id": rpc["id"], "payload": `{"echo":` + rpc["payload"].(string) + `}`},
+			})
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	type echo struct {
+		Echo struct {
+			Level int `json:"level"`
+		} `json:"echo"`
+	}
+	result, err := SocketRpcTyped[echo](&socket, "echo", map[string]int{"level": 7})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, result.Echo.Level)
+}
+
+func TestSocket_UpdateStatusJSON(t *testing.T) {
+	statuses := make(chan interface{}, 1)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			statuses <- request["status_update"].(map[string]interface{})["status"]
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{"cid": request["cid"]})
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	type richPresence struct {
+		Mode  string `json:"mode"`
+		Level int    `json:"level"`
+	}
+	assert.NoError(t, socket.UpdateStatusJSON(richPresence{Mode: "ranked", Level: 12}))
+	status := (<-statuses).(string)
+	assert.JSONEq(t, `{"mode":"ranked","level":12}`, status)
+
+	var decoded richPresence
+	assert.NoError(t, Presence{UserID: "u1", Status: status}.DecodeStatus(&decoded))
+	assert.Equal(t, richPresence{Mode: "ranked", Level: 12}, decoded)
+
+	err = socket.UpdateStatusJSON(map[string]string{"note": strings.Repeat("x", MaxStatusLength)})
+	assert.ErrorIs(t, err, ErrStatusTooLong)
+	assert.Empty(t, statuses, "an oversized status is not sent")
+}
+
+func TestSocket_JoinMatched(t *testing.T) {
+	users := []map[string]interface{}{
+		{"presence": map[string]interface{}{"user_id": "u2", "session_id": "s2"}, "numeric_properties": map[string]interface{}{"skill": 10}},
+		{"presence": map[string]interface{}{"user_id": "u1", "session_id": "s1"}, "string_properties": map[string]interface{}{"region": "eu"}},
+	}
+	self := users[1]
+	joins := make(chan map[string]interface{}, 2)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		_ = wsjson.Write(ctx, conn, map[string]interface{}{
+			"matchmaker_matched": map[string]interface{}{"ticket": "t1", "match_id": "m1", "users": users, "self": self},
+		})
+		_ = wsjson.Write(ctx, conn, map[string]interface{}{
+			"matchmaker_matched": map[string]interface{}{"ticket": "t2", "token": "match-token", "users": users, "self": self},
+		})
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			join := request["match_join"].(map[string]interface{})
+			joins <- join
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid":   request["cid"],
+				"match": map[string]interface{}{"match_id": "m1"},
+			})
+		}
+	})
+
+	matched := make(chan MatchmakerMatched, 2)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnMatchmakerMatched(func(event MatchmakerMatched) { matched <- event })
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	for _, want := range []struct {
+		kind MatchmakerJoinKind
+		join map[string]interface{}
+	}{
+		{MatchmakerJoinByID, map[string]interface{}{"match_id": "m1"}},
+		{MatchmakerJoinByToken, map[string]interface{}{"token": "match-token"}},
+	} {
+		var event MatchmakerMatched
+		select {
+		case event = <-matched:
+		case <-time.After(time.Second):
+			t.Fatal("matchmaker matched event was not dispatched")
+		}
+		assert.Equal(t, want.kind, event.JoinKind())
+		assert.Equal(t, 1, event.SelfIndex())
+		assert.Equal(t, "eu", event.Users[event.SelfIndex()].StringProperties["region"])
+		assert.Equal(t, 10.0, event.Users[0].NumericProperties["skill"])
+
+		_, err := socket.JoinMatched(event)
+		assert.NoError(t, err)
+		assert.Equal(t, want.join, <-joins)
+	}
+
+	_, err = socket.JoinMatched(MatchmakerMatched{})
+	assert.Error(t, err)
+	assert.Equal(t, -1, MatchmakerMatched{Self: MatchmakerUser{Presence: Presence{SessionID: "s9"}}}.SelfIndex())
+}
+
+func TestSocket_JoinMatchMetadata(t *testing.T) {
+	joins := make(chan map[string]interface{}, 2)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			join := request["match_join"].(map[string]interface{})
+			joins <- join
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid":   request["cid"],
+				"match": map[string]interface{}{"match_id": join["match_id"]},
+			})
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	matchID := "m1"
+	metadata := map[string]interface{}{"team": "red", "slot": 2}
+	_, err = socket.JoinMatch(&matchID, nil, &metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"team": "red", "slot": "2"}, (<-joins)["metadata"])
+
+	_, err = socket.JoinMatch(&matchID, nil, &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.NotContains(t, <-joins, "metadata")
+}
+
+func TestSocket_Codec(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			if request["match_create"] != nil {
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"cid":   request["cid"],
+					"match": map[string]interface{}{"match_id": "m1", "authoritative": false, "size": 1},
+				})
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"notifications": map[string]interface{}{"notifications": []interface{}{
+						map[string]interface{}{"id": "n1", "code": SystemNotificationFriendRequest, "content": `{"username":"alice"}`},
+					}},
+				})
+				continue
+			}
+			_ = wsjson.Write(ctx, conn, map[string]interface{}{
+				"cid": request["cid"],
+				"rpc": map[string]interface{}{"id": "echo", "payload": "{}"},
+			})
+		}
+	})
+
+	codec := &countingCodec{}
+	adapter := NewWebSocketAdapterText()
+	adapter.Codec = codec
+	socket := NewDefaultSocket(host, port, false, false, adapter, nil)
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	rpc, err := socket.Rpc("echo", "{}", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", *rpc.ID)
+	assert.GreaterOrEqual(t, codec.marshals.Load(), int32(2), "the request and the decoded rpc field")
+	assert.GreaterOrEqual(t, codec.unmarshals.Load(), int32(2), "the response and the decoded rpc field")
+
+	notifications := make(chan Notification, 1)
+	socket.OnNotification(func(notification Notification) { notifications <- notification })
+	unmarshals := codec.unmarshals.Load()
+	match, err := socket.CreateMatch(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "m1", match.MatchID)
+	assert.GreaterOrEqual(t, codec.unmarshals.Load()-unmarshals, int32(2), "the response and the decoded match field")
+
+	notification := <-notifications
+	unmarshals = codec.unmarshals.Load()
+	content, err := notification.DecodeContent()
+	assert.NoError(t, err)
+	assert.Equal(t, &FriendRequestNotification{Username: "alice"}, content)
+	assert.Equal(t, int32(1), codec.unmarshals.Load()-unmarshals, "the notification content")
+}
+
+func TestSocket_OnCloseServerReason(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		_ = conn.Close(websocket.StatusPolicyViolation, "session kicked")
+	})
+
+	closed := make(chan *CloseEvent, 2)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.OnClose(func(event *CloseEvent) { closed <- event })
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+
+	select {
+	case event := <-closed:
+		assert.Equal(t, int(websocket.StatusPolicyViolation), event.Code)
+		assert.Equal(t, "session kicked", event.Reason)
+		assert.False(t, event.Local)
+		assert.EqualError(t, event, "websocket closed with code 1008: session kicked")
+	case <-time.After(time.Second):
+		t.Fatal("close was not reported")
+	}
+
+	socket.Disconnect(true)
+	event := <-closed
+	assert.True(t, event.Local)
+	assert.Equal(t, int(websocket.StatusNormalClosure), event.Code)
+}
+
+func TestSocket_MatchDataSequencing(t *testing.T) {
+	sent := make(chan map[string]interface{}, 2)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(ctx, conn, &request); err != nil {
+				return
+			}
+			send := request["match_data_send"].(map[string]interface{})
+			sent <- send
+			// Relay the data back from another player, skipping sequence numbers 3 and 4.
+			for _, sequence := range []uint64{1, 2, 5} {
+				data := append(binary.BigEndian.AppendUint64(nil, sequence), "move"...)
+				_ = wsjson.Write(ctx, conn, map[string]interface{}{
+					"match_data": map[string]interface{}{
+						"match_id": "m1",
+						"op_code":  "7",
+						"data":     base64.StdEncoding.EncodeToString(data),
+						"presence": map[string]interface{}{"user_id": "u2", "session_id": "s2"},
+					},
+				})
+			}
+		}
+	})
+
+	received := make(chan MatchData, 3)
+	gaps := make(chan MatchDataGap, 1)
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.SetMatchDataSequencing(true)
+	socket.OnMatchData(func(data MatchData) { received <- data })
+	socket.OnMatchDataGap(func(gap MatchDataGap) { gaps <- gap })
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	assert.NoError(t, socket.SendMatchState("m1", 7, "hello", nil, false))
+	data, _ := base64.StdEncoding.DecodeString((<-sent)["data"].(string))
+	assert.Equal(t, append(binary.BigEndian.AppendUint64(nil, 1), "hello"...), data)
+
+	for _, sequence := range []uint64{1, 2, 5} {
+		select {
+		case data := <-received:
+			assert.Equal(t, sequence, data.Sequence)
+			assert.Equal(t, []byte("move"), data.Data)
+			assert.Equal(t, 7, data.OpCode)
+			assert.Equal(t, "s2", data.Presence.SessionID)
+		case <-time.After(time.Second):
+			t.Fatal("match data was not dispatched")
+		}
+	}
+	gap := <-gaps
+	assert.Equal(t, MatchDataGap{MatchID: "m1", Sender: gap.Sender, Expected: 3, Received: 5}, gap)
+	assert.Equal(t, uint64(2), gap.Missing())
+
+	assert.Error(t, socket.SendMatchState("m1", 7, map[string]int{"x": 1}, nil, false))
+}
+
+func TestSocket_MatchDataSequencingRejectedSends(t *testing.T) {
+	sent := make(chan map[string]interface{}, 2)
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+				return
+			}
+			sent <- request["match_data_send"].(map[string]interface{})
+		}
+	})
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	socket.SetMatchDataSequencing(true)
+
+	// A send on a closed connection does not use up a sequence number.
+	assert.Error(t, socket.SendMatchState("m1", 7, "lost", nil, false))
+
+	_, err := socket.Connect(Session{Token: "token"}, nil, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+
+	// Neither do sends rejected before they reach the server.
+	assert.Error(t, socket.SendMatchState("m1", 7, 42, nil, false))
+	err = socket.SendMatchState("m1", 7, "private", []Presence{{UserID: "u2", SessionID: "s2"}}, false)
+	assert.ErrorIs(t, err, ErrSequencedMatchPresences)
+
+	for _, sequence := range []uint64{1, 2} {
+		assert.NoError(t, socket.SendMatchState("m1", 7, "hello", nil, false))
+		data, _ := base64.StdEncoding.DecodeString((<-sent)["data"].(string))
+		assert.Equal(t, append(binary.BigEndian.AppendUint64(nil, sequence), "hello"...), data)
+	}
+
+	// Without sequencing, data may be sent to some presences.
+	socket.SetMatchDataSequencing(false)
+	assert.NoError(t, socket.SendMatchState("m1", 7, "private", []Presence{{UserID: "u2", SessionID: "s2"}}, false))
+	assert.Len(t, (<-sent)["presences"], 1)
+}
+
+func TestSocket_ReconnectReappliesStatus(t *testing.T) {
+	type connection struct {
+		status string
+		conn   *websocket.Conn
+	}
+	connections := make(chan connection, 2)
+	updates := make(chan interface{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		connections <- connection{status: r.URL.Query().Get("status"), conn: conn}
+		for {
+			var request map[string]interface{}
+			if err := wsjson.Read(context.Background(), conn, &request); err != nil {
+				return
+			}
+			if update, ok := request["status_update"].(map[string]interface{}); ok {
+				updates <- update["status"]
+			}
+			_ = wsjson.Write(context.Background(), conn, map[string]interface{}{"cid": request["cid"]})
+		}
+	}))
+	defer server.Close()
+	host, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+	backoff := BackoffConfig{Base: 10 * time.Millisecond, Factor: 2, Jitter: JitterNone}
+
+	next := func(t *testing.T) connection {
+		select {
+		case c := <-connections:
+			return c
+		case <-time.After(2 * time.Second):
+			t.Fatal("the socket did not connect")
+			return connection{}
+		}
+	}
+
+	t.Run("create status", func(t *testing.T) {
+		session := &Session{Token: "token"}
+		socket := NewDefaultSocket(host, port, false, false, nil, nil)
+		socket.SetAutoReconnect(session, backoff, 3)
+		createStatus := true
+		_, err := socket.Connect(*session, &createStatus, nil)
+		assert.NoError(t, err)
+		defer socket.Disconnect(false)
+
+		first := next(t)
+		assert.Equal(t, "true", first.status)
+		_ = first.conn.Close(websocket.StatusGoingAway, "restarting")
+		assert.Equal(t, "true", next(t).status)
+	})
+
+	t.Run("initial status", func(t *testing.T) {
+		session := &Session{Token: "token"}
+		socket := NewDefaultSocket(host, port, false, false, nil, nil)
+		socket.SetAutoReconnect(session, backoff, 3)
+		status := "in lobby"
+		_, err := socket.ConnectWithStatus(*session, &status, nil)
+		assert.NoError(t, err)
+		defer socket.Disconnect(false)
+
+		first := next(t)
+		assert.Equal(t, "false", first.status)
+		assert.Equal(t, "in lobby", <-updates)
+		_ = first.conn.Close(websocket.StatusGoingAway, "restarting")
+		assert.Equal(t, "false", next(t).status)
+		select {
+		case update := <-updates:
+			assert.Equal(t, "in lobby", update)
+		case <-time.After(2 * time.Second):
+			t.Fatal("the status was not set again")
+		}
+	})
+}
+
+func TestSocket_ReconnectStoppedDuringBackoff(t *testing.T) {
+	socket := NewDefaultSocket("127.0.0.1", "1", false, false, nil, nil)
+	result := make(chan error, 1)
+	go func() {
+		_, err := socket.Reconnect(Session{Token: "token"}, BackoffConfig{Base: time.Minute, Factor: 2, Jitter: JitterNone}, 3, nil)
+		result <- err
+	}()
+	assert.Eventually(t, func() bool {
+		return socket.Adapter.State() == ConnectionStateReconnecting
+	}, time.Second, time.Millisecond)
+
+	socket.Disconnect(false)
+	select {
+	case err := <-result:
+		assert.EqualError(t, err, "socket reconnect stopped by disconnect")
+	case <-time.After(time.Second):
+		t.Fatal("Disconnect did not interrupt the backoff delay")
+	}
+	assert.Equal(t, ConnectionStateDisconnected, socket.Adapter.State())
+}
+
+func TestSocket_DisconnectRightAfterDrop(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		conn, err := websocket.Accept(w, r, nil)
+		if err == nil {
+			_ = conn.Close(websocket.StatusGoingAway, "restarting")
+		}
+	}))
+	t.Cleanup(server.Close)
+	host, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+
+	socket := NewDefaultSocket(host, port, false, false, nil, nil)
+	session := &Session{Token: "token"}
+	socket.SetAutoReconnect(session, BackoffConfig{Base: time.Millisecond, Factor: 2, Jitter: JitterNone}, 3)
+	// Disconnect is called as soon as the drop is reported, before the reconnect would start.
+	closed := make(chan struct{}, 1)
+	socket.OnClose(func(*CloseEvent) {
+		socket.Disconnect(false)
+		select {
+		case closed <- struct{}{}:
+		default:
+		}
+	})
+
+	_, err := socket.Connect(*session, nil, nil)
+	assert.NoError(t, err)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the drop was not reported")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connections))
+	assert.Equal(t, ConnectionStateDisconnected, socket.Adapter.State())
+}
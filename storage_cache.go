@@ -0,0 +1,91 @@
+package nakama
+
+import (
+	"container/list"
+	"sync"
+)
+
+// StorageCacheKey identifies a storage object in a StorageCache.
+type StorageCacheKey struct {
+	Collection string
+	Key        string
+	UserID     string // The owner of the object.
+}
+
+// StorageCache stores decoded storage objects by their key. When a read returns an object whose
+// version matches the cached one, the client uses the cached value instead of decoding it again.
+// Implementations must be safe for concurrent use.
+type StorageCache interface {
+	Get(key StorageCacheKey) (StorageObject, bool)
+	Put(key StorageCacheKey, object StorageObject)
+	Remove(key StorageCacheKey)
+}
+
+// LRUStorageCache is a StorageCache that holds up to a fixed number of objects, evicting the least
+// recently used.
+type LRUStorageCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Most recently used first, holding *storageCacheEntry.
+	entries  map[StorageCacheKey]*list.Element
+}
+
+type storageCacheEntry struct {
+	key    StorageCacheKey
+	object StorageObject
+}
+
+// NewLRUStorageCache creates a cache that holds up to capacity objects.
+func NewLRUStorageCache(capacity int) *LRUStorageCache {
+	return &LRUStorageCache{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[StorageCacheKey]*list.Element),
+	}
+}
+
+// Get returns the cached object for key.
+func (c *LRUStorageCache) Get(key StorageCacheKey) (StorageObject, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return StorageObject{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*storageCacheEntry).object, true
+}
+
+// Put caches the object for key, evicting the least recently used object if the cache is full.
+func (c *LRUStorageCache) Put(key StorageCacheKey, object StorageObject) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*storageCacheEntry).object = object
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&storageCacheEntry{key: key, object: object})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*storageCacheEntry).key)
+	}
+}
+
+// Remove removes the object for key from the cache.
+func (c *LRUStorageCache) Remove(key StorageCacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of cached objects.
+func (c *LRUStorageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
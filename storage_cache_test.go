@@ -0,0 +1,62 @@
+package nakama
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUStorageCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUStorageCache(2)
+	a, b, c := StorageCacheKey{"saves", "a", "u"}, StorageCacheKey{"saves", "b", "u"}, StorageCacheKey{"saves", "c", "u"}
+
+	cache.Put(a, StorageObject{})
+	cache.Put(b, StorageObject{})
+	_, _ = cache.Get(a)
+	cache.Put(c, StorageObject{})
+
+	_, ok := cache.Get(b)
+	assert.False(t, ok, "b was least recently used")
+	_, ok = cache.Get(a)
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	cache.Remove(a)
+	_, ok = cache.Get(a)
+	assert.False(t, ok)
+}
+
+func TestReadStorageObjects_Cache(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	var version, value atomic.Value
+	version.Store("v1")
+	value.Store(`{\"coins\":10}`)
+	client := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"objects":[{"collection":"wallet","key":"coins","user_id":"u","version":"` + version.Load().(string) +
+			`","value":"` + value.Load().(string) + `","create_time":"` + now + `","update_time":"` + now + `"}]}`))
+	})
+	client.StorageCache = NewLRUStorageCache(10)
+	session := &Session{Token: "token"}
+	request := &ApiReadStorageObjectsRequest{ObjectIDs: ReadIds("wallet", "coins")}
+
+	objects, err := client.ReadStorageObjects(session, request)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, objects.Objects[0].Value["coins"])
+	objects.Objects[0].Value["coins"] = 0.0
+
+	// A hit returns the cached value without decoding the response value.
+	value.Store(`not json`)
+	objects, err = client.ReadStorageObjects(session, request)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, objects.Objects[0].Value["coins"], "callers must not be able to modify the cached value")
+
+	// A new version is a miss and is decoded.
+	version.Store("v2")
+	value.Store(`{\"coins\":20}`)
+	objects, err = client.ReadStorageObjects(session, request)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, objects.Objects[0].Value["coins"])
+}
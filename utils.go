@@ -1,14 +1,56 @@
 package nakama
 
 import (
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// MaxUsernameLength is the maximum username length in bytes accepted by the server.
+const MaxUsernameLength = 128
+
+// MaxStatusLength is the maximum status length in bytes accepted by the server.
+const MaxStatusLength = 2048
+
+// MaxStorageValueBytes is the largest encoded storage object value accepted by ValidateStorageObjects.
+// Adjust it to match the limits of your deployment.
+var MaxStorageValueBytes = 1 << 20
+
+// MaxStorageUpdateAttempts is how many times UpdateStorageObject reads and writes an object before
+// giving up on concurrent writers. Values below 1 are treated as 1.
+var MaxStorageUpdateAttempts = 5
+
+// MaxRefreshAttempts is how many times SessionRefresh, and so an automatic refresh, tries a refresh
+// that failed with a transport error or a 502, 503 or 504, waiting the Client's Backoff delays
+// between attempts. Values below 1 are treated as 1.
+var MaxRefreshAttempts = 3
+
+// MaxBatchParallelism is how many calls of a Client.Batch run at once.
+var MaxBatchParallelism = 4
+
+// MaxVarsBytes is the largest total size of session var keys and values accepted by ValidateVars.
+// Vars are embedded in every session token, so large maps inflate each request. Adjust it to match
+// the limits of your deployment.
+var MaxVarsBytes = 4096
+
+// ServerInfoRpcID is the RPC called by Client.ServerInfo. Nakama has no endpoint that reports its
+// configuration to clients, so the server runtime must register an RPC under this ID that returns a
+// ServerInfo as JSON.
+var ServerInfoRpcID = "server_info"
+
+// ReservedVarKeys are the session var keys rejected by ValidateVars. By default they are the claim
+// names of the session token. Adjust it to match the keys reserved by your server runtime.
+var ReservedVarKeys = []string{"exp", "iat", "tid", "uid", "usn", "vrs"}
+
 // BuildFetchOptions constructs fetch options similar to the JavaScript version.
 func BuildFetchOptions(method string, options map[string]interface{}, bodyJson string) (map[string]interface{}, error) {
 	// Initialize fetchOptions with method and merge with provided options.
@@ -97,6 +139,18 @@ func stringPointerToIntPointer(s *string) *int {
 	return &value
 }
 
+// Helper function to convert *string to *int64, returning an error for malformed values
+func stringPointerToInt64Pointer(s *string) (*int64, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(*s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
 // Helper function to convert *int to *string
 func intPointerToStringPointer(i *int) *string {
 	if i == nil {
@@ -115,6 +169,22 @@ func int64PointerToIntPointer(i *int64) *int {
 	return &value
 }
 
+// Helper function to dereference an int pointer, treating nil as 0.
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// Helper function to dereference a string pointer, treating nil as "".
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 // Helper function to convert a value to JSON string
 func ToJSON(value interface{}) []byte {
 	data, err := json.Marshal(value)
@@ -123,3 +193,328 @@ func ToJSON(value interface{}) []byte {
 	}
 	return data
 }
+
+// DecodeJSONField decodes a JSON-encoded string field, such as Metadata, Content, Value, Wallet or
+// ProviderResponse, into out. An empty string leaves out at its zero value, and invalid JSON is an error.
+func DecodeJSONField(s string, out any) error {
+	if s == "" {
+		if v := reflect.ValueOf(out); v.Kind() == reflect.Pointer && !v.IsNil() {
+			v.Elem().SetZero()
+		}
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s), out); err != nil {
+		return fmt.Errorf("invalid JSON field: %w", err)
+	}
+	return nil
+}
+
+// Helper function to decode a JSON match label.
+func parseMatchLabel(label *string, out interface{}) error {
+	if label == nil || *label == "" {
+		return errors.New("match has no label")
+	}
+	if err := json.Unmarshal([]byte(*label), out); err != nil {
+		return fmt.Errorf("match label is not valid JSON: %w", err)
+	}
+	return nil
+}
+
+// Helper function to normalize a pagination cursor before it is sent. Surrounding whitespace is
+// trimmed, an empty cursor means no cursor, and anything that is not base64 is rejected because the
+// server only issues base64 cursors.
+func normalizeCursor(cursor *string) (*string, error) {
+	if cursor == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*cursor)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if strings.IndexFunc(trimmed, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("+/=-_", r))
+	}) >= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCursor, trimmed)
+	}
+	return &trimmed, nil
+}
+
+// redactedValue replaces credentials in debug logs.
+const redactedValue = "[REDACTED]"
+
+// Helper function to report whether a field or parameter name holds a credential.
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "token") || key == "http_key"
+}
+
+// Helper function to render a URL with credential query parameters redacted.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for key := range query {
+		if isSensitiveKey(key) {
+			query.Set(key, redactedValue)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// Helper function to render a JSON body with credential fields redacted. Bodies that are not JSON
+// objects or arrays are returned unchanged.
+func redactJSONBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+	redacted, err := json.Marshal(redactJSONValue(decoded))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+// Helper function to redact credential fields of a decoded JSON value recursively.
+func redactJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitiveKey(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactJSONValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSONValue(item)
+		}
+	}
+	return value
+}
+
+// ValidateUsername checks a username before it is sent: at most MaxUsernameLength bytes and no ASCII
+// control or whitespace characters other than a plain space. Other characters are left to the server.
+// A nil or empty username is valid because the server generates one in that case.
+func ValidateUsername(username *string) error {
+	return validateUsername(username, MaxUsernameLength)
+}
+
+// Helper function to validate a username against a maximum length
+func validateUsername(username *string, maxLength int) error {
+	if username == nil || *username == "" {
+		return nil
+	}
+	if len(*username) > maxLength {
+		return fmt.Errorf("invalid username: must be at most %d bytes, got %d", maxLength, len(*username))
+	}
+	// Every ASCII whitespace character other than a space is a control character.
+	if strings.IndexFunc(*username, func(r rune) bool {
+		return r < ' ' || r == 0x7f
+	}) >= 0 {
+		return fmt.Errorf("invalid username %q: must not contain control or whitespace characters", *username)
+	}
+	return nil
+}
+
+// ValidateVars checks session vars before they are sent: keys must be non-empty and not in
+// ReservedVarKeys, and the keys and values together must not exceed MaxVarsBytes.
+func ValidateVars(vars map[string]string) error {
+	size := 0
+	for key, value := range vars {
+		if key == "" {
+			return errors.New("invalid vars: keys must not be empty")
+		}
+		if slices.Contains(ReservedVarKeys, key) {
+			return fmt.Errorf("invalid vars: key %q is reserved", key)
+		}
+		size += len(key) + len(value)
+	}
+	if size > MaxVarsBytes {
+		return fmt.Errorf("invalid vars: %d bytes exceeds the %d byte limit", size, MaxVarsBytes)
+	}
+	return nil
+}
+
+// ValidateServerKey checks that a server key is usable as the Basic auth username for
+// authentication requests: it must be non-empty and must not contain a colon.
+func ValidateServerKey(serverKey string) error {
+	if serverKey == "" {
+		return fmt.Errorf("invalid server key: must not be empty, set it to the server's --socket.server_key value")
+	}
+	if strings.Contains(serverKey, ":") {
+		return fmt.Errorf("invalid server key: must not contain ':'")
+	}
+	return nil
+}
+
+// ValidateStorageObjects checks storage objects locally before they are written: each value must
+// encode to JSON of at most MaxStorageValueBytes, and permissions must be one of the PermissionRead
+// and PermissionWrite constants.
+// It does not perform any network call.
+func ValidateStorageObjects(objects []WriteStorageObject) error {
+	for i, object := range objects {
+		if object.Collection == nil || *object.Collection == "" {
+			return fmt.Errorf("invalid storage object %d: collection is required", i)
+		}
+		if object.Key == nil || *object.Key == "" {
+			return fmt.Errorf("invalid storage object %d: key is required", i)
+		}
+
+		value := []byte(object.RawValue)
+		if object.RawValue == "" {
+			var err error
+			if value, err = json.Marshal(object.Value); err != nil {
+				return fmt.Errorf("invalid storage object %d (%s/%s): value is not valid JSON: %w", i, *object.Collection, *object.Key, err)
+			}
+		} else if !json.Valid(value) {
+			return fmt.Errorf("invalid storage object %d (%s/%s): raw value is not valid JSON", i, *object.Collection, *object.Key)
+		}
+		if len(value) > MaxStorageValueBytes {
+			return fmt.Errorf("invalid storage object %d (%s/%s): value is %d bytes, must be at most %d", i, *object.Collection, *object.Key, len(value), MaxStorageValueBytes)
+		}
+	}
+	return validateStoragePermissions(objects)
+}
+
+// Helper function to check that storage objects only set permissions a client may set.
+func validateStoragePermissions(objects []WriteStorageObject) error {
+	for i, object := range objects {
+		if object.PermissionRead != nil && (*object.PermissionRead < PermissionReadNoAccess || *object.PermissionRead > PermissionReadPublic) {
+			return fmt.Errorf("invalid storage object %d (%s/%s): %w: permission read must be one of the PermissionRead constants, got %d", i, stringValue(object.Collection), stringValue(object.Key), ErrStoragePermission, *object.PermissionRead)
+		}
+		if object.PermissionWrite != nil && (*object.PermissionWrite < PermissionWriteNoAccess || *object.PermissionWrite > PermissionWriteOwner) {
+			return fmt.Errorf("invalid storage object %d (%s/%s): %w: permission write must be one of the PermissionWrite constants, got %d", i, stringValue(object.Collection), stringValue(object.Key), ErrStoragePermission, *object.PermissionWrite)
+		}
+	}
+	return nil
+}
+
+// ReadIds builds the IDs of the current user's storage objects with the given keys in a collection.
+func ReadIds(collection string, keys ...string) []ApiReadStorageObjectId {
+	return UserReadIds("", collection, keys...)
+}
+
+// UserReadIds builds the IDs of another user's storage objects with the given keys in a collection.
+// An empty userId means the current user.
+func UserReadIds(userId, collection string, keys ...string) []ApiReadStorageObjectId {
+	ids := make([]ApiReadStorageObjectId, len(keys))
+	for i, key := range keys {
+		ids[i] = ApiReadStorageObjectId{Collection: &collection, Key: &key}
+		if userId != "" {
+			ids[i].UserID = &userId
+		}
+	}
+	return ids
+}
+
+// DeleteIds builds the IDs of the current user's storage objects with the given keys in a collection,
+// deleted regardless of their version.
+func DeleteIds(collection string, keys ...string) []ApiDeleteStorageObjectId {
+	ids := make([]ApiDeleteStorageObjectId, len(keys))
+	for i, key := range keys {
+		ids[i] = ApiDeleteStorageObjectId{Collection: &collection, Key: &key}
+	}
+	return ids
+}
+
+// StorageWrites builds a batch of storage object writes:
+//
+//	objects := nakama.NewStorageWrites("saves").
+//		Put("slot1", slot1).Permissions(nakama.PermissionReadOwner, nakama.PermissionWriteOwner).
+//		Put("slot2", slot2).Version(version).
+//		Objects()
+//
+// Permissions and Version apply to the object added by the preceding Put.
+type StorageWrites struct {
+	collection string
+	objects    []WriteStorageObject
+}
+
+// NewStorageWrites starts a batch of writes to a collection.
+func NewStorageWrites(collection string) *StorageWrites {
+	return &StorageWrites{collection: collection}
+}
+
+// Collection sets the collection of the objects added by the following calls to Put.
+func (b *StorageWrites) Collection(collection string) *StorageWrites {
+	b.collection = collection
+	return b
+}
+
+// Put adds an object with the given key and value.
+func (b *StorageWrites) Put(key string, value map[string]interface{}) *StorageWrites {
+	collection := b.collection
+	b.objects = append(b.objects, WriteStorageObject{Collection: &collection, Key: &key, Value: value})
+	return b
+}
+
+// Permissions sets the read and write permissions of the last object added.
+func (b *StorageWrites) Permissions(read, write int) *StorageWrites {
+	if last := b.last(); last != nil {
+		last.PermissionRead = &read
+		last.PermissionWrite = &write
+	}
+	return b
+}
+
+// Version sets the version of the last object added, so that it is only written if unchanged. A
+// version of "*" only writes the object if it does not exist yet.
+func (b *StorageWrites) Version(version string) *StorageWrites {
+	if last := b.last(); last != nil {
+		last.Version = &version
+	}
+	return b
+}
+
+// Objects returns the objects added, ready to pass to WriteStorageObjects.
+func (b *StorageWrites) Objects() []WriteStorageObject {
+	return slices.Clone(b.objects)
+}
+
+// Helper function to return the last object added to the batch, or nil if it is empty.
+func (b *StorageWrites) last() *WriteStorageObject {
+	if len(b.objects) == 0 {
+		return nil
+	}
+	return &b.objects[len(b.objects)-1]
+}
+
+// Helper function to deep copy a decoded JSON object, so that callers cannot modify a cached value.
+func cloneJSONObject(object map[string]interface{}) map[string]interface{} {
+	if object == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		clone[key] = cloneJSONValue(value)
+	}
+	return clone
+}
+
+// Helper function to deep copy a decoded JSON value.
+func cloneJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return cloneJSONObject(v)
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, element := range v {
+			clone[i] = cloneJSONValue(element)
+		}
+		return clone
+	default:
+		return v
+	}
+}
+
+// Helper function to generate a random idempotency key
+func generateIdempotencyKey() string {
+	var key [16]byte
+	_, _ = rand.Read(key[:])
+	return hex.EncodeToString(key[:])
+}
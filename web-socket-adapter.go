@@ -3,28 +3,181 @@ package nakama
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coder/websocket"
 )
 
+// DefaultReadLimit is the default largest message, in bytes, a WebSocketAdapter accepts from the server.
+const DefaultReadLimit = 1 << 20
+
+// ConnectionState describes the lifecycle state of a WebSocket connection.
+type ConnectionState int
+
+const (
+	ConnectionStateDisconnected ConnectionState = iota
+	ConnectionStateConnecting
+	ConnectionStateConnected
+	ConnectionStateReconnecting
+	ConnectionStateClosing
+)
+
+// String returns a human-readable name for the connection state.
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionStateDisconnected:
+		return "disconnected"
+	case ConnectionStateConnecting:
+		return "connecting"
+	case ConnectionStateConnected:
+		return "connected"
+	case ConnectionStateReconnecting:
+		return "reconnecting"
+	case ConnectionStateClosing:
+		return "closing"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int(s))
+	}
+}
+
+// CloseEvent describes why a WebSocket connection closed. It is also an error, which unwraps to the
+// error that ended the connection, so that errors.Is(event, ErrMessageTooLarge) works.
+type CloseEvent struct {
+	Code   int    // The close code, e.g. 1000 for a normal closure or 1008 for a policy violation, or -1 if the connection dropped without a close frame.
+	Reason string // The reason sent with the close code, e.g. why the server kicked the session.
+	Local  bool   // Whether the client closed the connection, rather than the server or the network.
+	Err    error  // The error that ended the connection, or nil for a close by Disconnect.
+}
+
+// Error implements the error interface.
+func (e *CloseEvent) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("websocket closed with code %d: %v", e.Code, e.Err)
+	}
+	return fmt.Sprintf("websocket closed with code %d", e.Code)
+}
+
+// Unwrap returns the error that ended the connection.
+func (e *CloseEvent) Unwrap() error {
+	return e.Err
+}
+
+// newCloseEvent returns the CloseEvent of a connection whose read failed with err, parsing the close
+// frame if the server sent one.
+func newCloseEvent(err error) *CloseEvent {
+	event := &CloseEvent{Code: int(websocket.CloseStatus(err)), Err: err}
+	var closeError websocket.CloseError
+	if errors.As(err, &closeError) {
+		event.Reason = closeError.Reason
+	}
+	if tooLarge := messageTooLargeError(err); tooLarge != nil {
+		event.Err = tooLarge
+		if event.Code == -1 {
+			// The read limit was exceeded locally, and the websocket package closed the connection.
+			event.Code = int(websocket.StatusMessageTooBig)
+			event.Local = true
+		}
+	}
+	return event
+}
+
+// localCloseEvent returns the CloseEvent of a connection closed by Close.
+func localCloseEvent() *CloseEvent {
+	return &CloseEvent{Code: int(websocket.StatusNormalClosure), Reason: localCloseReason, Local: true}
+}
+
+// localCloseReason is the reason sent with the close frame by Close.
+const localCloseReason = "Client closed connection"
+
 // WebSocketAdapter is a text-based WebSocket adapter for transmitting payloads over UTF-8.
 type WebSocketAdapter struct {
-	socket    *websocket.Conn
-	onClose   func(err error)
-	onError   func(err error)
-	onMessage func(message []byte)
-	onOpen    func(event interface{}) error
-	mu        sync.Mutex // To guard websocket connection reference
+	socket        *websocket.Conn
+	state         ConnectionState
+	onClose       func(event *CloseEvent)
+	onError       func(err error)
+	onMessage     func(message map[string]interface{})
+	onOpen        func(event interface{}) error
+	onStateChange func(old, new ConnectionState)
+	Logger        Logger       // The logger used by the adapter. Defaults to a no-op logger.
+	HTTPClient    *http.Client // The HTTP client used for the WebSocket handshake, for example to customise TLS.
+	ReadLimit     int64        // The largest message accepted from the server, in bytes. Zero uses DefaultReadLimit, -1 disables the limit.
+	Codec         Codec        // The JSON encoding of messages, including their decoding by DefaultSocket. Defaults to encoding/json.
+	mu            sync.Mutex   // To guard websocket connection reference and state
+	attempts      int          // Counts connection attempts, so that a dial aborted by Close is told from a later one.
+	stats         adapterStats
+}
+
+// AdapterStats counts the traffic of a WebSocketAdapter since it was created, across reconnects.
+type AdapterStats struct {
+	MessagesSent     int64 // Messages written to the server.
+	MessagesReceived int64 // Messages read from the server, including ones that failed to decode.
+	BytesSent        int64 // Encoded bytes of the messages sent.
+	BytesReceived    int64 // Encoded bytes of the messages received.
+	Reconnects       int64 // Successful reconnects by DefaultSocket.Reconnect.
+}
+
+// adapterStats holds the counters behind AdapterStats.
+type adapterStats struct {
+	messagesSent     atomic.Int64
+	messagesReceived atomic.Int64
+	bytesSent        atomic.Int64
+	bytesReceived    atomic.Int64
+	reconnects       atomic.Int64
 }
 
 // NewWebSocketAdapterText creates a new instance of WebSocketAdapter.
 func NewWebSocketAdapterText() *WebSocketAdapter {
-	return &WebSocketAdapter{}
+	return &WebSocketAdapter{Logger: NoopLogger{}, ReadLimit: DefaultReadLimit}
+}
+
+// SetReadLimit sets the largest message, in bytes, accepted from the server, including on the
+// current connection. Messages are reassembled from their frames before the limit is applied. A
+// larger message closes the connection and the close is reported with ErrMessageTooLarge.
+//
+// The limit only covers messages received. The server enforces its own limit on messages sent to it,
+// socket.max_message_size_bytes in the Nakama configuration (4096 bytes by default), and closes the
+// connection when it is exceeded, which is also reported with ErrMessageTooLarge.
+func (w *WebSocketAdapter) SetReadLimit(n int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ReadLimit = n
+	if w.socket != nil {
+		w.socket.SetReadLimit(w.readLimit())
+	}
+}
+
+// readLimit returns the effective read limit. The caller must hold the lock.
+func (w *WebSocketAdapter) readLimit() int64 {
+	if w.ReadLimit == 0 {
+		return DefaultReadLimit
+	}
+	return w.ReadLimit
+}
+
+// logger returns the configured logger, or a no-op logger if none is set.
+func (w *WebSocketAdapter) logger() Logger {
+	return loggerOrNoop(w.Logger)
+}
+
+// Stats returns a snapshot of the adapter's traffic counters.
+func (w *WebSocketAdapter) Stats() AdapterStats {
+	return AdapterStats{
+		MessagesSent:     w.stats.messagesSent.Load(),
+		MessagesReceived: w.stats.messagesReceived.Load(),
+		BytesSent:        w.stats.bytesSent.Load(),
+		BytesReceived:    w.stats.bytesReceived.Load(),
+		Reconnects:       w.stats.reconnects.Load(),
+	}
 }
 
 // IsOpen determines if the WebSocket connection is open.
@@ -34,20 +187,65 @@ func (w *WebSocketAdapter) IsOpen() bool {
 	return w.socket != nil
 }
 
-// Close closes the WebSocket connection.
-func (w *WebSocketAdapter) Close() {
+// State returns the current connection state.
+func (w *WebSocketAdapter) State() ConnectionState {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	if w.socket != nil {
-		_ = w.socket.Close(websocket.StatusNormalClosure, "Client closed connection")
-		w.socket = nil
+	return w.state
+}
+
+// OnStateChange registers a callback invoked once for every connection state transition.
+// The callback runs after the adapter lock is released, so it may safely call back into the adapter.
+func (w *WebSocketAdapter) OnStateChange(callback func(old, new ConnectionState)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onStateChange = callback
+}
+
+// Close closes the WebSocket connection. A connection attempt in progress is aborted.
+func (w *WebSocketAdapter) Close() {
+	w.mu.Lock()
+	socket := w.socket
+	if socket == nil {
+		aborted := func() {}
+		if w.state == ConnectionStateConnecting {
+			aborted = w.transition(ConnectionStateDisconnected)
+		}
+		w.mu.Unlock()
+		aborted()
+		return
 	}
+	w.socket = nil
+	closing := w.transition(ConnectionStateClosing)
+	w.mu.Unlock()
+	closing()
+
+	_ = socket.Close(websocket.StatusNormalClosure, localCloseReason)
+
+	w.setState(ConnectionStateDisconnected)
 }
 
 // Connect connects to the WebSocket using the specified arguments.
 func (w *WebSocketAdapter) Connect(scheme, host, port string, createStatus bool, token string) error {
+	return w.ConnectContext(context.Background(), scheme, host, port, createStatus, token)
+}
+
+// ConnectContext connects to the WebSocket using the specified arguments. The context bounds the
+// dial and handshake, so a cancelled context or an expired deadline aborts a stalled connection attempt.
+// It fails if the adapter is already connecting or connected, and a Close during the dial aborts it.
+func (w *WebSocketAdapter) ConnectContext(ctx context.Context, scheme, host, port string, createStatus bool, token string) error {
 	w.mu.Lock()
-	defer w.mu.Unlock()
+	if w.state == ConnectionStateConnecting || w.state == ConnectionStateConnected {
+		state := w.state
+		w.mu.Unlock()
+		return fmt.Errorf("WebSocket is already %s", state)
+	}
+	connecting := w.transition(ConnectionStateConnecting)
+	w.attempts++
+	attempt := w.attempts
+	options := &websocket.DialOptions{HTTPClient: w.HTTPClient}
+	w.mu.Unlock()
+	connecting()
 
 	urlStr := fmt.Sprintf("%s%s:%s/ws?lang=en&status=%s&token=%s",
 		scheme,
@@ -57,20 +255,58 @@ func (w *WebSocketAdapter) Connect(scheme, host, port string, createStatus bool,
 		url.QueryEscape(token),
 	)
 
-	var err error
+	socket, _, err := websocket.Dial(ctx, urlStr, options)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	w.socket, _, err = websocket.Dial(ctx, urlStr, nil)
+	w.mu.Lock()
+	if w.state != ConnectionStateConnecting || w.attempts != attempt {
+		// Close was called during the dial.
+		w.mu.Unlock()
+		if err == nil {
+			_ = socket.Close(websocket.StatusNormalClosure, localCloseReason)
+		}
+		return errors.New("WebSocket connect aborted by Close")
+	}
 	if err != nil {
+		failed := w.transition(ConnectionStateDisconnected)
+		w.mu.Unlock()
+		failed()
 		return err
 	}
+	socket.SetReadLimit(w.readLimit())
+	w.socket = socket
+	connected := w.transition(ConnectionStateConnected)
+	w.mu.Unlock()
+	connected()
 
-	//go w.listen()
+	go w.listen(socket)
 
 	return nil
 }
 
+// setState moves the adapter to the given state and notifies the state-change callback.
+func (w *WebSocketAdapter) setState(state ConnectionState) {
+	w.mu.Lock()
+	notify := w.transition(state)
+	w.mu.Unlock()
+	notify()
+}
+
+// transition updates the state while the caller holds the lock and returns a function that
+// emits the change notification. The returned function must be called after the lock is released.
+func (w *WebSocketAdapter) transition(state ConnectionState) func() {
+	old := w.state
+	if old == state {
+		return func() {}
+	}
+	w.state = state
+	callback := w.onStateChange
+	return func() {
+		if callback != nil {
+			callback(old, state)
+		}
+	}
+}
+
 // Send sends a message through the WebSocket connection.
 func (w *WebSocketAdapter) Send(message interface{}) error {
 	w.mu.Lock()
@@ -86,18 +322,19 @@ func (w *WebSocketAdapter) Send(message interface{}) error {
 	//	handleEncodedData(msgMap, "party_data_send")
 	//}
 
-	msgBytes, err := json.Marshal(message)
+	msgBytes, err := codecOrStd(w.Codec).Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	err = w.socket.Write(ctx, websocket.MessageText, msgBytes)
 	if err != nil {
 		return err
 	}
+	w.stats.messagesSent.Add(1)
+	w.stats.bytesSent.Add(int64(len(msgBytes)))
 
 	return nil
 }
@@ -122,29 +359,40 @@ func (w *WebSocketAdapter) Read() ([]byte, error) {
 	return message, nil
 }
 
-// listen listens for messages or errors from the WebSocket server.
-func (w *WebSocketAdapter) listen() {
+// listen listens for messages or errors from the WebSocket server until the connection closes.
+func (w *WebSocketAdapter) listen(socket *websocket.Conn) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	for {
-		_, message, err := w.socket.Read(ctx)
+		_, message, err := socket.Read(ctx)
 		if err != nil {
 			w.mu.Lock()
-			socket := w.socket
+			current := w.socket
+			onClose := w.onClose
 			w.mu.Unlock()
 
-			if socket != nil {
-				closeStatus := websocket.CloseStatus(err)
-				fmt.Printf("WebSocket closed with status: %d\n", closeStatus)
+			// Only report the close if the connection was not closed locally.
+			if current == socket {
+				event := newCloseEvent(err)
+				if errors.Is(event.Err, ErrMessageTooLarge) {
+					w.logger().Error("WebSocket message too large", "error", err)
+				} else {
+					w.logger().Info("WebSocket closed", "status", event.Code, "reason", event.Reason)
+				}
 
 				w.Close()
+				if onClose != nil {
+					onClose(event)
+				}
 			}
 			break
 		}
+		w.stats.messagesReceived.Add(1)
+		w.stats.bytesReceived.Add(int64(len(message)))
 
 		var decodedMessage map[string]interface{}
-		if err := json.Unmarshal(message, &decodedMessage); err != nil {
-			fmt.Printf("Error unmarshalling WebSocket message: %v\n", err)
+		if err := codecOrStd(w.Codec).Unmarshal(message, &decodedMessage); err != nil {
+			w.logger().Error("Error unmarshalling WebSocket message", "error", err)
 			continue
 		}
 
@@ -152,15 +400,27 @@ func (w *WebSocketAdapter) listen() {
 		decodeReceivedData(decodedMessage, "match_data")
 		decodeReceivedData(decodedMessage, "party_data")
 
-		messageBytes, err := json.Marshal(decodedMessage)
-		if err == nil {
-			w.onMessage(messageBytes)
-		} else if w.onError != nil {
-			w.onError(err)
+		w.mu.Lock()
+		onMessage := w.onMessage
+		w.mu.Unlock()
+
+		// Deliver the decoded envelope as-is so binary data fields are not re-encoded.
+		if onMessage != nil {
+			onMessage(decodedMessage)
 		}
 	}
 }
 
+// messageTooLargeError returns err wrapped with ErrMessageTooLarge if the connection was closed
+// because a message exceeded the read limit or the server's limit, or nil otherwise.
+func messageTooLargeError(err error) error {
+	// The websocket package reports its own read limit only through the error text.
+	if websocket.CloseStatus(err) == websocket.StatusMessageTooBig || strings.Contains(err.Error(), "read limited at") {
+		return fmt.Errorf("%w: %w", ErrMessageTooLarge, err)
+	}
+	return nil
+}
+
 // handleEncodedData handles encoding of match_data_send and party_data_send fields.
 func handleEncodedData(msg map[string]interface{}, field string) {
 	if sendData, exists := msg[field]; exists {
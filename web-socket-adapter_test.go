@@ -0,0 +1,284 @@
+package nakama
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupWebSocketServer starts a WebSocket server that hands every accepted connection to handler.
+func setupWebSocketServer(t *testing.T, handler func(conn *websocket.Conn)) (string, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	hostPort := strings.TrimPrefix(server.URL, "http://")
+	parts := strings.Split(hostPort, ":")
+	return parts[0], parts[1]
+}
+
+func TestWebSocketAdapter_StateTransitions(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	var transitions []ConnectionState
+	adapter := NewWebSocketAdapterText()
+	adapter.OnStateChange(func(old, new ConnectionState) {
+		transitions = append(transitions, new)
+	})
+
+	err := adapter.Connect("ws://", host, port, false, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, ConnectionStateConnected, adapter.State())
+
+	adapter.Close()
+	adapter.Close()
+
+	assert.Equal(t, ConnectionStateDisconnected, adapter.State())
+	assert.Equal(t, []ConnectionState{
+		ConnectionStateConnecting,
+		ConnectionStateConnected,
+		ConnectionStateClosing,
+		ConnectionStateDisconnected,
+	}, transitions)
+}
+
+// recordingLogger captures log messages and their attributes for assertions.
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+	args     [][]any
+}
+
+func (l *recordingLogger) record(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, level+": "+msg)
+	l.args = append(l.args, args)
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.record("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.record("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.record("error", msg, args) }
+
+func (l *recordingLogger) Messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.messages...)
+}
+
+// Attr returns the value of the named attribute of the i-th message.
+func (l *recordingLogger) Attr(i int, key string) any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	args := l.args[i]
+	for j := 0; j+1 < len(args); j += 2 {
+		if args[j] == key {
+			return args[j+1]
+		}
+	}
+	return nil
+}
+
+func TestWebSocketAdapter_LogsMalformedMessages(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		_ = conn.Write(context.Background(), websocket.MessageText, []byte("not json"))
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	logger := &recordingLogger{}
+	adapter := NewWebSocketAdapterText()
+	adapter.Logger = logger
+
+	err := adapter.Connect("ws://", host, port, false, "token")
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(logger.Messages()) > 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "error: Error unmarshalling WebSocket message", logger.Messages()[0])
+}
+
+func TestWebSocketAdapter_DeliversDecodedMatchData(t *testing.T) {
+	payload := []byte{0x00, 0xff, 0x10, 0x80}
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		message := `{"match_data":{"match_id":"m","op_code":"1","data":"` + base64.StdEncoding.EncodeToString(payload) + `"}}`
+		_ = conn.Write(context.Background(), websocket.MessageText, []byte(message))
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	received := make(chan map[string]interface{}, 1)
+	adapter := NewWebSocketAdapterText()
+	adapter.onMessage = func(message map[string]interface{}) {
+		received <- message
+	}
+
+	err := adapter.Connect("ws://", host, port, false, "token")
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	select {
+	case message := <-received:
+		matchData := message["match_data"].(map[string]interface{})
+		assert.Equal(t, payload, matchData["data"])
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestWebSocketAdapter_ReadLimit(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		message := `{"match_data":{"match_id":"m","op_code":"1","data":"` + strings.Repeat("A", 2048) + `"}}`
+		_ = conn.Write(context.Background(), websocket.MessageText, []byte(message))
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	closed := make(chan error, 1)
+	adapter := NewWebSocketAdapterText()
+	adapter.SetReadLimit(1024)
+	adapter.onClose = func(event *CloseEvent) {
+		closed <- event
+	}
+
+	err := adapter.Connect("ws://", host, port, false, "token")
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for close")
+	}
+	assert.Equal(t, ConnectionStateDisconnected, adapter.State())
+}
+
+func TestWebSocketAdapter_ReassemblesFragmentedMessages(t *testing.T) {
+	payload := []byte(strings.Repeat("fragment", 16*1024))
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		writer, err := conn.Writer(context.Background(), websocket.MessageText)
+		if err != nil {
+			return
+		}
+		// Each write below is flushed as its own continuation frame.
+		message := `{"match_data":{"match_id":"m","op_code":"1","data":"` + base64.StdEncoding.EncodeToString(payload) + `"}}`
+		for len(message) > 0 {
+			chunk := min(len(message), 8*1024)
+			_, _ = writer.Write([]byte(message[:chunk]))
+			message = message[chunk:]
+		}
+		_ = writer.Close()
+		_, _, _ = conn.Read(context.Background())
+	})
+
+	received := make(chan map[string]interface{}, 1)
+	adapter := NewWebSocketAdapterText()
+	adapter.onMessage = func(message map[string]interface{}) {
+		received <- message
+	}
+
+	err := adapter.Connect("ws://", host, port, false, "token")
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	select {
+	case message := <-received:
+		matchData := message["match_data"].(map[string]interface{})
+		assert.Equal(t, payload, matchData["data"])
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestWebSocketAdapter_Stats(t *testing.T) {
+	host, port := setupWebSocketServer(t, func(conn *websocket.Conn) {
+		for {
+			_, message, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			_ = conn.Write(context.Background(), websocket.MessageText, message)
+			_ = conn.Write(context.Background(), websocket.MessageText, []byte("not json"))
+		}
+	})
+
+	received := make(chan map[string]interface{}, 4)
+	adapter := NewWebSocketAdapterText()
+	adapter.onMessage = func(message map[string]interface{}) {
+		received <- message
+	}
+
+	err := adapter.Connect("ws://", host, port, false, "token")
+	assert.NoError(t, err)
+	assert.NoError(t, adapter.Send(map[string]interface{}{"ping": map[string]interface{}{}}))
+	<-received
+	assert.Eventually(t, func() bool {
+		return adapter.Stats().MessagesReceived == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, AdapterStats{MessagesSent: 1, MessagesReceived: 2, BytesSent: 11, BytesReceived: 19}, adapter.Stats())
+
+	adapter.Close()
+	socket := NewDefaultSocket(host, port, false, false, adapter, nil)
+	_, err = socket.Reconnect(Session{Token: "token"}, BackoffConfig{}, 1, nil)
+	assert.NoError(t, err)
+	defer socket.Disconnect(false)
+	assert.Equal(t, int64(1), adapter.Stats().Reconnects)
+	assert.Equal(t, int64(1), adapter.Stats().MessagesSent, "counters are kept across reconnects")
+}
+
+func TestWebSocketAdapter_CloseDuringConnect(t *testing.T) {
+	release := make(chan struct{})
+	serverClosed := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		_, _, _ = conn.Read(context.Background())
+		serverClosed <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	hostPort := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")
+
+	adapter := NewWebSocketAdapterText()
+	connected := make(chan error, 1)
+	go func() {
+		connected <- adapter.Connect("ws://", hostPort[0], hostPort[1], false, "token")
+	}()
+	assert.Eventually(t, func() bool {
+		return adapter.State() == ConnectionStateConnecting
+	}, time.Second, time.Millisecond)
+
+	err := adapter.Connect("ws://", hostPort[0], hostPort[1], false, "token")
+	assert.EqualError(t, err, "WebSocket is already connecting")
+
+	adapter.Close()
+	assert.Equal(t, ConnectionStateDisconnected, adapter.State())
+	close(release)
+
+	assert.Error(t, <-connected)
+	assert.False(t, adapter.IsOpen())
+	assert.Equal(t, ConnectionStateDisconnected, adapter.State())
+	select {
+	case <-serverClosed:
+	case <-time.After(time.Second):
+		t.Fatal("the connection dialled after Close was not closed")
+	}
+}